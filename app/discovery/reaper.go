@@ -0,0 +1,160 @@
+package discovery
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// OrphansStop stops the orphans
+	OrphansStop = "stop"
+	// OrphansAdopt creates a session with the default duration for the orphans
+	OrphansAdopt = "adopt"
+)
+
+// Reaper handles the orphans, the enabled instances running without a session.
+// Such instances are started by an external source, and would otherwise run forever.
+type Reaper struct {
+	provider   providers.Provider
+	bus        *events.Bus
+	registered func() []string
+	adopt      func(name string)
+	config     config.Provider
+	now        func() time.Time
+
+	// mu serializes the checks, the runs of successive leaderships might overlap
+	mu      sync.Mutex
+	orphans map[string]orphan
+}
+
+type orphan struct {
+	// since is the time at which the instance was first seen without a session
+	since time.Time
+	// reported is true once the action was reported in dry run mode
+	reported bool
+}
+
+// NewReaper creates a reaper of the orphans. registered returns the instances with a session,
+// adopt creates a session for an orphan.
+func NewReaper(provider providers.Provider, bus *events.Bus, registered func() []string, adopt func(name string), conf config.Provider) (*Reaper, error) {
+	if conf.Orphans.Policy != OrphansStop && conf.Orphans.Policy != OrphansAdopt {
+		return nil, fmt.Errorf("unknown orphans policy \"%s\", must be one of [%s %s]", conf.Orphans.Policy, OrphansStop, OrphansAdopt)
+	}
+
+	return &Reaper{
+		provider:   provider,
+		bus:        bus,
+		registered: registered,
+		adopt:      adopt,
+		config:     conf,
+		now:        time.Now,
+		orphans:    make(map[string]orphan),
+	}, nil
+}
+
+// Enabled returns true if the orphans are handled on startup or periodically
+func (r *Reaper) Enabled() bool {
+	return r.config.AutoStopOnStartup || r.config.Orphans.Interval > 0
+}
+
+// Startup handles all the orphans once when provider.auto-stop-on-startup is set
+func (r *Reaper) Startup(ctx context.Context) {
+	if r.config.AutoStopOnStartup {
+		r.reap(ctx, 0)
+	}
+}
+
+// Run handles the orphans at each interval until ctx is done, the orphans are only handled once
+// seen for the grace period. It returns right away when the interval is zero.
+func (r *Reaper) Run(ctx context.Context) {
+	if r.config.Orphans.Interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.config.Orphans.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			r.reap(ctx, r.config.Orphans.GracePeriod)
+		}
+	}
+}
+
+// reap handles the orphans seen for at least gracePeriod
+func (r *Reaper) reap(ctx context.Context, gracePeriod time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	instances, err := r.provider.InstanceList(ctx, providers.InstanceListOptions{
+		All:    false, // Only running containers
+		Labels: []string{LabelEnable},
+	})
+	if err != nil {
+		log.Warnf("could not list the instances to find the orphans: %v", err)
+		return
+	}
+
+	registered := make(map[string]struct{})
+	for _, name := range r.registered() {
+		registered[name] = struct{}{}
+	}
+
+	now := r.now()
+	orphans := make(map[string]orphan)
+	for _, instance := range instances {
+		if _, ok := registered[instance.Name]; ok {
+			continue
+		}
+
+		o, ok := r.orphans[instance.Name]
+		if !ok {
+			o = orphan{since: now}
+		}
+		if now.Sub(o.since) >= gracePeriod && !o.reported {
+			r.handle(ctx, instance.Name)
+			// In dry run mode, the orphan is still running and is only reported once
+			o.reported = r.config.Orphans.DryRun
+		}
+		orphans[instance.Name] = o
+	}
+	r.orphans = orphans
+}
+
+func (r *Reaper) handle(ctx context.Context, name string) {
+	message := ""
+	if r.config.Orphans.DryRun {
+		message = "dry run"
+	}
+
+	switch r.config.Orphans.Policy {
+	case OrphansAdopt:
+		if r.config.Orphans.DryRun {
+			log.Infof("%s is running without a session, it would be adopted (dry run)", name)
+		} else {
+			log.Infof("%s is running without a session, adopting it", name)
+			r.adopt(name)
+		}
+		r.bus.Publish(events.Event{Type: events.OrphanAdopted, Instance: name, Message: message})
+	default:
+		if r.config.Orphans.DryRun {
+			log.Infof("%s is running without a session, it would be stopped (dry run)", name)
+		} else {
+			log.Infof("%s is running without a session, stopping it", name)
+			if err := r.provider.Stop(ctx, name); err != nil {
+				log.Errorf("Could not stop %v: %v", name, err)
+				return
+			}
+		}
+		r.bus.Publish(events.Event{Type: events.OrphanStopped, Instance: name, Message: message})
+	}
+}
@@ -0,0 +1,110 @@
+package discovery
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/providers/mock"
+	"github.com/acouvreur/sablier/app/types"
+	"github.com/acouvreur/sablier/config"
+	"gotest.tools/v3/assert"
+)
+
+func newTestReaper(t *testing.T, orphans config.Orphans, adopt func(name string)) (*Reaper, *mock.ProviderMock, <-chan events.Event) {
+	provider := new(mock.ProviderMock)
+	provider.On("InstanceList", context.TODO(), providers.InstanceListOptions{
+		All:    false,
+		Labels: []string{LabelEnable},
+	}).Return([]types.Instance{{Name: "nginx"}, {Name: "apache"}}, nil)
+
+	bus := events.NewBus()
+	received, unsubscribe := bus.Subscribe(8)
+	t.Cleanup(unsubscribe)
+
+	conf := config.NewProviderConfig()
+	conf.Orphans = orphans
+	registered := func() []string { return []string{"nginx"} }
+	reaper, err := NewReaper(provider, bus, registered, adopt, conf)
+	assert.NilError(t, err)
+
+	return reaper, provider, received
+}
+
+func TestReaper_StopsOrphansAfterGracePeriod(t *testing.T) {
+	reaper, provider, received := newTestReaper(t, config.Orphans{Policy: OrphansStop}, nil)
+	provider.On("Stop", context.TODO(), "apache").Return(nil)
+	now := time.Now()
+	reaper.now = func() time.Time { return now }
+
+	reaper.reap(context.TODO(), time.Minute)
+	provider.AssertNotCalled(t, "Stop", context.TODO(), "apache")
+
+	now = now.Add(time.Minute)
+	reaper.reap(context.TODO(), time.Minute)
+	provider.AssertCalled(t, "Stop", context.TODO(), "apache")
+	provider.AssertNotCalled(t, "Stop", context.TODO(), "nginx")
+
+	event := <-received
+	assert.Equal(t, event.Type, events.OrphanStopped)
+	assert.Equal(t, event.Instance, "apache")
+}
+
+func TestReaper_AdoptsOrphans(t *testing.T) {
+	var adopted []string
+	reaper, provider, received := newTestReaper(t, config.Orphans{Policy: OrphansAdopt}, func(name string) {
+		adopted = append(adopted, name)
+	})
+
+	reaper.reap(context.TODO(), 0)
+
+	assert.DeepEqual(t, adopted, []string{"apache"})
+	provider.AssertNotCalled(t, "Stop", context.TODO(), "apache")
+	event := <-received
+	assert.Equal(t, event.Type, events.OrphanAdopted)
+}
+
+func TestReaper_DryRun(t *testing.T) {
+	reaper, provider, received := newTestReaper(t, config.Orphans{Policy: OrphansStop, DryRun: true}, nil)
+
+	reaper.reap(context.TODO(), 0)
+	reaper.reap(context.TODO(), 0)
+
+	provider.AssertNotCalled(t, "Stop", context.TODO(), "apache")
+	event := <-received
+	assert.Equal(t, event.Type, events.OrphanStopped)
+	assert.Equal(t, event.Message, "dry run")
+	// The orphan is only reported once
+	assert.Equal(t, len(received), 0)
+}
+
+func TestNewReaper_UnknownPolicy(t *testing.T) {
+	conf := config.NewProviderConfig()
+	conf.Orphans.Policy = "delete"
+
+	_, err := NewReaper(new(mock.ProviderMock), nil, nil, nil, conf)
+
+	assert.ErrorContains(t, err, "unknown orphans policy \"delete\"")
+}
+
+func TestReaper_OverlappingRuns(t *testing.T) {
+	reaper, provider, _ := newTestReaper(t, config.Orphans{Policy: OrphansStop, DryRun: true}, nil)
+
+	// The runs of two successive leaderships
+	done := make(chan struct{})
+	for i := 0; i < 2; i++ {
+		go func() {
+			defer func() { done <- struct{}{} }()
+			for j := 0; j < 10; j++ {
+				reaper.reap(context.TODO(), time.Minute)
+			}
+		}()
+	}
+	<-done
+	<-done
+
+	provider.AssertNotCalled(t, "Stop", context.TODO(), "apache")
+	assert.Equal(t, len(reaper.orphans), 1)
+}
@@ -6,6 +6,17 @@ const (
 	LabelGroupDefaultValue           = "default"
 	LabelReplicas                    = "sablier.replicas"
 	LabelReplicasDefaultValue uint64 = 1
+	LabelSessionDuration             = "sablier.session-duration"
+	LabelMaxLifetime                 = "sablier.max-lifetime"
+	LabelCoolDown                    = "sablier.cool-down"
+	LabelStartTimeout                = "sablier.start-timeout"
+	LabelDrainPeriod                 = "sablier.drain-period"
+	LabelPreStopHTTP                 = "sablier.pre-stop.http"
+	LabelPreStopExec                 = "sablier.pre-stop.exec"
+	LabelPoolSize                    = "sablier.pool-size"
+	LabelTheme                       = "sablier.theme"
+	LabelDisplayName                 = "sablier.display-name"
+	LabelCost                        = "sablier.cost"
 )
 
 type Group struct {
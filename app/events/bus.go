@@ -0,0 +1,86 @@
+package events
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// Bus dispatches the lifecycle events to its subscribers.
+//
+// Publishing never blocks: events are dropped for the subscribers whose buffer is full.
+// A nil *Bus is valid and discards all the events.
+type Bus struct {
+	mu          sync.RWMutex
+	subscribers map[*subscriber]struct{}
+}
+
+type subscriber struct {
+	events chan Event
+	types  map[Type]struct{}
+}
+
+func NewBus() *Bus {
+	return &Bus{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+// Publish sends the event to the subscribers interested in its type
+func (b *Bus) Publish(event Event) {
+	if b == nil {
+		return
+	}
+
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	for sub := range b.subscribers {
+		if !sub.accepts(event.Type) {
+			continue
+		}
+		select {
+		case sub.events <- event:
+		default:
+			log.Warnf("dropping event %s of %s, the subscriber is too slow", event.Type, event.Instance)
+		}
+	}
+}
+
+// Subscribe returns a channel receiving the events of the given types, or all of them if none is given.
+// The returned function unsubscribes and closes the channel.
+func (b *Bus) Subscribe(buffer int, types ...Type) (<-chan Event, func()) {
+	sub := &subscriber{
+		events: make(chan Event, buffer),
+		types:  make(map[Type]struct{}, len(types)),
+	}
+	for _, t := range types {
+		sub.types[t] = struct{}{}
+	}
+
+	b.mu.Lock()
+	b.subscribers[sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	return sub.events, func() {
+		once.Do(func() {
+			b.mu.Lock()
+			delete(b.subscribers, sub)
+			b.mu.Unlock()
+			close(sub.events)
+		})
+	}
+}
+
+func (s *subscriber) accepts(t Type) bool {
+	if len(s.types) == 0 {
+		return true
+	}
+	_, ok := s.types[t]
+	return ok
+}
@@ -0,0 +1,51 @@
+package events
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestBus_PublishFiltersTypes(t *testing.T) {
+	bus := NewBus()
+	all, unsubscribeAll := bus.Subscribe(10)
+	defer unsubscribeAll()
+	stopped, unsubscribeStopped := bus.Subscribe(10, InstanceStopped)
+	defer unsubscribeStopped()
+
+	bus.Publish(Event{Type: InstanceStarting, Instance: "nginx"})
+	bus.Publish(Event{Type: InstanceStopped, Instance: "nginx"})
+
+	assert.Equal(t, len(all), 2)
+	assert.Equal(t, len(stopped), 1)
+	event := <-stopped
+	assert.Equal(t, event.Type, InstanceStopped)
+	assert.Assert(t, !event.Time.IsZero())
+}
+
+func TestBus_PublishDoesNotBlock(t *testing.T) {
+	bus := NewBus()
+	received, unsubscribe := bus.Subscribe(1)
+
+	bus.Publish(Event{Type: InstanceReady, Instance: "nginx"})
+	bus.Publish(Event{Type: InstanceReady, Instance: "apache"})
+
+	assert.Equal(t, len(received), 1)
+	unsubscribe()
+	unsubscribe()
+	bus.Publish(Event{Type: InstanceReady, Instance: "nginx"})
+}
+
+func TestBus_NilBus(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Type: InstanceReady, Instance: "nginx"})
+}
+
+func TestParseTypes(t *testing.T) {
+	types, err := ParseTypes([]string{"instance.ready", "session.expired"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, types, []Type{InstanceReady, SessionExpired})
+
+	_, err = ParseTypes([]string{"instance.exploded"})
+	assert.ErrorContains(t, err, "unknown event type")
+}
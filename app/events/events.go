@@ -0,0 +1,74 @@
+package events
+
+import (
+	"fmt"
+	"time"
+)
+
+// Type is the type of a lifecycle event
+type Type string
+
+const (
+	// SessionRequested is emitted when a session is requested for an instance without session
+	SessionRequested Type = "session.requested"
+	// InstanceStarting is emitted when the provider is asked to start an instance
+	InstanceStarting Type = "instance.starting"
+	// InstanceReady is emitted when an instance of a session becomes ready
+	InstanceReady Type = "instance.ready"
+	// InstanceUnrecoverable is emitted when an instance cannot be started
+	InstanceUnrecoverable Type = "instance.unrecoverable"
+	// SessionExpired is emitted when a session expires, before the instance is stopped
+	SessionExpired Type = "session.expired"
+	// InstanceStopped is emitted when the provider notifies that an instance stopped,
+	// whether it was stopped by Sablier or by an external source
+	InstanceStopped Type = "instance.stopped"
+	// InstanceStopFailed is emitted when the instance of an expired session could not be stopped after all the retries
+	InstanceStopFailed Type = "instance.stop-failed"
+	// OrphanStopped is emitted when an enabled instance running without a session is stopped
+	OrphanStopped Type = "orphan.stopped"
+	// OrphanAdopted is emitted when a session is created for an enabled instance running without a session
+	OrphanAdopted Type = "orphan.adopted"
+)
+
+// Types lists all the event types
+var Types = []Type{
+	SessionRequested,
+	InstanceStarting,
+	InstanceReady,
+	InstanceUnrecoverable,
+	SessionExpired,
+	InstanceStopped,
+	InstanceStopFailed,
+	OrphanStopped,
+	OrphanAdopted,
+}
+
+// Event is a lifecycle event of an instance
+type Event struct {
+	Type     Type      `json:"type"`
+	Instance string    `json:"instance"`
+	Time     time.Time `json:"time"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// ParseTypes validates the event type names
+func ParseTypes(names []string) ([]Type, error) {
+	types := make([]Type, 0, len(names))
+	for _, name := range names {
+		t := Type(name)
+		if !t.valid() {
+			return nil, fmt.Errorf("unknown event type \"%s\", must be one of %v", name, Types)
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+func (t Type) valid() bool {
+	for _, known := range Types {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
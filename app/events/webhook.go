@@ -0,0 +1,144 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/acouvreur/sablier/config"
+	"github.com/acouvreur/sablier/version"
+	log "github.com/sirupsen/logrus"
+)
+
+// SignatureHeader holds the HMAC-SHA256 of the payload, computed with the webhook secret
+const SignatureHeader = "X-Sablier-Signature"
+
+// WebhookPayload is the JSON body posted to the webhook
+type WebhookPayload struct {
+	Events []Event `json:"events"`
+}
+
+// Webhook delivers batches of events to an HTTP endpoint
+type Webhook struct {
+	config config.Webhook
+	client *http.Client
+}
+
+func NewWebhook(conf config.Webhook) *Webhook {
+	return &Webhook{
+		config: conf,
+		client: &http.Client{Timeout: conf.Timeout},
+	}
+}
+
+// Run delivers the received events until the channel is closed or the context is done.
+// Events are sent once BatchSize events are buffered or after FlushInterval.
+func (w *Webhook) Run(ctx context.Context, events <-chan Event) {
+	batchSize := max(w.config.BatchSize, 1)
+	flushInterval := w.config.FlushInterval
+	if flushInterval <= 0 {
+		flushInterval = time.Second
+	}
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	batch := make([]Event, 0, batchSize)
+	flush := func(ctx context.Context) {
+		if len(batch) == 0 {
+			return
+		}
+		if err := w.Deliver(ctx, batch); err != nil {
+			log.Warnf("could not deliver %d events to the webhook: %v", len(batch), err)
+		}
+		batch = make([]Event, 0, batchSize)
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			// Best effort delivery of the pending events
+			shutdownCtx, cancel := context.WithTimeout(context.Background(), w.config.Timeout)
+			flush(shutdownCtx)
+			cancel()
+			return
+		case event, ok := <-events:
+			if !ok {
+				flush(ctx)
+				return
+			}
+			batch = append(batch, event)
+			if len(batch) >= batchSize {
+				flush(ctx)
+			}
+		case <-ticker.C:
+			flush(ctx)
+		}
+	}
+}
+
+// Deliver posts the events, retrying with an exponential backoff on failures
+func (w *Webhook) Deliver(ctx context.Context, events []Event) error {
+	body, err := json.Marshal(WebhookPayload{Events: events})
+	if err != nil {
+		return err
+	}
+
+	backoff := w.config.RetryInterval
+	for attempt := 0; ; attempt++ {
+		retry, err := w.post(ctx, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= w.config.MaxRetries {
+			return err
+		}
+
+		log.Debugf("webhook delivery failed (attempt %d): %v, retrying in %v", attempt+1, err, backoff)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
+
+// post sends the payload and returns whether a failure is worth retrying
+func (w *Webhook) post(ctx context.Context, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, w.config.URL, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "sablier/"+version.Version)
+	if w.config.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(w.config.Secret, body))
+	}
+
+	resp, err := w.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+	return retry, fmt.Errorf("webhook returned %s", resp.Status)
+}
+
+// Sign returns the signature of the payload, formatted as "sha256=<hex digest>"
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,101 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/config"
+	"gotest.tools/v3/assert"
+)
+
+func newWebhookConfig(url string) config.Webhook {
+	conf := config.NewEventsConfig().Webhook
+	conf.URL = url
+	conf.Secret = "secret"
+	conf.RetryInterval = time.Millisecond
+	return conf
+}
+
+func TestWebhook_DeliverSignsPayload(t *testing.T) {
+	payloads := make(chan WebhookPayload, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, r.Header.Get(SignatureHeader), Sign("secret", body))
+		var payload WebhookPayload
+		assert.NilError(t, json.Unmarshal(body, &payload))
+		payloads <- payload
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(newWebhookConfig(server.URL))
+	err := webhook.Deliver(context.Background(), []Event{{Type: InstanceReady, Instance: "nginx"}})
+
+	assert.NilError(t, err)
+	payload := <-payloads
+	assert.Equal(t, len(payload.Events), 1)
+	assert.Equal(t, payload.Events[0].Instance, "nginx")
+}
+
+func TestWebhook_DeliverRetries(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(newWebhookConfig(server.URL))
+	err := webhook.Deliver(context.Background(), []Event{{Type: InstanceReady, Instance: "nginx"}})
+
+	assert.NilError(t, err)
+	assert.Equal(t, calls.Load(), int32(3))
+}
+
+func TestWebhook_DeliverDoesNotRetryClientErrors(t *testing.T) {
+	var calls atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer server.Close()
+
+	webhook := NewWebhook(newWebhookConfig(server.URL))
+	err := webhook.Deliver(context.Background(), []Event{{Type: InstanceReady, Instance: "nginx"}})
+
+	assert.ErrorContains(t, err, "400")
+	assert.Equal(t, calls.Load(), int32(1))
+}
+
+func TestWebhook_RunBatchesEvents(t *testing.T) {
+	payloads := make(chan WebhookPayload, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload WebhookPayload
+		assert.NilError(t, json.NewDecoder(r.Body).Decode(&payload))
+		payloads <- payload
+	}))
+	defer server.Close()
+
+	conf := newWebhookConfig(server.URL)
+	conf.BatchSize = 2
+	conf.FlushInterval = time.Hour
+	webhook := NewWebhook(conf)
+
+	received := make(chan Event, 3)
+	received <- Event{Type: InstanceStarting, Instance: "nginx"}
+	received <- Event{Type: InstanceReady, Instance: "nginx"}
+	received <- Event{Type: SessionExpired, Instance: "nginx"}
+	close(received)
+
+	webhook.Run(context.Background(), received)
+
+	assert.Equal(t, len(payloads), 2)
+	assert.Equal(t, len((<-payloads).Events), 2)
+	assert.Equal(t, len((<-payloads).Events), 1)
+}
@@ -9,11 +9,16 @@ import (
 
 type Health struct {
 	TerminatingStatusCode int `description:"Terminating status code" json:"terminatingStatusCode,omitempty" yaml:"terminatingStatusCode,omitempty" export:"true"`
-	terminating           bool
+	DegradedStatusCode    int `description:"Degraded provider status code" json:"degradedStatusCode,omitempty" yaml:"degradedStatusCode,omitempty" export:"true"`
+	// Degraded reports whether the provider is degraded, nil if it cannot tell
+	Degraded    func() bool
+	terminating bool
 }
 
 func (h *Health) SetDefaults() {
 	h.TerminatingStatusCode = http.StatusServiceUnavailable
+	// A degraded provider is reported without failing the health check, Sablier itself is still serving
+	h.DegradedStatusCode = http.StatusOK
 }
 
 func (h *Health) WithContext(ctx context.Context) {
@@ -24,10 +29,15 @@ func (h *Health) WithContext(ctx context.Context) {
 }
 
 func (h *Health) ServeHTTP(c *gin.Context) {
-	statusCode := http.StatusOK
 	if h.terminating {
-		statusCode = h.TerminatingStatusCode
+		c.String(h.TerminatingStatusCode, http.StatusText(h.TerminatingStatusCode))
+		return
 	}
 
-	c.String(statusCode, http.StatusText(statusCode))
+	if h.Degraded != nil && h.Degraded() {
+		c.String(h.DegradedStatusCode, "Provider Degraded")
+		return
+	}
+
+	c.String(http.StatusOK, http.StatusText(http.StatusOK))
 }
@@ -0,0 +1,39 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestHealth_ServeHTTP(t *testing.T) {
+	tests := []struct {
+		name        string
+		terminating bool
+		degraded    func() bool
+		wantCode    int
+		wantBody    string
+	}{
+		{name: "healthy", wantCode: http.StatusOK, wantBody: "OK"},
+		{name: "healthy provider", degraded: func() bool { return false }, wantCode: http.StatusOK, wantBody: "OK"},
+		{name: "degraded provider", degraded: func() bool { return true }, wantCode: http.StatusOK, wantBody: "Provider Degraded"},
+		{name: "terminating", terminating: true, degraded: func() bool { return true }, wantCode: http.StatusServiceUnavailable, wantBody: "Service Unavailable"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			health := Health{Degraded: tt.degraded}
+			health.SetDefaults()
+			health.terminating = tt.terminating
+
+			recorder := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(recorder)
+			health.ServeHTTP(c)
+
+			assert.Equal(t, recorder.Code, tt.wantCode)
+			assert.Equal(t, recorder.Body.String(), tt.wantBody)
+		})
+	}
+}
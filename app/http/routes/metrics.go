@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/gin-gonic/gin"
+)
+
+type ServeMetrics struct {
+	SessionsManager sessions.Manager
+}
+
+func NewServeMetrics(sessionsManager sessions.Manager) *ServeMetrics {
+	return &ServeMetrics{
+		SessionsManager: sessionsManager,
+	}
+}
+
+// Get returns the metrics in the Prometheus text format
+func (s *ServeMetrics) Get(c *gin.Context) {
+	stopFailed := 0
+	for _, session := range s.SessionsManager.ListSessions() {
+		if session.Status == instance.StopFailed {
+			stopFailed++
+		}
+	}
+
+	c.Header("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	c.String(http.StatusOK,
+		"# HELP sablier_stop_failed_instances Number of instances which could not be stopped after all the retries.\n"+
+			"# TYPE sablier_stop_failed_instances gauge\n"+
+			"sablier_stop_failed_instances %d\n", stopFailed)
+}
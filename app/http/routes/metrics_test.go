@@ -0,0 +1,32 @@
+package routes
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions"
+	"gotest.tools/v3/assert"
+)
+
+func TestServeMetrics_Get(t *testing.T) {
+	manager := &SessionsListManagerMock{
+		Sessions: []sessions.SessionInfo{
+			{Name: "apache", Status: instance.StopFailed},
+			{Name: "nginx", Status: instance.Ready},
+			{Name: "whoami", Status: instance.StopFailed},
+		},
+	}
+	s := NewServeMetrics(manager)
+	recorder := httptest.NewRecorder()
+	c := GetTestGinContext(recorder)
+
+	s.Get(c)
+
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	assert.Equal(t, recorder.Body.String(),
+		"# HELP sablier_stop_failed_instances Number of instances which could not be stopped after all the retries.\n"+
+			"# TYPE sablier_stop_failed_instances gauge\n"+
+			"sablier_stop_failed_instances 2\n")
+}
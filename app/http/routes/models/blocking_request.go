@@ -5,6 +5,7 @@ import "time"
 type BlockingRequest struct {
 	Names           []string      `form:"names"`
 	Group           string        `form:"group"`
+	Claim           string        `form:"claim"`
 	SessionDuration time.Duration `form:"session_duration"`
 	Timeout         time.Duration `form:"timeout"`
 }
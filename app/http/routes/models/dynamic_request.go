@@ -6,6 +6,7 @@ import (
 
 type DynamicRequest struct {
 	Group            string        `form:"group"`
+	Claim            string        `form:"claim"`
 	Names            []string      `form:"names"`
 	ShowDetails      bool          `form:"show_details"`
 	DisplayName      string        `form:"display_name"`
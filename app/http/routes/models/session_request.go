@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type SessionRequest struct {
+	Names    []string      `form:"names"`
+	Group    string        `form:"group"`
+	Duration time.Duration `form:"duration"`
+}
+
+type SessionsListRequest struct {
+	Group  string `form:"group"`
+	Status string `form:"status"`
+}
@@ -0,0 +1,27 @@
+package routes
+
+import (
+	"net/http"
+
+	"github.com/acouvreur/sablier/app/prewarm"
+	"github.com/gin-gonic/gin"
+)
+
+type PredictionsProvider interface {
+	Predictions() []prewarm.PrewarmStatus
+}
+
+type ServePredictions struct {
+	Prewarmer PredictionsProvider
+}
+
+func NewServePredictions(prewarmer PredictionsProvider) *ServePredictions {
+	return &ServePredictions{
+		Prewarmer: prewarmer,
+	}
+}
+
+// List returns the predicted first requests of the day
+func (s *ServePredictions) List(c *gin.Context) {
+	c.JSON(http.StatusOK, map[string]interface{}{"predictions": s.Prewarmer.Predictions()})
+}
@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/prewarm"
+	"gotest.tools/v3/assert"
+)
+
+type PredictionsProviderMock struct {
+	statuses []prewarm.PrewarmStatus
+}
+
+func (p *PredictionsProviderMock) Predictions() []prewarm.PrewarmStatus {
+	return p.statuses
+}
+
+func TestServePredictions_List(t *testing.T) {
+	expected := time.Date(2024, 10, 21, 9, 0, 0, 0, time.UTC)
+	s := NewServePredictions(&PredictionsProviderMock{statuses: []prewarm.PrewarmStatus{{
+		Prediction: prewarm.Prediction{Instance: "nginx", Expected: expected, Confidence: 1, Samples: 4},
+		WarmAt:     expected.Add(-2 * time.Minute),
+		Eligible:   true,
+	}}})
+
+	recorder := httptest.NewRecorder()
+	c := GetTestGinContext(recorder)
+
+	s.List(c)
+
+	assert.Equal(t, recorder.Code, http.StatusOK)
+	var body struct {
+		Predictions []prewarm.PrewarmStatus `json:"predictions"`
+	}
+	assert.NilError(t, json.NewDecoder(recorder.Body).Decode(&body))
+	assert.Equal(t, len(body.Predictions), 1)
+	assert.Equal(t, body.Predictions[0].Instance, "nginx")
+	assert.Assert(t, body.Predictions[0].Expected.Equal(expected))
+}
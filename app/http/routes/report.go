@@ -0,0 +1,57 @@
+package routes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/acouvreur/sablier/app/savings"
+	"github.com/gin-gonic/gin"
+)
+
+// defaultReportWindow is the window of the report when none is requested
+const defaultReportWindow = 7 * 24 * time.Hour
+
+type ReportProvider interface {
+	Report(ctx context.Context, from time.Time, to time.Time) savings.Report
+}
+
+type ServeReport struct {
+	Reporter ReportProvider
+}
+
+func NewServeReport(reporter ReportProvider) *ServeReport {
+	return &ServeReport{
+		Reporter: reporter,
+	}
+}
+
+// Get returns the uptime avoided per instance over the requested window, as JSON or CSV
+func (s *ServeReport) Get(c *gin.Context) {
+	window := defaultReportWindow
+	if value := c.Query("window"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			c.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{"error": fmt.Sprintf("invalid window \"%s\"", value)})
+			return
+		}
+		window = min(parsed, savings.Retention)
+	}
+
+	to := time.Now()
+	report := s.Reporter.Report(c.Request.Context(), to.Add(-window), to)
+
+	switch format := c.DefaultQuery("format", "json"); format {
+	case "json":
+		c.JSON(http.StatusOK, map[string]interface{}{"report": report})
+	case "csv":
+		c.Header("Content-Type", "text/csv")
+		c.Status(http.StatusOK)
+		if err := report.WriteCSV(c.Writer); err != nil {
+			c.Error(err)
+		}
+	default:
+		c.AbortWithStatusJSON(http.StatusBadRequest, map[string]interface{}{"error": fmt.Sprintf("unknown format \"%s\", must be one of [json csv]", format)})
+	}
+}
@@ -0,0 +1,64 @@
+package routes
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/savings"
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+type ReportProviderMock struct {
+	window time.Duration
+}
+
+func (m *ReportProviderMock) Report(_ context.Context, from time.Time, to time.Time) savings.Report {
+	m.window = to.Sub(from)
+	return savings.Report{
+		From:      from,
+		To:        to,
+		Instances: []savings.InstanceReport{{Instance: "nginx", ManagedSeconds: 3600, StoppedSeconds: 3600, SavedRatio: 1}},
+		Savings:   map[string]float64{},
+	}
+}
+
+func TestServeReport_Get(t *testing.T) {
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		window     time.Duration
+		body       string
+	}{
+		{name: "default", url: "/api/report", statusCode: http.StatusOK, window: 7 * 24 * time.Hour, body: `"instance":"nginx"`},
+		{name: "window", url: "/api/report?window=1h", statusCode: http.StatusOK, window: time.Hour, body: `"savedRatio":1`},
+		{name: "window capped to the retention", url: "/api/report?window=8760h", statusCode: http.StatusOK, window: savings.Retention, body: `"instance":"nginx"`},
+		{name: "csv", url: "/api/report?format=csv", statusCode: http.StatusOK, window: 7 * 24 * time.Hour, body: "\nnginx,"},
+		{name: "invalid window", url: "/api/report?window=week", statusCode: http.StatusBadRequest},
+		{name: "unknown format", url: "/api/report?format=xml", statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reporter := &ReportProviderMock{}
+			r := gin.New()
+			r.GET("/api/report", NewServeReport(reporter).Get)
+
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, recorder.Code, tt.statusCode)
+			if tt.statusCode != http.StatusOK {
+				assert.Assert(t, json.Valid(recorder.Body.Bytes()))
+				return
+			}
+			assert.Equal(t, reporter.window, tt.window)
+			assert.Assert(t, strings.Contains(recorder.Body.String(), tt.body), recorder.Body.String())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/acouvreur/sablier/app/http/routes/models"
+	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/config"
+	"github.com/gin-gonic/gin"
+)
+
+type ServeSessions struct {
+	SessionsManager sessions.Manager
+	SessionsConfig  config.Sessions
+}
+
+func NewServeSessions(sessionsManager sessions.Manager, sessionsConf config.Sessions) *ServeSessions {
+	return &ServeSessions{
+		SessionsManager: sessionsManager,
+		SessionsConfig:  sessionsConf,
+	}
+}
+
+// List returns all the sessions, optionally filtered by group and status
+func (s *ServeSessions) List(c *gin.Context) {
+	request := models.SessionsListRequest{}
+
+	if err := c.ShouldBind(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return
+	}
+
+	sessionsInfo := []sessions.SessionInfo{}
+	for _, session := range s.SessionsManager.ListSessions() {
+		if request.Group != "" && session.Group != request.Group {
+			continue
+		}
+		if request.Status != "" && session.Status != request.Status {
+			continue
+		}
+		sessionsInfo = append(sessionsInfo, session)
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{"sessions": sessionsInfo})
+}
+
+// Get returns the session of a single instance
+func (s *ServeSessions) Get(c *gin.Context) {
+	// Instance names may contain slashes, the name is a catch-all parameter
+	name := strings.TrimPrefix(c.Param("name"), "/")
+	if name == "" {
+		s.List(c)
+		return
+	}
+
+	session, ok := s.SessionsManager.GetSession(name)
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{"session": session})
+}
+
+// Extend adds the duration to the sessions
+func (s *ServeSessions) Extend(c *gin.Context) {
+	request, names, ok := s.bind(c)
+	if !ok {
+		return
+	}
+
+	s.respond(c, s.SessionsManager.ExtendSession(names, request.Duration))
+}
+
+// Stop stops the instances now
+func (s *ServeSessions) Stop(c *gin.Context) {
+	_, names, ok := s.bind(c)
+	if !ok {
+		return
+	}
+
+	s.respond(c, s.SessionsManager.StopSession(c.Request.Context(), names))
+}
+
+// Pin keeps the sessions alive until they are unpinned
+func (s *ServeSessions) Pin(c *gin.Context) {
+	_, names, ok := s.bind(c)
+	if !ok {
+		return
+	}
+
+	s.respond(c, s.SessionsManager.PinSession(names))
+}
+
+// Unpin lets the sessions expire after the duration
+func (s *ServeSessions) Unpin(c *gin.Context) {
+	request, names, ok := s.bind(c)
+	if !ok {
+		return
+	}
+
+	s.respond(c, s.SessionsManager.UnpinSession(names, request.Duration))
+}
+
+func (s *ServeSessions) bind(c *gin.Context) (models.SessionRequest, []string, bool) {
+	request := models.SessionRequest{
+		Duration: s.SessionsConfig.DefaultDuration,
+	}
+
+	if err := c.ShouldBind(&request); err != nil {
+		c.AbortWithError(http.StatusBadRequest, err)
+		return request, nil, false
+	}
+
+	names := request.Names
+	if len(names) == 0 && request.Group != "" {
+		names = s.SessionsManager.Groups().Get(request.Group)
+	}
+
+	if len(names) == 0 {
+		c.AbortWithStatus(http.StatusNotFound)
+		return request, nil, false
+	}
+
+	return request, names, true
+}
+
+func (s *ServeSessions) respond(c *gin.Context, sessionState *sessions.SessionState) {
+	if sessionState == nil {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{"session": sessionState})
+}
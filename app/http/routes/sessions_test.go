@@ -0,0 +1,90 @@
+package routes
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/config"
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+type SessionsListManagerMock struct {
+	Sessions []sessions.SessionInfo
+	sessions.Manager
+}
+
+func (s *SessionsListManagerMock) ListSessions() []sessions.SessionInfo {
+	return s.Sessions
+}
+
+func (s *SessionsListManagerMock) GetSession(name string) (sessions.SessionInfo, bool) {
+	for _, session := range s.Sessions {
+		if session.Name == name {
+			return session, true
+		}
+	}
+	return sessions.SessionInfo{}, false
+}
+
+func TestServeSessions_List(t *testing.T) {
+	manager := &SessionsListManagerMock{
+		Sessions: []sessions.SessionInfo{
+			{Name: "apache", Group: "default", Status: instance.Ready},
+			{Name: "deployment/default/nginx/1", Group: "internal", Status: instance.NotReady},
+			{Name: "whoami", Group: "default", Status: instance.NotReady},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		want       []string
+	}{
+		{name: "all sessions", url: "/api/sessions", statusCode: http.StatusOK, want: []string{"apache", "deployment/default/nginx/1", "whoami"}},
+		{name: "filtered by group", url: "/api/sessions?group=default", statusCode: http.StatusOK, want: []string{"apache", "whoami"}},
+		{name: "filtered by group and status", url: "/api/sessions?group=default&status=not-ready", statusCode: http.StatusOK, want: []string{"whoami"}},
+		{name: "single session", url: "/api/sessions/deployment/default/nginx/1", statusCode: http.StatusOK, want: []string{"deployment/default/nginx/1"}},
+		{name: "unknown session", url: "/api/sessions/unknown", statusCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			control := NewServeSessions(manager, config.NewSessionsConfig())
+			r := gin.New()
+			r.GET("/api/sessions", control.List)
+			r.GET("/api/sessions/*name", control.Get)
+
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.url, nil))
+			res := recorder.Result()
+			defer res.Body.Close()
+
+			assert.Equal(t, res.StatusCode, tt.statusCode)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			body, _ := io.ReadAll(res.Body)
+			var got struct {
+				Sessions []sessions.SessionInfo `json:"sessions"`
+				Session  *sessions.SessionInfo  `json:"session"`
+			}
+			assert.NilError(t, json.Unmarshal(body, &got))
+			if got.Session != nil {
+				got.Sessions = append(got.Sessions, *got.Session)
+			}
+
+			var names []string
+			for _, session := range got.Sessions {
+				names = append(names, session.Name)
+			}
+			assert.DeepEqual(t, names, tt.want)
+		})
+	}
+}
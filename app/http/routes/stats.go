@@ -0,0 +1,47 @@
+package routes
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/acouvreur/sablier/app/stats"
+	"github.com/gin-gonic/gin"
+)
+
+type StatsProvider interface {
+	Stats() []stats.InstanceStats
+	InstanceStats(name string) (stats.InstanceStats, bool)
+}
+
+type ServeStats struct {
+	Tracker StatsProvider
+}
+
+func NewServeStats(tracker StatsProvider) *ServeStats {
+	return &ServeStats{
+		Tracker: tracker,
+	}
+}
+
+// List returns the recent transitions and the start latency of all the instances
+func (s *ServeStats) List(c *gin.Context) {
+	c.JSON(http.StatusOK, map[string]interface{}{"stats": s.Tracker.Stats()})
+}
+
+// Get returns the recent transitions and the start latency of an instance
+func (s *ServeStats) Get(c *gin.Context) {
+	// Instance names may contain slashes, the name is a catch-all parameter
+	name := strings.TrimPrefix(c.Param("name"), "/")
+	if name == "" {
+		s.List(c)
+		return
+	}
+
+	instanceStats, ok := s.Tracker.InstanceStats(name)
+	if !ok {
+		c.AbortWithStatus(http.StatusNotFound)
+		return
+	}
+
+	c.JSON(http.StatusOK, map[string]interface{}{"stats": instanceStats})
+}
@@ -0,0 +1,132 @@
+package routes
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/app/stats"
+	"github.com/gin-gonic/gin"
+	"gotest.tools/v3/assert"
+)
+
+func TestServeStats(t *testing.T) {
+	tracker := stats.NewTracker()
+	now := time.Now()
+	tracker.Record(events.Event{Type: events.InstanceStarting, Instance: "deployment/default/nginx/1", Time: now})
+	tracker.Record(events.Event{Type: events.InstanceReady, Instance: "deployment/default/nginx/1", Time: now.Add(25 * time.Second)})
+
+	tests := []struct {
+		name       string
+		url        string
+		statusCode int
+		want       string
+	}{
+		{name: "all instances", url: "/api/stats", statusCode: http.StatusOK, want: `{"stats":[{"instance":"deployment/default/nginx/1","startLatency":{"p50Ms":25000,"p90Ms":25000,"p99Ms":25000,"samples":1}}]}`},
+		{name: "single instance", url: "/api/stats/deployment/default/nginx/1", statusCode: http.StatusOK, want: `{"stats":{"instance":"deployment/default/nginx/1","startLatency":{"p50Ms":25000,"p90Ms":25000,"p99Ms":25000,"samples":1}}}`},
+		{name: "unknown instance", url: "/api/stats/unknown", statusCode: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewServeStats(tracker)
+			r := gin.New()
+			r.GET("/api/stats", s.List)
+			r.GET("/api/stats/*name", s.Get)
+
+			recorder := httptest.NewRecorder()
+			r.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, tt.url, nil))
+
+			assert.Equal(t, recorder.Code, tt.statusCode)
+			if tt.statusCode != http.StatusOK {
+				return
+			}
+
+			// The transitions are compared apart from the latency
+			var got map[string]any
+			assert.NilError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+			removeTransitions(got["stats"])
+			body, _ := json.Marshal(got)
+			assert.Equal(t, string(body), tt.want)
+		})
+	}
+}
+
+func removeTransitions(value any) {
+	switch v := value.(type) {
+	case []any:
+		for _, item := range v {
+			removeTransitions(item)
+		}
+	case map[string]any:
+		delete(v, "transitions")
+	}
+}
+
+type StartLatencyMock map[string]time.Duration
+
+func (m StartLatencyMock) ExpectedStartDuration(name string) (time.Duration, bool) {
+	d, ok := m[name]
+	return d, ok
+}
+
+func TestServeStrategy_expectedReady(t *testing.T) {
+	now := time.Now()
+	earlier := now.Add(-10 * time.Second)
+
+	session := func(states ...instance.State) *sessions.SessionState {
+		instances := &sync.Map{}
+		for i := range states {
+			instances.Store(states[i].Name, sessions.InstanceState{Instance: &states[i]})
+		}
+		return &sessions.SessionState{Instances: instances}
+	}
+	latency := StartLatencyMock{"nginx": 20 * time.Second, "apache": 40 * time.Second}
+
+	tests := []struct {
+		name          string
+		latency       StartLatencyProvider
+		session       *sessions.SessionState
+		wantStartedAt time.Time
+		wantReadyAt   time.Time
+	}{
+		{
+			name:    "without start latency",
+			session: session(instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &now}),
+		},
+		{
+			name:    "unknown instance",
+			latency: latency,
+			session: session(instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &now}, instance.State{Name: "whoami", Status: instance.NotReady, StartedAt: &now}),
+		},
+		{
+			name:          "slowest instance",
+			latency:       latency,
+			session:       session(instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &earlier}, instance.State{Name: "apache", Status: instance.NotReady, StartedAt: &now}),
+			wantStartedAt: earlier,
+			wantReadyAt:   now.Add(40 * time.Second),
+		},
+		{
+			name:          "ready instances are ignored",
+			latency:       latency,
+			session:       session(instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &now}, instance.State{Name: "apache", Status: instance.Ready, StartedAt: &earlier}),
+			wantStartedAt: now,
+			wantReadyAt:   now.Add(20 * time.Second),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &ServeStrategy{StartLatency: tt.latency}
+
+			startedAt, readyAt := s.expectedReady(tt.session)
+
+			assert.Assert(t, startedAt.Equal(tt.wantStartedAt))
+			assert.Assert(t, readyAt.Equal(tt.wantReadyAt))
+		})
+	}
+}
@@ -3,12 +3,14 @@ package routes
 import (
 	"bufio"
 	"bytes"
+	"errors"
 	"fmt"
 	"net/http"
 	"os"
 	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
@@ -28,6 +30,12 @@ type ServeStrategy struct {
 	SessionsManager sessions.Manager
 	StrategyConfig  config.Strategy
 	SessionsConfig  config.Sessions
+	// StartLatency gives the usual start duration of the instances, nil if unknown
+	StartLatency StartLatencyProvider
+}
+
+type StartLatencyProvider interface {
+	ExpectedStartDuration(name string) (time.Duration, bool)
 }
 
 func NewServeStrategy(sessionsManager sessions.Manager, strategyConf config.Strategy, sessionsConf config.Sessions, themes *theme.Themes) *ServeStrategy {
@@ -58,8 +66,20 @@ func (s *ServeStrategy) ServeDynamic(c *gin.Context) {
 	var sessionState *sessions.SessionState
 	if len(request.Names) > 0 {
 		sessionState = s.SessionsManager.RequestSession(request.Names, request.SessionDuration)
+	} else if request.Claim != "" {
+		var err error
+		sessionState, err = s.SessionsManager.ClaimSession(request.Group, request.Claim, request.SessionDuration)
+		if err != nil {
+			c.AbortWithError(claimErrorStatus(err), err)
+			return
+		}
 	} else {
-		sessionState = s.SessionsManager.RequestSessionGroup(request.Group, request.SessionDuration)
+		var err error
+		sessionState, err = s.SessionsManager.RequestSessionGroup(request.Group, request.SessionDuration)
+		if err != nil {
+			c.AbortWithError(claimErrorStatus(err), err)
+			return
+		}
 	}
 
 	if sessionState == nil {
@@ -72,14 +92,26 @@ func (s *ServeStrategy) ServeDynamic(c *gin.Context) {
 	} else {
 		c.Header("X-Sablier-Session-Status", "not-ready")
 	}
+	setAssignedInstance(c, sessionState)
+
+	// Instances policies take precedence over the request
+	if sessionState.Theme != "" {
+		request.Theme = sessionState.Theme
+	}
+	if sessionState.DisplayName != "" {
+		request.DisplayName = sessionState.DisplayName
+	}
 
 	renderOptions := theme.Options{
 		DisplayName:      request.DisplayName,
 		ShowDetails:      request.ShowDetails,
-		SessionDuration:  request.SessionDuration,
+		SessionDuration:  sessionState.SessionDuration,
 		RefreshFrequency: request.RefreshFrequency,
 		InstanceStates:   sessionStateToRenderOptionsInstanceState(sessionState),
+		HardStopAt:       sessionState.HardStopAt,
+		ProviderDegraded: sessionState.ProviderDegraded,
 	}
+	renderOptions.StartedAt, renderOptions.ExpectedReadyAt = s.expectedReady(sessionState)
 
 	buf := new(bytes.Buffer)
 	writer := bufio.NewWriter(buf)
@@ -110,8 +142,18 @@ func (s *ServeStrategy) ServeBlocking(c *gin.Context) {
 	var err error
 	if len(request.Names) > 0 {
 		sessionState, err = s.SessionsManager.RequestReadySession(c.Request.Context(), request.Names, request.SessionDuration, request.Timeout)
+	} else if request.Claim != "" {
+		sessionState, err = s.SessionsManager.ClaimReadySession(c.Request.Context(), request.Group, request.Claim, request.SessionDuration, request.Timeout)
+		if err != nil && (errors.Is(err, sessions.ErrNotAPool) || errors.Is(err, sessions.ErrPoolExhausted)) {
+			c.AbortWithError(claimErrorStatus(err), err)
+			return
+		}
 	} else {
 		sessionState, err = s.SessionsManager.RequestReadySessionGroup(c.Request.Context(), request.Group, request.SessionDuration, request.Timeout)
+		if errors.Is(err, sessions.ErrClaimRequired) {
+			c.AbortWithError(claimErrorStatus(err), err)
+			return
+		}
 	}
 
 	if err != nil {
@@ -135,10 +177,65 @@ func (s *ServeStrategy) ServeBlocking(c *gin.Context) {
 	} else {
 		c.Header("X-Sablier-Session-Status", "not-ready")
 	}
+	setAssignedInstance(c, sessionState)
 
 	c.JSON(http.StatusOK, map[string]interface{}{"session": sessionState})
 }
 
+// AssignedInstanceHeader tells the proxy which instance of a pool was assigned to the request
+const AssignedInstanceHeader = "X-Sablier-Assigned-Instance"
+
+func setAssignedInstance(c *gin.Context, sessionState *sessions.SessionState) {
+	if sessionState.Assigned != "" {
+		c.Header(AssignedInstanceHeader, sessionState.Assigned)
+	}
+}
+
+func claimErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, sessions.ErrNotAPool), errors.Is(err, sessions.ErrClaimRequired):
+		return http.StatusBadRequest
+	case errors.Is(err, sessions.ErrPoolExhausted):
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// expectedReady returns when the first of the starting instances started and when the last of them is usually ready.
+// Both are zero if the start latency of one of them is unknown.
+func (s *ServeStrategy) expectedReady(sessionState *sessions.SessionState) (startedAt time.Time, readyAt time.Time) {
+	if s.StartLatency == nil {
+		return time.Time{}, time.Time{}
+	}
+
+	known := true
+	sessionState.Instances.Range(func(key, value any) bool {
+		state := value.(sessions.InstanceState).Instance
+		if state == nil || state.IsReady() || state.StartedAt == nil {
+			return true
+		}
+
+		expected, ok := s.StartLatency.ExpectedStartDuration(state.Name)
+		if !ok {
+			known = false
+			return false
+		}
+		if startedAt.IsZero() || state.StartedAt.Before(startedAt) {
+			startedAt = *state.StartedAt
+		}
+		if ready := state.StartedAt.Add(expected); ready.After(readyAt) {
+			readyAt = ready
+		}
+		return true
+	})
+
+	if !known {
+		return time.Time{}, time.Time{}
+	}
+	return startedAt, readyAt
+}
+
 func sessionStateToRenderOptionsInstanceState(sessionState *sessions.SessionState) (instances []theme.Instance) {
 	if sessionState == nil {
 		log.Warnf("sessionStateToRenderOptionsInstanceState: sessionState is nil")
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -35,6 +36,10 @@ func (s *SessionsManagerMock) RequestReadySession(ctx context.Context, names []s
 	return &s.SessionState, nil
 }
 
+func (s *SessionsManagerMock) ClaimSession(group string, claim string, duration time.Duration) (*sessions.SessionState, error) {
+	return &s.SessionState, nil
+}
+
 func (s *SessionsManagerMock) LoadSessions(io.ReadCloser) error {
 	return nil
 }
@@ -109,6 +114,25 @@ func TestServeStrategy_ServeDynamic(t *testing.T) {
 			expectedHeaderKey:   "X-Sablier-Session-Status",
 			expectedHeaderValue: "ready",
 		},
+		{
+			name: "header has the instance assigned by the pool",
+			arg: arg{
+				body: models.DynamicRequest{
+					Group:           "preview",
+					Claim:           "alice",
+					Theme:           "hacker-terminal",
+					SessionDuration: 1 * time.Minute,
+				},
+				session: sessions.SessionState{
+					Instances: createMap([]*instance.State{
+						{Name: "preview-1", Status: instance.Ready},
+					}),
+					Assigned: "preview-1",
+				},
+			},
+			expectedHeaderKey:   "X-Sablier-Assigned-Instance",
+			expectedHeaderValue: "preview-1",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
@@ -216,6 +240,31 @@ func TestServeStrategy_ServeBlocking(t *testing.T) {
 	}
 }
 
+type PoolSessionsManagerMock struct {
+	SessionsManagerMock
+}
+
+func (s *PoolSessionsManagerMock) RequestSessionGroup(group string, duration time.Duration) (*sessions.SessionState, error) {
+	return nil, fmt.Errorf("%w: %s", sessions.ErrClaimRequired, group)
+}
+
+func TestServeStrategy_ServeDynamicPoolWithoutClaim(t *testing.T) {
+	theme, err := theme.NewWithCustomThemes(fstest.MapFS{})
+	assert.NilError(t, err)
+	s := &ServeStrategy{
+		SessionsManager: &PoolSessionsManagerMock{},
+		StrategyConfig:  config.NewStrategyConfig(),
+		Theme:           theme,
+	}
+	recorder := httptest.NewRecorder()
+	c := GetTestGinContext(recorder)
+	MockJsonPost(c, models.DynamicRequest{Group: "preview"})
+
+	s.ServeDynamic(c)
+
+	assert.Equal(t, recorder.Code, http.StatusBadRequest)
+}
+
 // mock gin context
 func GetTestGinContext(w *httptest.ResponseRecorder) *gin.Context {
 	gin.SetMode(gin.TestMode)
@@ -12,13 +12,17 @@ import (
 
 	"github.com/acouvreur/sablier/app/http/middleware"
 	"github.com/acouvreur/sablier/app/http/routes"
+	"github.com/acouvreur/sablier/app/prewarm"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/savings"
 	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/app/stats"
 	"github.com/acouvreur/sablier/app/theme"
 	"github.com/acouvreur/sablier/config"
 	"github.com/gin-gonic/gin"
 )
 
-func Start(serverConf config.Server, strategyConf config.Strategy, sessionsConf config.Sessions, sessionManager sessions.Manager, t *theme.Themes) {
+func Start(serverConf config.Server, strategyConf config.Strategy, sessionsConf config.Sessions, sessionManager sessions.Manager, t *theme.Themes, prewarmer *prewarm.Prewarmer, provider providers.HealthReporter, tracker *stats.Tracker, reporter *savings.Reporter) {
 
 	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
 	defer stop()
@@ -32,12 +36,43 @@ func Start(serverConf config.Server, strategyConf config.Strategy, sessionsConf
 		api := base.Group("/api")
 		{
 			strategy := routes.NewServeStrategy(sessionManager, strategyConf, sessionsConf, t)
+			strategy.StartLatency = tracker
 			api.GET("/strategies/dynamic", strategy.ServeDynamic)
 			api.GET("/strategies/dynamic/themes", strategy.ServeDynamicThemes)
 			api.GET("/strategies/blocking", strategy.ServeBlocking)
+
+			control := routes.NewServeSessions(sessionManager, sessionsConf)
+			api.GET("/sessions", control.List)
+			api.GET("/sessions/*name", control.Get)
+			// The endpoints acting on the sessions are only exposed to the holders of the token
+			if serverConf.SessionsControlToken != "" {
+				authorized := api.Group("/sessions", middleware.BearerToken(serverConf.SessionsControlToken))
+				authorized.POST("/extend", control.Extend)
+				authorized.POST("/stop", control.Stop)
+				authorized.POST("/pin", control.Pin)
+				authorized.POST("/unpin", control.Unpin)
+			}
+
+			statistics := routes.NewServeStats(tracker)
+			api.GET("/stats", statistics.List)
+			api.GET("/stats/*name", statistics.Get)
+
+			report := routes.NewServeReport(reporter)
+			api.GET("/report", report.Get)
+
+			if prewarmer != nil {
+				predictions := routes.NewServePredictions(prewarmer)
+				api.GET("/predictions", predictions.List)
+			}
 		}
+		metrics := routes.NewServeMetrics(sessionManager)
+		base.GET("/metrics", metrics.Get)
+
 		health := routes.Health{}
 		health.SetDefaults()
+		if provider != nil {
+			health.Degraded = provider.Degraded
+		}
 		health.WithContext(ctx)
 		base.GET("/health", health.ServeHTTP)
 	}
@@ -1,10 +1,16 @@
 package instance
 
-import log "github.com/sirupsen/logrus"
+import (
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
 
 var Ready = "ready"
 var NotReady = "not-ready"
 var Unrecoverable = "unrecoverable"
+var Draining = "draining"
+var StopFailed = "stop-failed"
 
 type State struct {
 	Name            string `json:"name"`
@@ -12,6 +18,16 @@ type State struct {
 	DesiredReplicas int32  `json:"desiredReplicas"`
 	Status          string `json:"status"`
 	Message         string `json:"message,omitempty"`
+	// Pinned sessions never expire until they are unpinned
+	Pinned bool `json:"pinned,omitempty"`
+	// StartedAt is when the instance was started for the current session
+	StartedAt *time.Time `json:"startedAt,omitempty"`
+	// LastRequestedAt is when the session was last requested
+	LastRequestedAt *time.Time `json:"lastRequestedAt,omitempty"`
+	// Standby instances are kept warm in their pool until they are claimed
+	Standby bool `json:"standby,omitempty"`
+	// Claim is the key of the request which claimed the pool instance
+	Claim string `json:"claim,omitempty"`
 }
 
 func (instance State) IsReady() bool {
@@ -0,0 +1,26 @@
+//go:build !unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+var errFileLockUnsupported = errors.New("the file lock is not supported on this platform")
+
+// FileLock is only supported on unix platforms
+type FileLock struct{}
+
+func NewFileLock(_ string, _ string) (*FileLock, error) {
+	return nil, errFileLockUnsupported
+}
+
+func (l *FileLock) Acquire(_ context.Context, _ time.Duration) (bool, error) {
+	return false, errFileLockUnsupported
+}
+
+func (l *FileLock) Release(_ context.Context) error {
+	return nil
+}
@@ -0,0 +1,73 @@
+//go:build unix
+
+package leader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// FileLock is an advisory lock of a file, for replicas sharing a volume on the same host.
+// The lock is released by the operating system when the process exits, the lease duration is not used.
+type FileLock struct {
+	path     string
+	identity string
+
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFileLock(path string, identity string) (*FileLock, error) {
+	if path == "" {
+		return nil, fmt.Errorf("the file lock requires a file")
+	}
+	return &FileLock{path: path, identity: identity}, nil
+}
+
+func (l *FileLock) Acquire(_ context.Context, _ time.Duration) (bool, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file != nil {
+		return true, nil
+	}
+
+	file, err := os.OpenFile(l.path, os.O_RDWR|os.O_CREATE, 0644)
+	if err != nil {
+		return false, err
+	}
+
+	err = syscall.Flock(int(file.Fd()), syscall.LOCK_EX|syscall.LOCK_NB)
+	if errors.Is(err, syscall.EWOULDBLOCK) {
+		file.Close()
+		return false, nil
+	}
+	if err != nil {
+		file.Close()
+		return false, err
+	}
+
+	// The holder is written for troubleshooting only
+	file.Truncate(0)
+	file.WriteAt([]byte(l.identity+"\n"), 0)
+	l.file = file
+	return true, nil
+}
+
+func (l *FileLock) Release(_ context.Context) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.file == nil {
+		return nil
+	}
+	// Closing the file releases the lock
+	err := l.file.Close()
+	l.file = nil
+	return err
+}
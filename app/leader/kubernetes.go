@@ -0,0 +1,103 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/utils/ptr"
+)
+
+// LeaseLock is a Kubernetes Lease holding the identity of the leader.
+// Concurrent updates are rejected by the API server, so a single replica can acquire an expired Lease.
+type LeaseLock struct {
+	client    kubernetes.Interface
+	name      string
+	namespace string
+	identity  string
+}
+
+func NewLeaseLock(client kubernetes.Interface, name string, namespace string, identity string) *LeaseLock {
+	return &LeaseLock{client: client, name: name, namespace: namespace, identity: identity}
+}
+
+func (l *LeaseLock) Acquire(ctx context.Context, leaseDuration time.Duration) (bool, error) {
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+	now := metav1.NewMicroTime(time.Now())
+
+	lease, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		lease = &coordinationv1.Lease{ObjectMeta: metav1.ObjectMeta{Name: l.name, Namespace: l.namespace}}
+		l.hold(lease, leaseDuration, now)
+		_, err = leases.Create(ctx, lease, metav1.CreateOptions{})
+		if apierrors.IsAlreadyExists(err) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+	if err != nil {
+		return false, err
+	}
+
+	if l.heldByAnother(lease, now.Time) {
+		return false, nil
+	}
+
+	l.hold(lease, leaseDuration, now)
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		// Another replica updated the Lease first
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (l *LeaseLock) Release(ctx context.Context) error {
+	leases := l.client.CoordinationV1().Leases(l.namespace)
+
+	lease, err := leases.Get(ctx, l.name, metav1.GetOptions{})
+	if err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	if lease.Spec.HolderIdentity == nil || *lease.Spec.HolderIdentity != l.identity {
+		return nil
+	}
+
+	lease.Spec.HolderIdentity = nil
+	_, err = leases.Update(ctx, lease, metav1.UpdateOptions{})
+	if apierrors.IsConflict(err) {
+		return nil
+	}
+	return err
+}
+
+func (l *LeaseLock) heldByAnother(lease *coordinationv1.Lease, now time.Time) bool {
+	spec := lease.Spec
+	if spec.HolderIdentity == nil || *spec.HolderIdentity == "" || *spec.HolderIdentity == l.identity {
+		return false
+	}
+	if spec.RenewTime == nil || spec.LeaseDurationSeconds == nil {
+		return false
+	}
+	expiresAt := spec.RenewTime.Add(time.Duration(*spec.LeaseDurationSeconds) * time.Second)
+	return now.Before(expiresAt)
+}
+
+func (l *LeaseLock) hold(lease *coordinationv1.Lease, leaseDuration time.Duration, now metav1.MicroTime) {
+	holder := lease.Spec.HolderIdentity
+	if holder == nil || *holder != l.identity {
+		lease.Spec.AcquireTime = &now
+		if holder != nil && *holder != "" {
+			lease.Spec.LeaseTransitions = ptr.To(ptr.Deref(lease.Spec.LeaseTransitions, 0) + 1)
+		}
+	}
+	lease.Spec.HolderIdentity = ptr.To(l.identity)
+	lease.Spec.LeaseDurationSeconds = ptr.To(int32(max(leaseDuration.Round(time.Second), time.Second) / time.Second))
+	lease.Spec.RenewTime = &now
+}
@@ -0,0 +1,148 @@
+// Package leader elects a leader among the Sablier replicas sharing their sessions.
+//
+// Every replica serves the requests, but only the leader performs the actions which must
+// happen once: stopping the expired instances, stopping the unregistered instances on
+// startup and watching the groups.
+package leader
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	defaultLeaseDuration = 15 * time.Second
+	defaultRenewInterval = 5 * time.Second
+)
+
+// Lock is held by the leader
+type Lock interface {
+	// Acquire acquires the lock, or renews it if it is already held, for the lease duration.
+	// It returns false if another replica holds the lock.
+	Acquire(ctx context.Context, leaseDuration time.Duration) (bool, error)
+	// Release releases the lock if it is held, so that another replica can acquire it right away
+	Release(ctx context.Context) error
+}
+
+// Elector tries to acquire the lock at each renew interval and runs the registered functions while it holds it.
+//
+// A nil Elector is always the leader, as a single replica is.
+type Elector struct {
+	lock          Lock
+	identity      string
+	leaseDuration time.Duration
+	renewInterval time.Duration
+
+	mu      sync.Mutex
+	leading bool
+	// ctx is the context of the functions running while leading
+	ctx    context.Context
+	cancel context.CancelFunc
+	funcs  []func(ctx context.Context)
+}
+
+func NewElector(lock Lock, identity string, leaseDuration time.Duration, renewInterval time.Duration) *Elector {
+	if leaseDuration <= 0 {
+		leaseDuration = defaultLeaseDuration
+	}
+	if renewInterval <= 0 {
+		renewInterval = defaultRenewInterval
+	}
+	return &Elector{
+		lock:          lock,
+		identity:      identity,
+		leaseDuration: leaseDuration,
+		renewInterval: renewInterval,
+	}
+}
+
+// IsLeader returns true if the lock is held
+func (e *Elector) IsLeader() bool {
+	if e == nil {
+		return true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.leading
+}
+
+// WhileLeading runs fn each time the leadership is acquired, its context is cancelled when the leadership is lost.
+// With a nil Elector, fn runs right away until the process exits.
+func (e *Elector) WhileLeading(fn func(ctx context.Context)) {
+	if e == nil {
+		go fn(context.Background())
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.funcs = append(e.funcs, fn)
+	if e.leading {
+		go fn(e.ctx)
+	}
+}
+
+// Run acquires and renews the lock until the context is done, then releases it
+func (e *Elector) Run(ctx context.Context) {
+	ticker := time.NewTicker(e.renewInterval)
+	defer ticker.Stop()
+
+	for {
+		e.tryAcquire(ctx)
+
+		select {
+		case <-ctx.Done():
+			e.release()
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (e *Elector) tryAcquire(ctx context.Context) {
+	// The renewal must complete before the next one, well before the lease expires
+	ctx, cancel := context.WithTimeout(ctx, e.renewInterval)
+	defer cancel()
+
+	acquired, err := e.lock.Acquire(ctx, e.leaseDuration)
+	if err != nil {
+		log.Warnf("could not acquire the leader lock: %v", err)
+	}
+	e.setLeading(acquired && err == nil)
+}
+
+func (e *Elector) setLeading(leading bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if leading == e.leading {
+		return
+	}
+	e.leading = leading
+
+	if !leading {
+		log.Warnf("%s is no longer the leader", e.identity)
+		e.cancel()
+		return
+	}
+
+	log.Infof("%s is the leader", e.identity)
+	e.ctx, e.cancel = context.WithCancel(context.Background())
+	for _, fn := range e.funcs {
+		go fn(e.ctx)
+	}
+}
+
+func (e *Elector) release() {
+	e.setLeading(false)
+
+	ctx, cancel := context.WithTimeout(context.Background(), e.renewInterval)
+	defer cancel()
+	if err := e.lock.Release(ctx); err != nil {
+		log.Warnf("could not release the leader lock: %v", err)
+	}
+}
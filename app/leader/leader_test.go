@@ -0,0 +1,180 @@
+package leader
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+// memoryLock is a lease shared by the electors of a test
+type memoryLock struct {
+	mu        *sync.Mutex
+	holder    *string
+	expiresAt *time.Time
+
+	identity string
+	// crashed replicas neither renew nor release the lock
+	crashed atomic.Bool
+}
+
+func newMemoryLocks(identities ...string) []*memoryLock {
+	mu, holder, expiresAt := &sync.Mutex{}, new(string), new(time.Time)
+	locks := make([]*memoryLock, 0, len(identities))
+	for _, identity := range identities {
+		locks = append(locks, &memoryLock{mu: mu, holder: holder, expiresAt: expiresAt, identity: identity})
+	}
+	return locks
+}
+
+func (l *memoryLock) Acquire(_ context.Context, leaseDuration time.Duration) (bool, error) {
+	if l.crashed.Load() {
+		return false, context.DeadlineExceeded
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if *l.holder != "" && *l.holder != l.identity && time.Now().Before(*l.expiresAt) {
+		return false, nil
+	}
+	*l.holder = l.identity
+	*l.expiresAt = time.Now().Add(leaseDuration)
+	return true, nil
+}
+
+func (l *memoryLock) Release(_ context.Context) error {
+	if l.crashed.Load() {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if *l.holder == l.identity {
+		*l.holder = ""
+	}
+	return nil
+}
+
+// replica runs an elector and counts the functions running while leading
+type replica struct {
+	elector *Elector
+	lock    *memoryLock
+	running atomic.Int32
+	cancel  context.CancelFunc
+}
+
+func startReplica(t *testing.T, lock *memoryLock, leaseDuration time.Duration) *replica {
+	r := &replica{
+		elector: NewElector(lock, lock.identity, leaseDuration, 10*time.Millisecond),
+		lock:    lock,
+	}
+	r.elector.WhileLeading(func(ctx context.Context) {
+		r.running.Add(1)
+		<-ctx.Done()
+		r.running.Add(-1)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	r.cancel = cancel
+	go r.elector.Run(ctx)
+	t.Cleanup(cancel)
+	return r
+}
+
+func waitForLeader(t *testing.T, replicas ...*replica) *replica {
+	var leader *replica
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		leader = nil
+		for _, r := range replicas {
+			if r.elector.IsLeader() && r.running.Load() == 1 {
+				if leader != nil {
+					return poll.Error(fmt.Errorf("%s and %s are both leading", leader.lock.identity, r.lock.identity))
+				}
+				leader = r
+			}
+		}
+		if leader == nil {
+			return poll.Continue("no leader elected yet")
+		}
+		return poll.Success()
+	}, poll.WithTimeout(2*time.Second), poll.WithDelay(5*time.Millisecond))
+	return leader
+}
+
+func TestElector_Failover(t *testing.T) {
+	locks := newMemoryLocks("a", "b", "c")
+	replicas := []*replica{startReplica(t, locks[0], 100*time.Millisecond), startReplica(t, locks[1], 100*time.Millisecond), startReplica(t, locks[2], 100*time.Millisecond)}
+
+	leader := waitForLeader(t, replicas...)
+
+	// The leader crashes, another replica takes over once the lease expires
+	leader.lock.crashed.Store(true)
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if leader.elector.IsLeader() || leader.running.Load() != 0 {
+			return poll.Continue("the crashed replica is still leading")
+		}
+		return poll.Success()
+	}, poll.WithTimeout(time.Second), poll.WithDelay(5*time.Millisecond))
+
+	var followers []*replica
+	for _, r := range replicas {
+		if r != leader {
+			followers = append(followers, r)
+		}
+	}
+	next := waitForLeader(t, followers...)
+	assert.Assert(t, next != leader)
+	for _, r := range followers {
+		if r != next {
+			assert.Assert(t, !r.elector.IsLeader())
+			assert.Equal(t, r.running.Load(), int32(0))
+		}
+	}
+}
+
+func TestElector_ReleasesOnStop(t *testing.T) {
+	locks := newMemoryLocks("a", "b")
+	a := startReplica(t, locks[0], time.Hour)
+	waitForLeader(t, a)
+
+	b := startReplica(t, locks[1], time.Hour)
+	a.cancel()
+
+	// The lock is released, b does not wait for the lease to expire
+	next := waitForLeader(t, b)
+	assert.Assert(t, next == b)
+	assert.Assert(t, !a.elector.IsLeader())
+}
+
+func TestElector_WhileLeadingAfterElection(t *testing.T) {
+	locks := newMemoryLocks("a")
+	r := startReplica(t, locks[0], time.Hour)
+	waitForLeader(t, r)
+
+	started := make(chan struct{})
+	r.elector.WhileLeading(func(ctx context.Context) {
+		close(started)
+	})
+
+	select {
+	case <-started:
+	case <-time.After(time.Second):
+		t.Fatal("the function registered after the election did not run")
+	}
+}
+
+func TestElector_Nil(t *testing.T) {
+	var e *Elector
+	assert.Assert(t, e.IsLeader())
+
+	started := make(chan struct{})
+	e.WhileLeading(func(ctx context.Context) {
+		close(started)
+	})
+	<-started
+}
@@ -0,0 +1,146 @@
+package leader
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/config"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gotest.tools/v3/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestFileLock(t *testing.T) {
+	ctx := context.Background()
+	file := filepath.Join(t.TempDir(), "sablier.lock")
+	a, err := NewFileLock(file, "a")
+	assert.NilError(t, err)
+	b, err := NewFileLock(file, "b")
+	assert.NilError(t, err)
+
+	acquired, err := a.Acquire(ctx, time.Second)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+
+	acquired, err = b.Acquire(ctx, time.Second)
+	assert.NilError(t, err)
+	assert.Assert(t, !acquired)
+
+	// Renewing
+	acquired, err = a.Acquire(ctx, time.Second)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+
+	assert.NilError(t, a.Release(ctx))
+	acquired, err = b.Acquire(ctx, time.Second)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+}
+
+func TestRedisLock(t *testing.T) {
+	ctx := context.Background()
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+	a := NewRedisLock(client, "sablier:leader", "a")
+	b := NewRedisLock(client, "sablier:leader", "b")
+
+	acquired, err := a.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+
+	acquired, err = b.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, !acquired)
+
+	// a stops renewing, b acquires the lock once it expires
+	server.FastForward(time.Minute)
+	acquired, err = b.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+
+	acquired, err = a.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, !acquired)
+
+	// Only the holder releases the lock
+	assert.NilError(t, a.Release(ctx))
+	assert.Assert(t, server.Exists("sablier:leader"))
+	assert.NilError(t, b.Release(ctx))
+	assert.Assert(t, !server.Exists("sablier:leader"))
+}
+
+func TestLeaseLock(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	a := NewLeaseLock(client, "sablier", "default", "a")
+	b := NewLeaseLock(client, "sablier", "default", "b")
+
+	acquired, err := a.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+
+	acquired, err = b.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, !acquired)
+
+	// a stops renewing, b acquires the lock once it expires
+	lease, err := client.CoordinationV1().Leases("default").Get(ctx, "sablier", metav1.GetOptions{})
+	assert.NilError(t, err)
+	expired := metav1.NewMicroTime(time.Now().Add(-2 * time.Minute))
+	lease.Spec.RenewTime = &expired
+	_, err = client.CoordinationV1().Leases("default").Update(ctx, lease, metav1.UpdateOptions{})
+	assert.NilError(t, err)
+
+	acquired, err = b.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+
+	lease, err = client.CoordinationV1().Leases("default").Get(ctx, "sablier", metav1.GetOptions{})
+	assert.NilError(t, err)
+	assert.Equal(t, *lease.Spec.HolderIdentity, "b")
+	assert.Equal(t, *lease.Spec.LeaseDurationSeconds, int32(60))
+	assert.Equal(t, *lease.Spec.LeaseTransitions, int32(1))
+
+	acquired, err = a.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, !acquired)
+
+	assert.NilError(t, b.Release(ctx))
+	acquired, err = a.Acquire(ctx, time.Minute)
+	assert.NilError(t, err)
+	assert.Assert(t, acquired)
+}
+
+func TestNew(t *testing.T) {
+	e, err := New(config.LeaderElection{}, nil, "")
+	assert.NilError(t, err)
+	assert.Assert(t, e == nil)
+
+	server := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { client.Close() })
+
+	_, err = New(config.LeaderElection{Enabled: true, Lock: "file"}, client, "sablier:")
+	assert.ErrorContains(t, err, "requires a file")
+
+	e, err = New(config.LeaderElection{Enabled: true, Lock: "redis"}, client, "sablier:")
+	assert.NilError(t, err)
+	assert.Assert(t, e != nil)
+
+	_, err = New(config.LeaderElection{Enabled: true, Lock: "etcd"}, client, "sablier:")
+	assert.ErrorContains(t, err, "unknown leader election lock etcd")
+}
+
+func TestNew_RequiresSharedSessions(t *testing.T) {
+	// Each replica keeps its own sessions with the other backends, the sessions expiring on a
+	// follower would never be stopped by the leader
+	for _, lock := range locks {
+		_, err := New(config.LeaderElection{Enabled: true, Lock: lock, File: filepath.Join(t.TempDir(), "leader.lock")}, nil, "")
+		assert.ErrorContains(t, err, "requires the redis storage backend")
+	}
+}
@@ -0,0 +1,84 @@
+package leader
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/acouvreur/sablier/config"
+	"github.com/redis/go-redis/v9"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// namespaceFile holds the namespace of the pod
+const namespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+
+var locks = []string{"file", "kubernetes", "redis"}
+
+// New returns the elector configured, or nil if the leader election is disabled.
+// The redis lock uses the client of the redis storage backend, it is nil with the other backends.
+//
+// The leader election requires the redis storage backend: the followers leave the expired sessions
+// to the leader, which only sees them when the replicas share their sessions.
+func New(conf config.LeaderElection, redisClient *redis.Client, redisPrefix string) (*Elector, error) {
+	if !conf.Enabled {
+		return nil, nil
+	}
+	if redisClient == nil {
+		return nil, fmt.Errorf("the leader election requires the redis storage backend so that the replicas share their sessions")
+	}
+
+	identity := conf.Identity
+	if identity == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			return nil, fmt.Errorf("could not get the hostname as identity: %w", err)
+		}
+		identity = hostname
+	}
+
+	var lock Lock
+	switch conf.Lock {
+	case "", "file":
+		fileLock, err := NewFileLock(conf.File, identity)
+		if err != nil {
+			return nil, err
+		}
+		lock = fileLock
+	case "kubernetes":
+		leaseLock, err := newLeaseLock(conf, identity)
+		if err != nil {
+			return nil, err
+		}
+		lock = leaseLock
+	case "redis":
+		lock = NewRedisLock(redisClient, redisPrefix+"leader", identity)
+	default:
+		return nil, fmt.Errorf("unknown leader election lock %s, must be one of %v", conf.Lock, locks)
+	}
+
+	return NewElector(lock, identity, conf.LeaseDuration, conf.RenewInterval), nil
+}
+
+func newLeaseLock(conf config.LeaderElection, identity string) (*LeaseLock, error) {
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace := conf.LeaseNamespace
+	if namespace == "" {
+		b, err := os.ReadFile(namespaceFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the namespace of the pod, set the lease namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(b))
+	}
+
+	return NewLeaseLock(client, conf.LeaseName, namespace, identity), nil
+}
@@ -0,0 +1,49 @@
+package leader
+
+import (
+	"context"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// acquireScript sets the key to the identity if it is not held by another replica, and renews its TTL
+var acquireScript = redis.NewScript(`
+local holder = redis.call("GET", KEYS[1])
+if holder and holder ~= ARGV[1] then
+	return 0
+end
+redis.call("SET", KEYS[1], ARGV[1], "PX", ARGV[2])
+return 1
+`)
+
+// releaseScript deletes the key only if it is held by the identity
+var releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0
+`)
+
+// RedisLock is a key holding the identity of the leader, expiring after the lease duration
+type RedisLock struct {
+	client   *redis.Client
+	key      string
+	identity string
+}
+
+func NewRedisLock(client *redis.Client, key string, identity string) *RedisLock {
+	return &RedisLock{client: client, key: key, identity: identity}
+}
+
+func (l *RedisLock) Acquire(ctx context.Context, leaseDuration time.Duration) (bool, error) {
+	acquired, err := acquireScript.Run(ctx, l.client, []string{l.key}, l.identity, leaseDuration.Milliseconds()).Int()
+	if err != nil {
+		return false, err
+	}
+	return acquired == 1, nil
+}
+
+func (l *RedisLock) Release(ctx context.Context) error {
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.identity).Err()
+}
@@ -0,0 +1,126 @@
+package prewarm
+
+import (
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/storage"
+)
+
+// History records the first request of each day of the instances.
+//
+// Only the first request of a day is kept as it is the one the predictions are based on.
+type History struct {
+	mu        sync.RWMutex
+	retention time.Duration
+	requests  map[string][]time.Time
+}
+
+func NewHistory(retention time.Duration) *History {
+	return &History{
+		retention: retention,
+		requests:  make(map[string][]time.Time),
+	}
+}
+
+// RecordRequest records a request of the instance, it implements sessions.RequestRecorder
+func (h *History) RecordRequest(name string, at time.Time) {
+	if h == nil {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	requests := h.requests[name]
+	day := startOfDay(at)
+	i := sort.Search(len(requests), func(i int) bool {
+		return !requests[i].Before(day)
+	})
+
+	switch {
+	case i < len(requests) && requests[i].Before(day.AddDate(0, 0, 1)):
+		// A request was already recorded this day, keep the earliest
+		if at.Before(requests[i]) {
+			requests[i] = at
+		}
+	default:
+		requests = append(requests, time.Time{})
+		copy(requests[i+1:], requests[i:])
+		requests[i] = at
+	}
+
+	h.requests[name] = prune(requests, at.Add(-h.retention))
+}
+
+// FirstRequests returns the first request of each day of the instance, sorted
+func (h *History) FirstRequests(name string) []time.Time {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	requests := make([]time.Time, len(h.requests[name]))
+	copy(requests, h.requests[name])
+	return requests
+}
+
+// Instances returns the instances with at least one recorded request, sorted
+func (h *History) Instances() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	names := make([]string, 0, len(h.requests))
+	for name := range h.requests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (h *History) Load(reader io.ReadCloser) error {
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(h)
+}
+
+func (h *History) Save(writer io.WriteCloser) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return storage.Close(writer, encoder.Encode(h))
+}
+
+func (h *History) MarshalJSON() ([]byte, error) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return json.Marshal(h.requests)
+}
+
+func (h *History) UnmarshalJSON(b []byte) error {
+	var requests map[string][]time.Time
+	if err := json.Unmarshal(b, &requests); err != nil {
+		return err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, times := range requests {
+		sort.Slice(times, func(i, j int) bool {
+			return times[i].Before(times[j])
+		})
+		h.requests[name] = times
+	}
+	return nil
+}
+
+func prune(requests []time.Time, before time.Time) []time.Time {
+	i := sort.Search(len(requests), func(i int) bool {
+		return !requests[i].Before(before)
+	})
+	return requests[i:]
+}
+
+func startOfDay(t time.Time) time.Time {
+	year, month, day := t.Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+}
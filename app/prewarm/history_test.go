@@ -0,0 +1,60 @@
+package prewarm
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestHistory_RecordRequestKeepsFirstOfDay(t *testing.T) {
+	history := NewHistory(28 * 24 * time.Hour)
+	monday := time.Date(2024, 10, 21, 9, 0, 0, 0, time.UTC)
+
+	history.RecordRequest("nginx", monday.Add(time.Hour))
+	history.RecordRequest("nginx", monday)
+	history.RecordRequest("nginx", monday.Add(2*time.Hour))
+	history.RecordRequest("nginx", monday.AddDate(0, 0, -1))
+
+	assert.DeepEqual(t, history.FirstRequests("nginx"), []time.Time{monday.AddDate(0, 0, -1), monday})
+	assert.DeepEqual(t, history.Instances(), []string{"nginx"})
+}
+
+func TestHistory_RecordRequestPrunesOldRequests(t *testing.T) {
+	history := NewHistory(7 * 24 * time.Hour)
+	monday := time.Date(2024, 10, 21, 9, 0, 0, 0, time.UTC)
+
+	history.RecordRequest("nginx", monday.AddDate(0, 0, -14))
+	history.RecordRequest("nginx", monday)
+
+	assert.DeepEqual(t, history.FirstRequests("nginx"), []time.Time{monday})
+}
+
+func TestHistory_SaveAndLoad(t *testing.T) {
+	history := NewHistory(28 * 24 * time.Hour)
+	monday := time.Date(2024, 10, 21, 9, 0, 0, 0, time.UTC)
+	history.RecordRequest("nginx", monday)
+
+	buffer := &bytes.Buffer{}
+	assert.NilError(t, history.Save(nopCloser{buffer}))
+
+	loaded := NewHistory(28 * 24 * time.Hour)
+	assert.NilError(t, loaded.Load(io.NopCloser(buffer)))
+
+	requests := loaded.FirstRequests("nginx")
+	assert.Equal(t, len(requests), 1)
+	assert.Assert(t, requests[0].Equal(monday))
+}
+
+func TestHistory_NilHistory(t *testing.T) {
+	var history *History
+	history.RecordRequest("nginx", time.Now())
+}
+
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
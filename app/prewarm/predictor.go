@@ -0,0 +1,62 @@
+package prewarm
+
+import (
+	"sort"
+	"time"
+)
+
+// minSamples is the minimum number of past same weekdays observed to make a prediction
+const minSamples = 2
+
+// Prediction is the expected first request of the day of an instance
+type Prediction struct {
+	Instance string    `json:"instance"`
+	Expected time.Time `json:"expected"`
+	// Confidence is the ratio of the past same weekdays with a request
+	Confidence float64 `json:"confidence"`
+	Samples    int     `json:"samples"`
+}
+
+// Predict returns the expected first request of the instance on the day of now.
+//
+// The past same weekdays within the history are compared: the confidence is the
+// ratio of those days with a request, and the expected time of day is the median
+// of their first requests.
+func Predict(name string, firstRequests []time.Time, now time.Time) (Prediction, bool) {
+	if len(firstRequests) == 0 {
+		return Prediction{}, false
+	}
+
+	day := startOfDay(now)
+	observedSince := startOfDay(firstRequests[0].In(now.Location()))
+
+	byDay := make(map[int64]time.Time, len(firstRequests))
+	for _, request := range firstRequests {
+		request = request.In(now.Location())
+		byDay[startOfDay(request).Unix()] = request
+	}
+
+	var samples int
+	var timesOfDay []time.Duration
+	for past := day.AddDate(0, 0, -7); !past.Before(observedSince); past = past.AddDate(0, 0, -7) {
+		samples++
+		if request, ok := byDay[past.Unix()]; ok {
+			timesOfDay = append(timesOfDay, request.Sub(past))
+		}
+	}
+
+	if samples < minSamples || len(timesOfDay) == 0 {
+		return Prediction{}, false
+	}
+
+	sort.Slice(timesOfDay, func(i, j int) bool {
+		return timesOfDay[i] < timesOfDay[j]
+	})
+
+	return Prediction{
+		Instance:   name,
+		Expected:   day.Add(timesOfDay[len(timesOfDay)/2]),
+		Confidence: float64(len(timesOfDay)) / float64(samples),
+		Samples:    samples,
+	}, true
+}
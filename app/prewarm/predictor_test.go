@@ -0,0 +1,75 @@
+package prewarm
+
+import (
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+// weekdaysAt returns the first requests of the weekdays of the previous weeks at the given times of day
+func weekdaysAt(until time.Time, weeks int, timeOfDay func(day time.Time) (time.Duration, bool)) []time.Time {
+	var requests []time.Time
+	for day := startOfDay(until).AddDate(0, 0, -7*weeks); day.Before(startOfDay(until)); day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == time.Saturday || day.Weekday() == time.Sunday {
+			continue
+		}
+		if offset, ok := timeOfDay(day); ok {
+			requests = append(requests, day.Add(offset))
+		}
+	}
+	return requests
+}
+
+func TestPredict(t *testing.T) {
+	// Monday 2024-10-21 at 07:00
+	now := time.Date(2024, 10, 21, 7, 0, 0, 0, time.UTC)
+
+	t.Run("every weekday around 9:00", func(t *testing.T) {
+		requests := weekdaysAt(now, 4, func(day time.Time) (time.Duration, bool) {
+			return 9*time.Hour + time.Duration(day.Day()%3)*time.Minute, true
+		})
+
+		prediction, ok := Predict("nginx", requests, now)
+
+		assert.Assert(t, ok)
+		assert.Equal(t, prediction.Samples, 4)
+		assert.Equal(t, prediction.Confidence, 1.0)
+		assert.Equal(t, prediction.Expected.Hour(), 9)
+		assert.Assert(t, prediction.Expected.Minute() <= 2)
+	})
+
+	t.Run("one monday out of two", func(t *testing.T) {
+		requests := weekdaysAt(now, 4, func(day time.Time) (time.Duration, bool) {
+			_, week := day.ISOWeek()
+			return 9 * time.Hour, day.Weekday() != time.Monday || week%2 == 0
+		})
+
+		prediction, ok := Predict("nginx", requests, now)
+
+		// The history starts on the tuesday following the first skipped monday
+		assert.Assert(t, ok)
+		assert.Equal(t, prediction.Samples, 3)
+		assert.Equal(t, prediction.Confidence, 2.0/3)
+	})
+
+	t.Run("not enough history", func(t *testing.T) {
+		requests := weekdaysAt(now, 1, func(day time.Time) (time.Duration, bool) {
+			return 9 * time.Hour, true
+		})
+
+		_, ok := Predict("nginx", requests, now)
+
+		assert.Assert(t, !ok)
+	})
+
+	t.Run("never requested on mondays", func(t *testing.T) {
+		requests := weekdaysAt(now, 4, func(day time.Time) (time.Duration, bool) {
+			return 9 * time.Hour, day.Weekday() != time.Monday
+		})
+
+		_, ok := Predict("nginx", requests, now)
+
+		assert.Assert(t, !ok)
+	})
+}
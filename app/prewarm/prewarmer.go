@@ -0,0 +1,136 @@
+package prewarm
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Clock returns the current time, it is replaced in tests
+type Clock interface {
+	Now() time.Time
+}
+
+type systemClock struct{}
+
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock is the clock of the system
+var SystemClock Clock = systemClock{}
+
+// Prewarmer starts the instances shortly before their predicted first request of the day
+type Prewarmer struct {
+	manager         sessions.Manager
+	history         *History
+	clock           Clock
+	config          config.Prewarm
+	sessionDuration time.Duration
+
+	mu sync.Mutex
+	// warmed holds the day at which each instance was last pre-warmed
+	warmed map[string]time.Time
+}
+
+// PrewarmStatus is a prediction along with the pre-warming decision
+type PrewarmStatus struct {
+	Prediction
+	WarmAt time.Time `json:"warmAt"`
+	// Eligible is true when the confidence reaches the threshold
+	Eligible bool `json:"eligible"`
+	Warmed   bool `json:"warmed"`
+}
+
+func NewPrewarmer(manager sessions.Manager, history *History, clock Clock, conf config.Prewarm, sessionDuration time.Duration) *Prewarmer {
+	return &Prewarmer{
+		manager:         manager,
+		history:         history,
+		clock:           clock,
+		config:          conf,
+		sessionDuration: sessionDuration,
+		warmed:          make(map[string]time.Time),
+	}
+}
+
+// Run evaluates the predictions at each interval until the context is done.
+// Nothing is pre-warmed when the interval is not positive.
+func (p *Prewarmer) Run(ctx context.Context) {
+	if p.config.Interval <= 0 {
+		log.Warnf("the predictions are never evaluated, prewarm.interval must be positive, got %v", p.config.Interval)
+		return
+	}
+
+	ticker := time.NewTicker(p.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			p.Tick()
+		}
+	}
+}
+
+// Tick starts the instances whose predicted first request is within the lookahead
+// and returns their names
+func (p *Prewarmer) Tick() []string {
+	now := p.clock.Now()
+
+	var started []string
+	for _, status := range p.Predictions() {
+		if !status.Eligible || status.Warmed || now.Before(status.WarmAt) || !now.Before(status.Expected) {
+			continue
+		}
+		if p.requestedToday(status.Instance, now) {
+			continue
+		}
+
+		log.Infof("pre-warming %s, expected at %s with a confidence of %.2f", status.Instance, status.Expected.Format(time.Kitchen), status.Confidence)
+		// The session lasts until the expected request and a usual session after it.
+		// The sablier.session-duration label of the instance still overrides this duration.
+		p.manager.WarmSession([]string{status.Instance}, status.Expected.Sub(now)+p.sessionDuration)
+
+		p.mu.Lock()
+		p.warmed[status.Instance] = startOfDay(now)
+		p.mu.Unlock()
+		started = append(started, status.Instance)
+	}
+
+	return started
+}
+
+// Predictions returns the predictions of the day for all the recorded instances
+func (p *Prewarmer) Predictions() []PrewarmStatus {
+	now := p.clock.Now()
+	today := startOfDay(now)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	statuses := make([]PrewarmStatus, 0)
+	for _, name := range p.history.Instances() {
+		prediction, ok := Predict(name, p.history.FirstRequests(name), now)
+		if !ok {
+			continue
+		}
+		statuses = append(statuses, PrewarmStatus{
+			Prediction: prediction,
+			WarmAt:     prediction.Expected.Add(-p.config.Lookahead),
+			Eligible:   prediction.Confidence >= p.config.ConfidenceThreshold,
+			Warmed:     p.warmed[name].Equal(today),
+		})
+	}
+	return statuses
+}
+
+func (p *Prewarmer) requestedToday(name string, now time.Time) bool {
+	requests := p.history.FirstRequests(name)
+	return len(requests) > 0 && !requests[len(requests)-1].Before(startOfDay(now))
+}
@@ -0,0 +1,119 @@
+package prewarm
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/config"
+	"gotest.tools/v3/assert"
+)
+
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+type warmedSession struct {
+	names    []string
+	duration time.Duration
+}
+
+type sessionsManagerMock struct {
+	warmed []warmedSession
+	sessions.Manager
+}
+
+func (m *sessionsManagerMock) WarmSession(names []string, duration time.Duration) *sessions.SessionState {
+	m.warmed = append(m.warmed, warmedSession{names: names, duration: duration})
+	return nil
+}
+
+func TestPrewarmer_Tick(t *testing.T) {
+	// Monday 2024-10-21 at 08:00
+	clock := &fakeClock{now: time.Date(2024, 10, 21, 8, 0, 0, 0, time.UTC)}
+	history := NewHistory(28 * 24 * time.Hour)
+	for _, request := range weekdaysAt(clock.now, 4, func(day time.Time) (time.Duration, bool) {
+		return 9 * time.Hour, true
+	}) {
+		history.RecordRequest("staging", request)
+	}
+
+	conf := config.NewPrewarmConfig()
+	conf.Lookahead = 5 * time.Minute
+	manager := &sessionsManagerMock{}
+	prewarmer := NewPrewarmer(manager, history, clock, conf, 10*time.Minute)
+
+	// Too early
+	assert.Equal(t, len(prewarmer.Tick()), 0)
+
+	clock.now = time.Date(2024, 10, 21, 8, 56, 0, 0, time.UTC)
+	assert.DeepEqual(t, prewarmer.Tick(), []string{"staging"})
+	assert.Equal(t, len(manager.warmed), 1)
+	assert.Equal(t, manager.warmed[0].duration, 14*time.Minute)
+
+	// Only once per day
+	clock.now = clock.now.Add(time.Minute)
+	assert.Equal(t, len(prewarmer.Tick()), 0)
+
+	predictions := prewarmer.Predictions()
+	assert.Equal(t, len(predictions), 1)
+	assert.Assert(t, predictions[0].Eligible)
+	assert.Assert(t, predictions[0].Warmed)
+}
+
+func TestPrewarmer_TickSkipsRequestedInstances(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 10, 21, 8, 58, 0, 0, time.UTC)}
+	history := NewHistory(28 * 24 * time.Hour)
+	for _, request := range weekdaysAt(clock.now, 4, func(day time.Time) (time.Duration, bool) {
+		return 9 * time.Hour, true
+	}) {
+		history.RecordRequest("staging", request)
+	}
+	// The user came early today
+	history.RecordRequest("staging", clock.now.Add(-time.Hour))
+
+	manager := &sessionsManagerMock{}
+	prewarmer := NewPrewarmer(manager, history, clock, config.NewPrewarmConfig(), 10*time.Minute)
+
+	assert.Equal(t, len(prewarmer.Tick()), 0)
+}
+
+func TestPrewarmer_TickBelowConfidenceThreshold(t *testing.T) {
+	clock := &fakeClock{now: time.Date(2024, 10, 21, 8, 58, 0, 0, time.UTC)}
+	history := NewHistory(28 * 24 * time.Hour)
+	for _, request := range weekdaysAt(clock.now, 4, func(day time.Time) (time.Duration, bool) {
+		_, week := day.ISOWeek()
+		return 9 * time.Hour, week%2 == 0
+	}) {
+		history.RecordRequest("staging", request)
+	}
+
+	manager := &sessionsManagerMock{}
+	prewarmer := NewPrewarmer(manager, history, clock, config.NewPrewarmConfig(), 10*time.Minute)
+
+	assert.Equal(t, len(prewarmer.Tick()), 0)
+	assert.Assert(t, !prewarmer.Predictions()[0].Eligible)
+}
+
+func TestPrewarmer_RunWithoutInterval(t *testing.T) {
+	conf := config.NewPrewarmConfig()
+	conf.Interval = 0
+	prewarmer := NewPrewarmer(&sessionsManagerMock{}, NewHistory(time.Hour), &fakeClock{}, conf, time.Minute)
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		prewarmer.Run(context.Background())
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return without interval")
+	}
+}
@@ -21,6 +21,10 @@ import (
 
 // Interface guard
 var _ providers.Provider = (*DockerClassicProvider)(nil)
+var _ providers.ReadinessNotifier = (*DockerClassicProvider)(nil)
+var _ providers.GroupsNotifier = (*DockerClassicProvider)(nil)
+var _ providers.LabelsProvider = (*DockerClassicProvider)(nil)
+var _ providers.Executor = (*DockerClassicProvider)(nil)
 
 type DockerClassicProvider struct {
 	Client          client.APIClient
@@ -122,6 +126,53 @@ func (provider *DockerClassicProvider) GetState(ctx context.Context, name string
 	}
 }
 
+func (provider *DockerClassicProvider) GetLabels(ctx context.Context, name string) (map[string]string, error) {
+	spec, err := provider.Client.ContainerInspect(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	if spec.Config == nil {
+		return map[string]string{}, nil
+	}
+
+	return spec.Config.Labels, nil
+}
+
+func (provider *DockerClassicProvider) Exec(ctx context.Context, name string, cmd []string) error {
+	exec, err := provider.Client.ContainerExecCreate(ctx, name, container.ExecOptions{
+		Cmd:          cmd,
+		AttachStdout: true,
+		AttachStderr: true,
+	})
+	if err != nil {
+		return fmt.Errorf("cannot create exec in %s: %w", name, err)
+	}
+
+	attach, err := provider.Client.ContainerExecAttach(ctx, exec.ID, container.ExecAttachOptions{})
+	if err != nil {
+		return fmt.Errorf("cannot attach to exec in %s: %w", name, err)
+	}
+	defer attach.Close()
+
+	// The output is drained until the command completes
+	output, err := io.ReadAll(attach.Reader)
+	if err != nil {
+		return fmt.Errorf("cannot read exec output in %s: %w", name, err)
+	}
+
+	inspect, err := provider.Client.ContainerExecInspect(ctx, exec.ID)
+	if err != nil {
+		return fmt.Errorf("cannot inspect exec in %s: %w", name, err)
+	}
+
+	if inspect.ExitCode != 0 {
+		return fmt.Errorf("command %v in %s exited with code %d: %s", cmd, name, inspect.ExitCode, strings.TrimSpace(string(output)))
+	}
+
+	return nil
+}
+
 func (provider *DockerClassicProvider) NotifyInstanceStopped(ctx context.Context, instance chan<- string) {
 	msgs, errs := provider.Client.Events(ctx, types.EventsOptions{
 		Filters: filters.NewArgs(
@@ -154,3 +205,82 @@ func (provider *DockerClassicProvider) NotifyInstanceStopped(ctx context.Context
 		}
 	}
 }
+
+func (provider *DockerClassicProvider) NotifyInstanceReady(ctx context.Context, instance chan<- string) {
+	msgs, errs := provider.Client.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("scope", "local"),
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("event", "start"),
+			filters.Arg("event", "health_status"),
+		),
+	})
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Error("provider event stream is closed")
+				return
+			}
+			// Containers with a healthcheck are ready once healthy, the others as soon as they are started
+			if msg.Action == "start" || msg.Action == "health_status: healthy" {
+				instance <- strings.TrimPrefix(msg.Actor.Attributes["name"], "/")
+			}
+		case err, ok := <-errs:
+			if !ok {
+				log.Error("provider event stream is closed", err)
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				log.Debug("provider event stream closed")
+				return
+			}
+			log.Error("provider event stream error", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (provider *DockerClassicProvider) NotifyGroupChanged(ctx context.Context, event chan<- providers.GroupEvent) {
+	msgs, errs := provider.Client.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("scope", "local"),
+			filters.Arg("type", string(events.ContainerEventType)),
+			filters.Arg("label", fmt.Sprintf("%s=true", discovery.LabelEnable)),
+			filters.Arg("event", "create"),
+			filters.Arg("event", "destroy"),
+		),
+	})
+	for {
+		select {
+		case msg, ok := <-msgs:
+			if !ok {
+				log.Error("provider event stream is closed")
+				return
+			}
+			// Container labels are reported as event attributes
+			groupName := msg.Actor.Attributes[discovery.LabelGroup]
+			if len(groupName) == 0 {
+				groupName = discovery.LabelGroupDefaultValue
+			}
+			providers.SendGroupEvent(ctx, event, providers.GroupEvent{
+				Instance: strings.TrimPrefix(msg.Actor.Attributes["name"], "/"),
+				Group:    groupName,
+				Removed:  msg.Action == "destroy",
+			})
+		case err, ok := <-errs:
+			if !ok {
+				log.Error("provider event stream is closed", err)
+				return
+			}
+			if errors.Is(err, io.EOF) {
+				log.Debug("provider event stream closed")
+				return
+			}
+			log.Error("provider event stream error", err)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -1,14 +1,18 @@
 package docker
 
 import (
+	"bufio"
 	"context"
 	"fmt"
+	"net"
 	"reflect"
+	"strings"
 	"testing"
 
 	"github.com/acouvreur/sablier/app/instance"
 	"github.com/acouvreur/sablier/app/providers/mocks"
 	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/container"
 	"github.com/docker/docker/api/types/events"
 	"github.com/stretchr/testify/mock"
 )
@@ -404,3 +408,86 @@ func TestDockerClassicProvider_NotifyInstanceStopped(t *testing.T) {
 		})
 	}
 }
+
+func TestDockerClassicProvider_NotifyInstanceReady(t *testing.T) {
+	tests := []struct {
+		name   string
+		want   []string
+		events []events.Message
+		errors []error
+	}{
+		{
+			name: "container nginx is started and apache is healthy",
+			want: []string{"nginx", "apache"},
+			events: []events.Message{
+				mocks.ContainerStartedEvent("nginx"),
+				mocks.ContainerHealthStatusEvent("whoami", "unhealthy"),
+				mocks.ContainerHealthStatusEvent("apache", "healthy"),
+			},
+			errors: []error{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider := &DockerClassicProvider{
+				Client:          mocks.NewDockerAPIClientMockWithEvents(tt.events, tt.errors),
+				desiredReplicas: 1,
+			}
+
+			instanceC := make(chan string, len(tt.want))
+
+			provider.NotifyInstanceReady(context.Background(), instanceC)
+			close(instanceC)
+
+			var got []string
+			for instance := range instanceC {
+				got = append(got, instance)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NotifyInstanceReady() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDockerClassicProvider_Exec(t *testing.T) {
+	tests := []struct {
+		name     string
+		exitCode int
+		wantErr  bool
+	}{
+		{
+			name:     "command succeeds",
+			exitCode: 0,
+			wantErr:  false,
+		},
+		{
+			name:     "command exits with an error",
+			exitCode: 1,
+			wantErr:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := mocks.NewDockerAPIClientMock()
+			provider := &DockerClassicProvider{
+				Client:          client,
+				desiredReplicas: 1,
+			}
+
+			conn, _ := net.Pipe()
+			client.On("ContainerExecCreate", mock.Anything, "nginx", mock.Anything).Return(types.IDResponse{ID: "exec"}, nil)
+			client.On("ContainerExecAttach", mock.Anything, "exec", mock.Anything).Return(types.HijackedResponse{
+				Conn:   conn,
+				Reader: bufio.NewReader(strings.NewReader("output")),
+			}, nil)
+			client.On("ContainerExecInspect", mock.Anything, "exec").Return(container.ExecInspect{ExitCode: tt.exitCode}, nil)
+
+			err := provider.Exec(context.Background(), "nginx", []string{"sh", "-c", "sync"})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DockerClassicProvider.Exec() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -19,6 +19,9 @@ import (
 
 // Interface guard
 var _ providers.Provider = (*DockerSwarmProvider)(nil)
+var _ providers.ReadinessNotifier = (*DockerSwarmProvider)(nil)
+var _ providers.GroupsNotifier = (*DockerSwarmProvider)(nil)
+var _ providers.LabelsProvider = (*DockerSwarmProvider)(nil)
 
 type DockerSwarmProvider struct {
 	Client          client.APIClient
@@ -125,6 +128,15 @@ func (provider *DockerSwarmProvider) GetState(ctx context.Context, name string)
 	return instance.ReadyInstanceState(foundName, provider.desiredReplicas), nil
 }
 
+func (provider *DockerSwarmProvider) GetLabels(ctx context.Context, name string) (map[string]string, error) {
+	service, err := provider.getServiceByName(name, ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.Spec.Labels, nil
+}
+
 func (provider *DockerSwarmProvider) getServiceByName(name string, ctx context.Context) (*swarm.Service, error) {
 	opts := types.ServiceListOptions{
 		Filters: filters.NewArgs(),
@@ -139,7 +151,7 @@ func (provider *DockerSwarmProvider) getServiceByName(name string, ctx context.C
 	}
 
 	if len(services) == 0 {
-		return nil, fmt.Errorf(fmt.Sprintf("service with name %s was not found", name))
+		return nil, fmt.Errorf("service %s: %w", name, providers.ErrNotFound)
 	}
 
 	for _, service := range services {
@@ -149,7 +161,7 @@ func (provider *DockerSwarmProvider) getServiceByName(name string, ctx context.C
 		}
 	}
 
-	return nil, fmt.Errorf(fmt.Sprintf("service %s was not found because it did not match exactly or on suffix", name))
+	return nil, fmt.Errorf("service %s did not match exactly or on suffix: %w", name, providers.ErrNotFound)
 }
 
 func (provider *DockerSwarmProvider) getInstanceName(name string, service swarm.Service) string {
@@ -197,3 +209,101 @@ func (provider *DockerSwarmProvider) NotifyInstanceStopped(ctx context.Context,
 		}
 	}()
 }
+
+// NotifyInstanceReady listens to the containers of the swarm tasks scheduled on this node.
+// Services with tasks on other nodes are still discovered by polling their state.
+func (provider *DockerSwarmProvider) NotifyInstanceReady(ctx context.Context, instance chan<- string) {
+	msgs, errs := provider.Client.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("scope", "local"),
+			filters.Arg("type", "container"),
+			filters.Arg("event", "start"),
+			filters.Arg("event", "health_status"),
+		),
+	})
+
+	go func() {
+		for {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					log.Error("provider event stream is closed")
+					return
+				}
+				service, ok := msg.Actor.Attributes["com.docker.swarm.service.name"]
+				if !ok {
+					continue
+				}
+				if msg.Action == "start" || msg.Action == "health_status: healthy" {
+					instance <- service
+				}
+			case err, ok := <-errs:
+				if !ok {
+					log.Error("provider event stream is closed", err)
+					return
+				}
+				if errors.Is(err, io.EOF) {
+					log.Debug("provider event stream closed")
+					return
+				}
+				log.Error("provider event stream error", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
+
+func (provider *DockerSwarmProvider) NotifyGroupChanged(ctx context.Context, event chan<- providers.GroupEvent) {
+	msgs, errs := provider.Client.Events(ctx, types.EventsOptions{
+		Filters: filters.NewArgs(
+			filters.Arg("scope", "swarm"),
+			filters.Arg("type", "service"),
+			filters.Arg("event", "create"),
+			filters.Arg("event", "remove"),
+		),
+	})
+
+	go func() {
+		for {
+			select {
+			case msg, ok := <-msgs:
+				if !ok {
+					log.Error("provider event stream is closed")
+					return
+				}
+				name := msg.Actor.Attributes["name"]
+				if msg.Action == "remove" {
+					providers.SendGroupEvent(ctx, event, providers.GroupEvent{Instance: name, Removed: true})
+					continue
+				}
+				// Service events do not carry the labels
+				service, err := provider.getServiceByName(name, ctx)
+				if err != nil {
+					log.Warnf("could not inspect created service %s: %v", name, err)
+					continue
+				}
+				if enabled := service.Spec.Labels[discovery.LabelEnable]; enabled != "true" {
+					continue
+				}
+				groupName := service.Spec.Labels[discovery.LabelGroup]
+				if len(groupName) == 0 {
+					groupName = discovery.LabelGroupDefaultValue
+				}
+				providers.SendGroupEvent(ctx, event, providers.GroupEvent{Instance: name, Group: groupName})
+			case err, ok := <-errs:
+				if !ok {
+					log.Error("provider event stream is closed", err)
+					return
+				}
+				if errors.Is(err, io.EOF) {
+					log.Debug("provider event stream closed")
+					return
+				}
+				log.Error("provider event stream error", err)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+}
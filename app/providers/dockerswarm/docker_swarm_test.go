@@ -6,6 +6,7 @@ import (
 	"testing"
 
 	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
 	"github.com/acouvreur/sablier/app/providers/mocks"
 	"github.com/docker/docker/api/types/events"
 	"github.com/docker/docker/api/types/swarm"
@@ -290,3 +291,37 @@ func TestDockerSwarmProvider_NotifyInstanceStopped(t *testing.T) {
 		})
 	}
 }
+
+func TestDockerSwarmProvider_GetStateNotFound(t *testing.T) {
+	tests := []struct {
+		name        string
+		serviceList []swarm.Service
+	}{
+		{
+			name:        "no service",
+			serviceList: []swarm.Service{},
+		},
+		{
+			name: "no exact match",
+			serviceList: []swarm.Service{
+				mocks.ServiceReplicated("nginx-2", 1),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clientMock := mocks.NewDockerAPIClientMock()
+			provider := &DockerSwarmProvider{
+				Client:          clientMock,
+				desiredReplicas: 1,
+			}
+
+			clientMock.On("ServiceList", mock.Anything, mock.Anything).Return(tt.serviceList, nil)
+
+			_, err := provider.GetState(context.Background(), "nginx")
+			if got := providers.Classify(err); got != providers.NotFound {
+				t.Errorf("providers.Classify(%v) = %v, want %v", err, got, providers.NotFound)
+			}
+		})
+	}
+}
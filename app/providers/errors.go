@@ -0,0 +1,78 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"syscall"
+
+	"github.com/docker/docker/client"
+	"github.com/docker/docker/errdefs"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// ErrorClass tells how a provider error should be handled
+type ErrorClass int
+
+const (
+	// Permanent errors will not succeed if the call is retried
+	Permanent ErrorClass = iota
+	// Transient errors might succeed if the call is retried
+	Transient
+	// NotFound errors are returned for instances which do not exist
+	NotFound
+)
+
+func (c ErrorClass) String() string {
+	switch c {
+	case Transient:
+		return "transient"
+	case NotFound:
+		return "not-found"
+	default:
+		return "permanent"
+	}
+}
+
+// ErrNotFound can be wrapped by providers to report an instance which does not exist
+var ErrNotFound = errors.New("instance not found")
+
+// ErrDegraded is returned without calling the provider while it is degraded
+var ErrDegraded = errors.New("provider is degraded")
+
+// HealthReporter is an optional interface implemented by providers able to report
+// that they are degraded, for example after too many transient errors.
+type HealthReporter interface {
+	Degraded() bool
+}
+
+// Classify returns the class of a provider error
+func Classify(err error) ErrorClass {
+	switch {
+	case err == nil:
+		return Permanent
+	case errors.Is(err, ErrDegraded):
+		return Transient
+	case errors.Is(err, ErrNotFound), errdefs.IsNotFound(err), apierrors.IsNotFound(err):
+		return NotFound
+	case errors.Is(err, context.Canceled):
+		return Permanent
+	case errors.Is(err, context.DeadlineExceeded):
+		return Transient
+	case client.IsErrConnectionFailed(err), errdefs.IsUnavailable(err), errdefs.IsDeadline(err):
+		return Transient
+	case apierrors.IsTooManyRequests(err), apierrors.IsServerTimeout(err), apierrors.IsTimeout(err),
+		apierrors.IsServiceUnavailable(err), apierrors.IsInternalError(err):
+		return Transient
+	case errors.Is(err, syscall.ECONNREFUSED), errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.ErrUnexpectedEOF):
+		return Transient
+	}
+
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return Transient
+	}
+
+	return Permanent
+}
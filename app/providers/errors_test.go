@@ -0,0 +1,38 @@
+package providers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"syscall"
+	"testing"
+
+	"github.com/docker/docker/errdefs"
+	"gotest.tools/v3/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestClassify(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want ErrorClass
+	}{
+		{name: "unknown error", err: errors.New("invalid name"), want: Permanent},
+		{name: "wrapped not found", err: fmt.Errorf("service nginx: %w", ErrNotFound), want: NotFound},
+		{name: "docker not found", err: errdefs.NotFound(errors.New("no such container")), want: NotFound},
+		{name: "docker unavailable", err: errdefs.Unavailable(errors.New("daemon restarting")), want: Transient},
+		{name: "kubernetes not found", err: apierrors.NewNotFound(schema.GroupResource{Resource: "deployments"}, "nginx"), want: NotFound},
+		{name: "kubernetes too many requests", err: apierrors.NewTooManyRequests("slow down", 1), want: Transient},
+		{name: "connection refused", err: fmt.Errorf("dial: %w", syscall.ECONNREFUSED), want: Transient},
+		{name: "deadline exceeded", err: context.DeadlineExceeded, want: Transient},
+		{name: "canceled", err: context.Canceled, want: Permanent},
+		{name: "degraded", err: ErrDegraded, want: Transient},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, Classify(tt.err), tt.want)
+		})
+	}
+}
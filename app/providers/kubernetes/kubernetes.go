@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"github.com/acouvreur/sablier/app/discovery"
 	"github.com/acouvreur/sablier/app/providers"
+	"sync"
 	"time"
 
 	appsv1 "k8s.io/api/apps/v1"
@@ -15,6 +16,7 @@ import (
 	log "github.com/sirupsen/logrus"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/client-go/informers"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
@@ -23,6 +25,9 @@ import (
 
 // Interface guard
 var _ providers.Provider = (*KubernetesProvider)(nil)
+var _ providers.ReadinessNotifier = (*KubernetesProvider)(nil)
+var _ providers.GroupsNotifier = (*KubernetesProvider)(nil)
+var _ providers.LabelsProvider = (*KubernetesProvider)(nil)
 
 type Workload interface {
 	GetScale(ctx context.Context, workloadName string, options metav1.GetOptions) (*autoscalingv1.Scale, error)
@@ -32,6 +37,9 @@ type Workload interface {
 type KubernetesProvider struct {
 	Client    kubernetes.Interface
 	delimiter string
+
+	factoryOnce sync.Once
+	factory     informers.SharedInformerFactory
 }
 
 func NewKubernetesProvider(providerConfig providerConfig.Kubernetes) (*KubernetesProvider, error) {
@@ -160,6 +168,42 @@ func (provider *KubernetesProvider) GetState(ctx context.Context, name string) (
 	}
 }
 
+// GetLabels returns the labels of the workload, overridden by its annotations.
+// Annotations allow values that are not valid label values, such as a display name with spaces.
+func (provider *KubernetesProvider) GetLabels(ctx context.Context, name string) (map[string]string, error) {
+	parsed, err := ParseName(name, ParseOptions{Delimiter: provider.delimiter})
+	if err != nil {
+		return nil, err
+	}
+
+	var meta metav1.ObjectMeta
+	switch parsed.Kind {
+	case "deployment":
+		d, err := provider.Client.AppsV1().Deployments(parsed.Namespace).Get(ctx, parsed.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		meta = d.ObjectMeta
+	case "statefulset":
+		ss, err := provider.Client.AppsV1().StatefulSets(parsed.Namespace).Get(ctx, parsed.Name, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		meta = ss.ObjectMeta
+	default:
+		return nil, fmt.Errorf("unsupported kind \"%s\" must be one of \"deployment\", \"statefulset\"", parsed.Kind)
+	}
+
+	labels := make(map[string]string, len(meta.Labels)+len(meta.Annotations))
+	for k, v := range meta.Labels {
+		labels[k] = v
+	}
+	for k, v := range meta.Annotations {
+		labels[k] = v
+	}
+	return labels, nil
+}
+
 func (provider *KubernetesProvider) getDeploymentState(ctx context.Context, config ParsedName) (instance.State, error) {
 	d, err := provider.Client.AppsV1().Deployments(config.Namespace).Get(ctx, config.Name, metav1.GetOptions{})
 	if err != nil {
@@ -186,15 +230,40 @@ func (provider *KubernetesProvider) getStatefulsetState(ctx context.Context, con
 	return instance.NotReadyInstanceState(config.Original, ss.Status.ReadyReplicas, *ss.Spec.Replicas), nil
 }
 
+// informerFactory returns the informer factory shared by the watchers, so that the deployments
+// and the statefulsets are listed and watched once whatever the number of handlers.
+// The informers run until the process exits, the handlers are removed when their watch is done.
+func (provider *KubernetesProvider) informerFactory() informers.SharedInformerFactory {
+	provider.factoryOnce.Do(func() {
+		provider.factory = informers.NewSharedInformerFactoryWithOptions(provider.Client, 2*time.Second, informers.WithNamespace(core_v1.NamespaceAll))
+	})
+	return provider.factory
+}
+
+// handle adds the handler to the informer until the context is done, so that the handlers added
+// each time the leadership is acquired do not pile up
+func (provider *KubernetesProvider) handle(ctx context.Context, informer cache.SharedIndexInformer, handler cache.ResourceEventHandler) {
+	registration, err := informer.AddEventHandler(handler)
+	if err != nil {
+		log.Errorf("could not watch the kubernetes events: %v", err)
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		if err := informer.RemoveEventHandler(registration); err != nil {
+			log.Warnf("could not stop watching the kubernetes events: %v", err)
+		}
+	}()
+	provider.informerFactory().Start(wait.NeverStop)
+}
+
 func (provider *KubernetesProvider) NotifyInstanceStopped(ctx context.Context, instance chan<- string) {
 
-	informer := provider.watchDeployents(instance)
-	go informer.Run(ctx.Done())
-	informer = provider.watchStatefulSets(instance)
-	go informer.Run(ctx.Done())
+	provider.watchDeployents(ctx, instance)
+	provider.watchStatefulSets(ctx, instance)
 }
 
-func (provider *KubernetesProvider) watchDeployents(instance chan<- string) cache.SharedIndexInformer {
+func (provider *KubernetesProvider) watchDeployents(ctx context.Context, instance chan<- string) {
 	handler := cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, new interface{}) {
 			newDeployment := new.(*appsv1.Deployment)
@@ -215,14 +284,10 @@ func (provider *KubernetesProvider) watchDeployents(instance chan<- string) cach
 			instance <- parsed.Original
 		},
 	}
-	factory := informers.NewSharedInformerFactoryWithOptions(provider.Client, 2*time.Second, informers.WithNamespace(core_v1.NamespaceAll))
-	informer := factory.Apps().V1().Deployments().Informer()
-
-	informer.AddEventHandler(handler)
-	return informer
+	provider.handle(ctx, provider.informerFactory().Apps().V1().Deployments().Informer(), handler)
 }
 
-func (provider *KubernetesProvider) watchStatefulSets(instance chan<- string) cache.SharedIndexInformer {
+func (provider *KubernetesProvider) watchStatefulSets(ctx context.Context, instance chan<- string) {
 	handler := cache.ResourceEventHandlerFuncs{
 		UpdateFunc: func(old, new interface{}) {
 			newStatefulSet := new.(*appsv1.StatefulSet)
@@ -243,9 +308,157 @@ func (provider *KubernetesProvider) watchStatefulSets(instance chan<- string) ca
 			instance <- parsed.Original
 		},
 	}
-	factory := informers.NewSharedInformerFactoryWithOptions(provider.Client, 2*time.Second, informers.WithNamespace(core_v1.NamespaceAll))
-	informer := factory.Apps().V1().StatefulSets().Informer()
+	provider.handle(ctx, provider.informerFactory().Apps().V1().StatefulSets().Informer(), handler)
+}
+
+func (provider *KubernetesProvider) NotifyInstanceReady(ctx context.Context, instance chan<- string) {
+
+	provider.watchReadyDeployments(ctx, instance)
+	provider.watchReadyStatefulSets(ctx, instance)
+}
+
+func (provider *KubernetesProvider) watchReadyDeployments(ctx context.Context, instance chan<- string) {
+	handler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			newDeployment := new.(*appsv1.Deployment)
+			oldDeployment := old.(*appsv1.Deployment)
+
+			if newDeployment.ObjectMeta.ResourceVersion == oldDeployment.ObjectMeta.ResourceVersion {
+				return
+			}
+
+			if *newDeployment.Spec.Replicas > 0 && newDeployment.Status.ReadyReplicas == *newDeployment.Spec.Replicas && oldDeployment.Status.ReadyReplicas != newDeployment.Status.ReadyReplicas {
+				parsed := DeploymentName(*newDeployment, ParseOptions{Delimiter: provider.delimiter})
+				instance <- parsed.Original
+			}
+		},
+	}
+	provider.handle(ctx, provider.informerFactory().Apps().V1().Deployments().Informer(), handler)
+}
+
+func (provider *KubernetesProvider) watchReadyStatefulSets(ctx context.Context, instance chan<- string) {
+	handler := cache.ResourceEventHandlerFuncs{
+		UpdateFunc: func(old, new interface{}) {
+			newStatefulSet := new.(*appsv1.StatefulSet)
+			oldStatefulSet := old.(*appsv1.StatefulSet)
+
+			if newStatefulSet.ObjectMeta.ResourceVersion == oldStatefulSet.ObjectMeta.ResourceVersion {
+				return
+			}
+
+			if *newStatefulSet.Spec.Replicas > 0 && newStatefulSet.Status.ReadyReplicas == *newStatefulSet.Spec.Replicas && oldStatefulSet.Status.ReadyReplicas != newStatefulSet.Status.ReadyReplicas {
+				parsed := StatefulSetName(*newStatefulSet, ParseOptions{Delimiter: provider.delimiter})
+				instance <- parsed.Original
+			}
+		},
+	}
+	provider.handle(ctx, provider.informerFactory().Apps().V1().StatefulSets().Informer(), handler)
+}
+
+func (provider *KubernetesProvider) NotifyGroupChanged(ctx context.Context, event chan<- providers.GroupEvent) {
+
+	provider.watchGroupDeployments(ctx, event)
+	provider.watchGroupStatefulSets(ctx, event)
+}
+
+func groupOf(labels map[string]string) string {
+	groupName := labels[discovery.LabelGroup]
+	if len(groupName) == 0 {
+		groupName = discovery.LabelGroupDefaultValue
+	}
+	return groupName
+}
+
+func enabled(labels map[string]string) bool {
+	_, ok := labels[discovery.LabelEnable]
+	return ok
+}
+
+// groupChange returns the event of an instance whose labels changed from old to new,
+// nil labels meaning that the instance does not exist
+func groupChange(name string, old map[string]string, new map[string]string) (providers.GroupEvent, bool) {
+	wasEnabled, isEnabled := enabled(old), enabled(new)
+	switch {
+	case isEnabled && (!wasEnabled || groupOf(old) != groupOf(new)):
+		return providers.GroupEvent{Instance: name, Group: groupOf(new)}, true
+	case wasEnabled && !isEnabled:
+		return providers.GroupEvent{Instance: name, Removed: true}, true
+	}
+	return providers.GroupEvent{}, false
+}
+
+func (provider *KubernetesProvider) watchGroupDeployments(ctx context.Context, event chan<- providers.GroupEvent) {
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			deployment := obj.(*appsv1.Deployment)
+			parsed := DeploymentName(*deployment, ParseOptions{Delimiter: provider.delimiter})
+			if e, ok := groupChange(parsed.Original, nil, deployment.Labels); ok {
+				providers.SendGroupEvent(ctx, event, e)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			newDeployment := new.(*appsv1.Deployment)
+			oldDeployment := old.(*appsv1.Deployment)
+
+			parsed := DeploymentName(*newDeployment, ParseOptions{Delimiter: provider.delimiter})
+			if e, ok := groupChange(parsed.Original, oldDeployment.Labels, newDeployment.Labels); ok {
+				providers.SendGroupEvent(ctx, event, e)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			deployment, ok := obj.(*appsv1.Deployment)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if deployment, ok = tombstone.Obj.(*appsv1.Deployment); !ok {
+					return
+				}
+			}
+			parsed := DeploymentName(*deployment, ParseOptions{Delimiter: provider.delimiter})
+			if e, ok := groupChange(parsed.Original, deployment.Labels, nil); ok {
+				providers.SendGroupEvent(ctx, event, e)
+			}
+		},
+	}
+	provider.handle(ctx, provider.informerFactory().Apps().V1().Deployments().Informer(), handler)
+}
+
+func (provider *KubernetesProvider) watchGroupStatefulSets(ctx context.Context, event chan<- providers.GroupEvent) {
+	handler := cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			statefulSet := obj.(*appsv1.StatefulSet)
+			parsed := StatefulSetName(*statefulSet, ParseOptions{Delimiter: provider.delimiter})
+			if e, ok := groupChange(parsed.Original, nil, statefulSet.Labels); ok {
+				providers.SendGroupEvent(ctx, event, e)
+			}
+		},
+		UpdateFunc: func(old, new interface{}) {
+			newStatefulSet := new.(*appsv1.StatefulSet)
+			oldStatefulSet := old.(*appsv1.StatefulSet)
 
-	informer.AddEventHandler(handler)
-	return informer
+			parsed := StatefulSetName(*newStatefulSet, ParseOptions{Delimiter: provider.delimiter})
+			if e, ok := groupChange(parsed.Original, oldStatefulSet.Labels, newStatefulSet.Labels); ok {
+				providers.SendGroupEvent(ctx, event, e)
+			}
+		},
+		DeleteFunc: func(obj interface{}) {
+			statefulSet, ok := obj.(*appsv1.StatefulSet)
+			if !ok {
+				tombstone, ok := obj.(cache.DeletedFinalStateUnknown)
+				if !ok {
+					return
+				}
+				if statefulSet, ok = tombstone.Obj.(*appsv1.StatefulSet); !ok {
+					return
+				}
+			}
+			parsed := StatefulSetName(*statefulSet, ParseOptions{Delimiter: provider.delimiter})
+			if e, ok := groupChange(parsed.Original, statefulSet.Labels, nil); ok {
+				providers.SendGroupEvent(ctx, event, e)
+			}
+		},
+	}
+	provider.handle(ctx, provider.informerFactory().Apps().V1().StatefulSets().Informer(), handler)
 }
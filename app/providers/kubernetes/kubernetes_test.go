@@ -4,13 +4,16 @@ import (
 	"context"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
 	"github.com/acouvreur/sablier/app/providers/mocks"
 	"github.com/stretchr/testify/mock"
 	v1 "k8s.io/api/apps/v1"
 	autoscalingv1 "k8s.io/api/autoscaling/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
 )
 
 func TestKubernetesProvider_Start(t *testing.T) {
@@ -286,3 +289,69 @@ func TestKubernetesProvider_GetState(t *testing.T) {
 		})
 	}
 }
+
+func Test_groupChange(t *testing.T) {
+	enabled := map[string]string{"sablier.enable": "true"}
+	internal := map[string]string{"sablier.enable": "true", "sablier.group": "internal"}
+	tests := []struct {
+		name   string
+		old    map[string]string
+		new    map[string]string
+		want   providers.GroupEvent
+		wantOk bool
+	}{
+		{name: "added", old: nil, new: enabled, want: providers.GroupEvent{Instance: "nginx", Group: "default"}, wantOk: true},
+		{name: "added without sablier", old: nil, new: map[string]string{}},
+		{name: "moved to another group", old: enabled, new: internal, want: providers.GroupEvent{Instance: "nginx", Group: "internal"}, wantOk: true},
+		{name: "resynced", old: internal, new: internal},
+		{name: "enabled", old: map[string]string{}, new: internal, want: providers.GroupEvent{Instance: "nginx", Group: "internal"}, wantOk: true},
+		{name: "disabled", old: internal, new: map[string]string{}, want: providers.GroupEvent{Instance: "nginx", Removed: true}, wantOk: true},
+		{name: "deleted", old: enabled, new: nil, want: providers.GroupEvent{Instance: "nginx", Removed: true}, wantOk: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := groupChange("nginx", tt.old, tt.new)
+			if ok != tt.wantOk || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("groupChange() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOk)
+			}
+		})
+	}
+}
+
+func TestKubernetesProvider_NotifyInstanceStoppedRemovesHandlers(t *testing.T) {
+	client := fake.NewSimpleClientset(&v1.Deployment{ObjectMeta: metav1.ObjectMeta{Name: "nginx", Namespace: "default"}})
+	provider := KubernetesProvider{Client: client, delimiter: "_"}
+	stopped := make(chan string, 10)
+
+	// The leadership is acquired and lost several times
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		provider.NotifyInstanceStopped(ctx, stopped)
+		cancel()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	provider.NotifyInstanceStopped(ctx, stopped)
+	provider.informerFactory().WaitForCacheSync(ctx.Done())
+	time.Sleep(100 * time.Millisecond)
+
+	err := client.AppsV1().Deployments("default").Delete(ctx, "nginx", metav1.DeleteOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case name := <-stopped:
+		if name != "deployment_default_nginx_1" {
+			t.Errorf("NotifyInstanceStopped() = %v, want deployment_default_nginx_1", name)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("the deletion was not notified")
+	}
+	// The handlers of the previous leaderships were removed
+	select {
+	case name := <-stopped:
+		t.Errorf("NotifyInstanceStopped() notified %v again", name)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
@@ -53,6 +53,21 @@ func (client *DockerAPIClientMock) ContainerInspect(ctx context.Context, contain
 	return args.Get(0).(types.ContainerJSON), args.Error(1)
 }
 
+func (client *DockerAPIClientMock) ContainerExecCreate(ctx context.Context, container string, options container.ExecOptions) (types.IDResponse, error) {
+	args := client.Mock.Called(ctx, container, options)
+	return args.Get(0).(types.IDResponse), args.Error(1)
+}
+
+func (client *DockerAPIClientMock) ContainerExecAttach(ctx context.Context, execID string, options container.ExecAttachOptions) (types.HijackedResponse, error) {
+	args := client.Mock.Called(ctx, execID, options)
+	return args.Get(0).(types.HijackedResponse), args.Error(1)
+}
+
+func (client *DockerAPIClientMock) ContainerExecInspect(ctx context.Context, execID string) (container.ExecInspect, error) {
+	args := client.Mock.Called(ctx, execID)
+	return args.Get(0).(container.ExecInspect), args.Error(1)
+}
+
 func (client *DockerAPIClientMock) Events(ctx context.Context, options types.EventsOptions) (<-chan events.Message, <-chan error) {
 	// client.Mock.Called(ctx, options)
 	evnts := make(chan events.Message)
@@ -197,6 +212,36 @@ func ContainerStoppedEvent(name string) events.Message {
 	}
 }
 
+func ContainerStartedEvent(name string) events.Message {
+	return events.Message{
+		From:   name,
+		Scope:  "local",
+		Action: "start",
+		Type:   "container",
+		Actor: events.Actor{
+			ID: "randomid",
+			Attributes: map[string]string{
+				"name": name,
+			},
+		},
+	}
+}
+
+func ContainerHealthStatusEvent(name string, status string) events.Message {
+	return events.Message{
+		From:   name,
+		Scope:  "local",
+		Action: events.Action("health_status: " + status),
+		Type:   "container",
+		Actor: events.Actor{
+			ID: "randomid",
+			Attributes: map[string]string{
+				"name": name,
+			},
+		},
+	}
+}
+
 func (client *DockerAPIClientMock) ServiceUpdate(ctx context.Context, serviceID string, version swarm.Version, service swarm.ServiceSpec, options types.ServiceUpdateOptions) (swarm.ServiceUpdateResponse, error) {
 	args := client.Mock.Called(ctx, serviceID, version, service, options)
 	return args.Get(0).(swarm.ServiceUpdateResponse), args.Error(1)
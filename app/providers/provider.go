@@ -16,3 +16,40 @@ type Provider interface {
 
 	NotifyInstanceStopped(ctx context.Context, instance chan<- string)
 }
+
+// ReadinessNotifier is an optional interface implemented by providers able to
+// notify when an instance might have become ready.
+//
+// Notifications are hints: the instance state is always checked again with GetState.
+type ReadinessNotifier interface {
+	NotifyInstanceReady(ctx context.Context, instance chan<- string)
+}
+
+// GroupsNotifier is an optional interface implemented by providers able to notify
+// when an instance is added to or removed from a group.
+//
+// NotifyGroupChanged might return before ctx is done and keep sending from its own goroutines.
+// The event channel is never closed and is not read anymore once ctx is done, see SendGroupEvent.
+type GroupsNotifier interface {
+	NotifyGroupChanged(ctx context.Context, event chan<- GroupEvent)
+}
+
+// SendGroupEvent sends e on event, unless ctx is done first
+func SendGroupEvent(ctx context.Context, event chan<- GroupEvent, e GroupEvent) {
+	select {
+	case event <- e:
+	case <-ctx.Done():
+	}
+}
+
+// LabelsProvider is an optional interface implemented by providers able to read
+// the labels (or annotations) of an instance.
+type LabelsProvider interface {
+	GetLabels(ctx context.Context, name string) (map[string]string, error)
+}
+
+// Executor is an optional interface implemented by providers able to run a command
+// inside an instance, used by the pre-stop hooks.
+type Executor interface {
+	Exec(ctx context.Context, name string, cmd []string) error
+}
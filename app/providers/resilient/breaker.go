@@ -0,0 +1,80 @@
+package resilient
+
+import (
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// breaker opens after consecutive transient errors so that the calls fail fast
+// while the provider is degraded. Once the open duration elapsed, a single call is
+// let through: its success closes the breaker, its failure opens it again.
+type breaker struct {
+	config config.CircuitBreaker
+	now    func() time.Time
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow returns false when the call must fail fast
+func (b *breaker) allow() bool {
+	if b.config.Threshold <= 0 {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.config.Threshold {
+		return true
+	}
+	if b.now().Before(b.openUntil) || b.probing {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// done records the result of a call
+func (b *breaker) done(err error) {
+	if b.config.Threshold <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if providers.Classify(err) != providers.Transient {
+		if b.failures >= b.config.Threshold {
+			log.Info("provider recovered")
+		}
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.config.Threshold {
+		if b.failures == b.config.Threshold {
+			log.Warnf("provider degraded after %d consecutive failures: %v", b.failures, err)
+		}
+		b.openUntil = b.now().Add(b.config.OpenDuration)
+	}
+}
+
+// degraded returns true while the breaker is not closed
+func (b *breaker) degraded() bool {
+	if b.config.Threshold <= 0 {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.failures >= b.config.Threshold
+}
@@ -0,0 +1,277 @@
+// Package resilient wraps a provider to retry the calls failing with a transient error
+// and to fail fast while the provider is degraded.
+package resilient
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/types"
+	"github.com/acouvreur/sablier/config"
+	log "github.com/sirupsen/logrus"
+)
+
+// Interface guards
+var _ providers.Provider = (*Provider)(nil)
+var _ providers.HealthReporter = (*Provider)(nil)
+var _ providers.ReadinessNotifier = readinessNotifier{}
+var _ providers.GroupsNotifier = groupsNotifier{}
+var _ providers.LabelsProvider = labelsProvider{}
+var _ providers.Executor = executor{}
+
+// Provider forwards the calls to the wrapped provider
+type Provider struct {
+	provider providers.Provider
+	config   config.Retry
+	breaker  *breaker
+	sleep    func(ctx context.Context, d time.Duration) bool
+}
+
+// New wraps the provider. The returned provider implements the optional interfaces implemented
+// by the wrapped provider only, so that the callers can tell what the wrapped provider supports.
+func New(provider providers.Provider, conf config.Provider) providers.Provider {
+	return newProvider(provider, conf).withCapabilities()
+}
+
+func newProvider(provider providers.Provider, conf config.Provider) *Provider {
+	return &Provider{
+		provider: provider,
+		config:   conf.Retry,
+		breaker:  &breaker{config: conf.CircuitBreaker, now: time.Now},
+		sleep:    sleep,
+	}
+}
+
+// capabilities are the optional interfaces implemented by a provider
+type capabilities int
+
+const (
+	readiness capabilities = 1 << iota
+	groups
+	labels
+	exec
+)
+
+// withCapabilities returns the provider implementing the optional interfaces of the wrapped provider
+func (p *Provider) withCapabilities() providers.Provider {
+	var c capabilities
+	if _, ok := p.provider.(providers.ReadinessNotifier); ok {
+		c |= readiness
+	}
+	if _, ok := p.provider.(providers.GroupsNotifier); ok {
+		c |= groups
+	}
+	if _, ok := p.provider.(providers.LabelsProvider); ok {
+		c |= labels
+	}
+	if _, ok := p.provider.(providers.Executor); ok {
+		c |= exec
+	}
+
+	r, g, l, e := readinessNotifier{p}, groupsNotifier{p}, labelsProvider{p}, executor{p}
+	switch c {
+	case readiness:
+		return struct {
+			*Provider
+			readinessNotifier
+		}{p, r}
+	case groups:
+		return struct {
+			*Provider
+			groupsNotifier
+		}{p, g}
+	case labels:
+		return struct {
+			*Provider
+			labelsProvider
+		}{p, l}
+	case exec:
+		return struct {
+			*Provider
+			executor
+		}{p, e}
+	case readiness | groups:
+		return struct {
+			*Provider
+			readinessNotifier
+			groupsNotifier
+		}{p, r, g}
+	case readiness | labels:
+		return struct {
+			*Provider
+			readinessNotifier
+			labelsProvider
+		}{p, r, l}
+	case readiness | exec:
+		return struct {
+			*Provider
+			readinessNotifier
+			executor
+		}{p, r, e}
+	case groups | labels:
+		return struct {
+			*Provider
+			groupsNotifier
+			labelsProvider
+		}{p, g, l}
+	case groups | exec:
+		return struct {
+			*Provider
+			groupsNotifier
+			executor
+		}{p, g, e}
+	case labels | exec:
+		return struct {
+			*Provider
+			labelsProvider
+			executor
+		}{p, l, e}
+	case readiness | groups | labels:
+		return struct {
+			*Provider
+			readinessNotifier
+			groupsNotifier
+			labelsProvider
+		}{p, r, g, l}
+	case readiness | groups | exec:
+		return struct {
+			*Provider
+			readinessNotifier
+			groupsNotifier
+			executor
+		}{p, r, g, e}
+	case readiness | labels | exec:
+		return struct {
+			*Provider
+			readinessNotifier
+			labelsProvider
+			executor
+		}{p, r, l, e}
+	case groups | labels | exec:
+		return struct {
+			*Provider
+			groupsNotifier
+			labelsProvider
+			executor
+		}{p, g, l, e}
+	case readiness | groups | labels | exec:
+		return struct {
+			*Provider
+			readinessNotifier
+			groupsNotifier
+			labelsProvider
+			executor
+		}{p, r, g, l, e}
+	}
+	return p
+}
+
+// Degraded returns true after too many consecutive transient errors, until a call succeeds again
+func (p *Provider) Degraded() bool {
+	return p.breaker.degraded()
+}
+
+func (p *Provider) Start(ctx context.Context, name string) error {
+	return p.do(ctx, "start "+name, func() error {
+		return p.provider.Start(ctx, name)
+	})
+}
+
+func (p *Provider) Stop(ctx context.Context, name string) error {
+	return p.do(ctx, "stop "+name, func() error {
+		return p.provider.Stop(ctx, name)
+	})
+}
+
+func (p *Provider) GetState(ctx context.Context, name string) (state instance.State, err error) {
+	err = p.do(ctx, "get state of "+name, func() error {
+		state, err = p.provider.GetState(ctx, name)
+		return err
+	})
+	return state, err
+}
+
+func (p *Provider) GetGroups(ctx context.Context) (groups map[string][]string, err error) {
+	err = p.do(ctx, "get groups", func() error {
+		groups, err = p.provider.GetGroups(ctx)
+		return err
+	})
+	return groups, err
+}
+
+func (p *Provider) InstanceList(ctx context.Context, options providers.InstanceListOptions) (instances []types.Instance, err error) {
+	err = p.do(ctx, "list instances", func() error {
+		instances, err = p.provider.InstanceList(ctx, options)
+		return err
+	})
+	return instances, err
+}
+
+func (p *Provider) NotifyInstanceStopped(ctx context.Context, instance chan<- string) {
+	p.provider.NotifyInstanceStopped(ctx, instance)
+}
+
+type readinessNotifier struct{ p *Provider }
+
+func (n readinessNotifier) NotifyInstanceReady(ctx context.Context, instance chan<- string) {
+	n.p.provider.(providers.ReadinessNotifier).NotifyInstanceReady(ctx, instance)
+}
+
+type groupsNotifier struct{ p *Provider }
+
+func (n groupsNotifier) NotifyGroupChanged(ctx context.Context, event chan<- providers.GroupEvent) {
+	n.p.provider.(providers.GroupsNotifier).NotifyGroupChanged(ctx, event)
+}
+
+type labelsProvider struct{ p *Provider }
+
+func (l labelsProvider) GetLabels(ctx context.Context, name string) (labels map[string]string, err error) {
+	err = l.p.do(ctx, "get labels of "+name, func() error {
+		labels, err = l.p.provider.(providers.LabelsProvider).GetLabels(ctx, name)
+		return err
+	})
+	return labels, err
+}
+
+type executor struct{ p *Provider }
+
+// Exec is not retried, the command might not be idempotent
+func (e executor) Exec(ctx context.Context, name string, cmd []string) error {
+	return e.p.provider.(providers.Executor).Exec(ctx, name, cmd)
+}
+
+// do calls fn until it succeeds, fails with a non transient error or the retries are exhausted
+func (p *Provider) do(ctx context.Context, operation string, fn func() error) error {
+	backoff := p.config.Backoff
+	for attempt := 0; ; attempt++ {
+		if !p.breaker.allow() {
+			return fmt.Errorf("could not %s: %w", operation, providers.ErrDegraded)
+		}
+
+		err := fn()
+		p.breaker.done(err)
+		if err == nil || providers.Classify(err) != providers.Transient || attempt >= p.config.MaxRetries {
+			return err
+		}
+
+		log.Debugf("could not %s, retrying in %v: %v", operation, backoff, err)
+		if !p.sleep(ctx, backoff) {
+			return err
+		}
+		backoff = min(2*backoff, max(p.config.MaxBackoff, p.config.Backoff))
+	}
+}
+
+func sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
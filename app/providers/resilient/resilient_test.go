@@ -0,0 +1,130 @@
+package resilient
+
+import (
+	"context"
+	"errors"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/providers/mock"
+	"github.com/acouvreur/sablier/config"
+	tmock "github.com/stretchr/testify/mock"
+	"gotest.tools/v3/assert"
+)
+
+var errTransient = syscall.ECONNREFUSED
+
+func newTestProvider(retries int, threshold int) (*Provider, *mock.ProviderMock, *time.Time) {
+	providermock := &mock.ProviderMock{}
+	now := time.Now()
+	p := newProvider(providermock, config.Provider{
+		Retry:          config.Retry{MaxRetries: retries, Backoff: time.Millisecond, MaxBackoff: time.Millisecond},
+		CircuitBreaker: config.CircuitBreaker{Threshold: threshold, OpenDuration: time.Minute},
+	})
+	p.breaker.now = func() time.Time { return now }
+	p.sleep = func(context.Context, time.Duration) bool { return true }
+	return p, providermock, &now
+}
+
+func TestProvider_RetriesTransientErrors(t *testing.T) {
+	p, providermock, _ := newTestProvider(3, 0)
+	providermock.On("Start", tmock.Anything, "nginx").Return(errTransient).Twice()
+	providermock.On("Start", tmock.Anything, "nginx").Return(nil).Once()
+
+	err := p.Start(context.Background(), "nginx")
+
+	assert.NilError(t, err)
+	providermock.AssertNumberOfCalls(t, "Start", 3)
+}
+
+func TestProvider_GivesUpAfterMaxRetries(t *testing.T) {
+	p, providermock, _ := newTestProvider(2, 0)
+	providermock.On("GetState", tmock.Anything, "nginx").Return(instance.State{}, errTransient)
+
+	_, err := p.GetState(context.Background(), "nginx")
+
+	assert.ErrorIs(t, err, errTransient)
+	providermock.AssertNumberOfCalls(t, "GetState", 3)
+}
+
+func TestProvider_DoesNotRetryPermanentErrors(t *testing.T) {
+	p, providermock, _ := newTestProvider(3, 0)
+	providermock.On("Start", tmock.Anything, "nginx").Return(errors.New("invalid name")).Once()
+	providermock.On("Stop", tmock.Anything, "nginx").Return(providers.ErrNotFound).Once()
+
+	assert.ErrorContains(t, p.Start(context.Background(), "nginx"), "invalid name")
+	assert.ErrorIs(t, p.Stop(context.Background(), "nginx"), providers.ErrNotFound)
+	providermock.AssertNumberOfCalls(t, "Start", 1)
+	providermock.AssertNumberOfCalls(t, "Stop", 1)
+}
+
+func TestProvider_CircuitBreaker(t *testing.T) {
+	p, providermock, now := newTestProvider(0, 2)
+	providermock.On("Start", tmock.Anything, "nginx").Return(errTransient).Twice()
+
+	assert.ErrorIs(t, p.Start(context.Background(), "nginx"), errTransient)
+	assert.Assert(t, !p.Degraded())
+	assert.ErrorIs(t, p.Start(context.Background(), "nginx"), errTransient)
+	assert.Assert(t, p.Degraded())
+
+	// The calls fail fast while the circuit is open
+	err := p.Start(context.Background(), "nginx")
+	assert.ErrorIs(t, err, providers.ErrDegraded)
+	assert.Equal(t, providers.Classify(err), providers.Transient)
+	providermock.AssertNumberOfCalls(t, "Start", 2)
+
+	// A call is tried again after the open duration, its success closes the circuit
+	*now = now.Add(2 * time.Minute)
+	providermock.On("Start", tmock.Anything, "nginx").Return(nil).Once()
+	assert.NilError(t, p.Start(context.Background(), "nginx"))
+	assert.Assert(t, !p.Degraded())
+}
+
+func TestProvider_CircuitBreakerProbeFailure(t *testing.T) {
+	p, providermock, now := newTestProvider(0, 1)
+	providermock.On("Start", tmock.Anything, "nginx").Return(errTransient)
+
+	assert.ErrorIs(t, p.Start(context.Background(), "nginx"), errTransient)
+	*now = now.Add(2 * time.Minute)
+	assert.ErrorIs(t, p.Start(context.Background(), "nginx"), errTransient)
+
+	assert.ErrorIs(t, p.Start(context.Background(), "nginx"), providers.ErrDegraded)
+	assert.Assert(t, p.Degraded())
+	providermock.AssertNumberOfCalls(t, "Start", 2)
+}
+
+func TestNew_Capabilities(t *testing.T) {
+	p := New(&mock.ProviderMock{}, config.NewProviderConfig())
+
+	// The wrapped provider implements none of the optional interfaces
+	_, ok := p.(providers.ReadinessNotifier)
+	assert.Assert(t, !ok)
+	_, ok = p.(providers.GroupsNotifier)
+	assert.Assert(t, !ok)
+	_, ok = p.(providers.LabelsProvider)
+	assert.Assert(t, !ok)
+	_, ok = p.(providers.Executor)
+	assert.Assert(t, !ok)
+	_, ok = p.(providers.HealthReporter)
+	assert.Assert(t, ok)
+
+	p = New(&labelsProviderMock{}, config.NewProviderConfig())
+	labeler, ok := p.(providers.LabelsProvider)
+	assert.Assert(t, ok)
+	labels, err := labeler.GetLabels(context.Background(), "nginx")
+	assert.NilError(t, err)
+	assert.DeepEqual(t, labels, map[string]string{"sablier.enable": "true"})
+	_, ok = p.(providers.Executor)
+	assert.Assert(t, !ok)
+}
+
+type labelsProviderMock struct {
+	mock.ProviderMock
+}
+
+func (m *labelsProviderMock) GetLabels(ctx context.Context, name string) (map[string]string, error) {
+	return map[string]string{"sablier.enable": "true"}, nil
+}
@@ -4,3 +4,11 @@ type InstanceListOptions struct {
 	All    bool
 	Labels []string
 }
+
+// GroupEvent describes an instance joining or leaving a group
+type GroupEvent struct {
+	Instance string
+	Group    string
+	// Removed is true when the instance does not belong to any group anymore
+	Removed bool
+}
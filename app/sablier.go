@@ -4,23 +4,38 @@ import (
 	"context"
 	"fmt"
 	"github.com/acouvreur/sablier/app/discovery"
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
 	"github.com/acouvreur/sablier/app/providers/docker"
 	"github.com/acouvreur/sablier/app/providers/dockerswarm"
 	"github.com/acouvreur/sablier/app/providers/kubernetes"
+	"github.com/acouvreur/sablier/app/providers/resilient"
+	"io"
 	"os"
+	"time"
 
 	"github.com/acouvreur/sablier/app/http"
-	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/leader"
+	"github.com/acouvreur/sablier/app/prewarm"
 	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/savings"
 	"github.com/acouvreur/sablier/app/sessions"
+	"github.com/acouvreur/sablier/app/stats"
 	"github.com/acouvreur/sablier/app/storage"
 	"github.com/acouvreur/sablier/app/theme"
 	"github.com/acouvreur/sablier/config"
+	"github.com/acouvreur/sablier/pkg/rediskv"
 	"github.com/acouvreur/sablier/pkg/tinykv"
 	"github.com/acouvreur/sablier/version"
 	log "github.com/sirupsen/logrus"
 )
 
+// webhookBufferSize is the number of events buffered while the webhook is being called
+const webhookBufferSize = 256
+
+// statsBufferSize is the number of events buffered while the stats are being recorded
+const statsBufferSize = 64
+
 func Start(conf config.Config) error {
 
 	logLevel, err := log.ParseLevel(conf.Logging.Level)
@@ -34,32 +49,178 @@ func Start(conf config.Config) error {
 
 	log.Info(version.Info())
 
-	provider, err := NewProvider(conf.Provider)
+	p, err := NewProvider(conf.Provider)
 	if err != nil {
 		return err
 	}
+	provider := resilient.New(p, conf.Provider)
 
 	log.Infof("using provider \"%s\"", conf.Provider.Name)
 
-	store := tinykv.New(conf.Sessions.ExpirationInterval, onSessionExpires(provider))
+	bus := events.NewBus()
+	if conf.Events.Webhook.URL != "" {
+		types, err := events.ParseTypes(conf.Events.Webhook.Events)
+		if err != nil {
+			return err
+		}
+		received, unsubscribe := bus.Subscribe(webhookBufferSize, types...)
+		defer unsubscribe()
+		go events.NewWebhook(conf.Events.Webhook).Run(context.Background(), received)
+		log.Infof("sending lifecycle events to webhook %s", conf.Events.Webhook.URL)
+	}
 
-	storage, err := storage.NewFileStorage(conf.Storage)
+	tracker := stats.NewTracker()
+	transitions, unsubscribeStats := bus.Subscribe(statsBufferSize)
+	defer unsubscribeStats()
+	go tracker.Run(context.Background(), transitions)
+
+	storage, err := storage.New(conf.Storage)
 	if err != nil {
 		return err
 	}
+	if closer, ok := storage.(io.Closer); ok {
+		defer closer.Close()
+	}
+
+	elector, err := newElector(conf.LeaderElection, storage)
+	if err != nil {
+		return err
+	}
+	if elector != nil {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go elector.Run(ctx)
+		log.Infof("electing the leader with the %s lock", conf.LeaderElection.Lock)
+	}
 
-	sessionsManager := sessions.NewSessionsManager(store, provider)
+	stopper := sessions.NewStopper(provider, bus, conf.Sessions)
+	store, persistent := newSessionsStore(storage, conf.Sessions.ExpirationInterval, conf.Storage.FlushInterval, func(name string, state instance.State) {
+		// The expired sessions are stopped by the leader only
+		if !elector.IsLeader() {
+			log.Debugf("session %s expired, it is stopped by the leader", name)
+			return
+		}
+		stopper.OnExpire(name, state)
+	})
+	if shared, ok := store.(*rediskv.KV[instance.State]); ok {
+		// The other replicas claim the expired sessions to stop them once they are the leader
+		shared.ClaimIf(elector.IsLeader)
+	}
+
+	var history *prewarm.History
+	var recorder sessions.RequestRecorder
+	if conf.Prewarm.Enabled {
+		history = prewarm.NewHistory(conf.Prewarm.Retention)
+		recorder = history
+	}
+
+	var sessionsLeader sessions.Leader
+	if elector != nil {
+		sessionsLeader = elector
+	}
+	sessionsManager := sessions.NewSessionsManager(store, provider, stopper, bus, recorder, sessionsLeader, conf.Sessions)
 	defer sessionsManager.Stop()
 
-	if storage.Enabled() {
+	if _, shared := store.(*rediskv.KV[instance.State]); shared {
+		// The sessions are kept in Redis, they are neither restored nor saved
+	} else if persistent != nil {
+		// Each change is already persisted, the sessions are not saved on shutdown
+		expired, err := persistent.Restore()
+		if err != nil {
+			log.Error("error restoring sessions", err)
+		}
+		sessionsManager.ExpiredWhileDown(expired)
+	} else if storage.Enabled() {
 		defer saveSessions(storage, sessionsManager)
 		loadSessions(storage, sessionsManager)
+		defer saveEvery(conf.Storage.SnapshotInterval, func() { saveSessions(storage, sessionsManager) })()
+	}
+	failuresStorage, err := storage.Namespace("stop-failures")
+	if err != nil {
+		return err
+	}
+	if failuresStorage.Enabled() {
+		// Only the leader stops the instances, it keeps their stop failures
+		saveFailures := func() {
+			if elector.IsLeader() {
+				saveStopFailures(failuresStorage, stopper)
+			}
+		}
+		defer saveFailures()
+		defer saveEvery(conf.Storage.SnapshotInterval, saveFailures)()
 	}
+	elector.WhileLeading(func(ctx context.Context) {
+		// The restored stop failures are cleared by the reconciliation if the instances stopped since
+		if failuresStorage.Enabled() {
+			loadStopFailures(failuresStorage, stopper)
+		}
+		reconcileEvery(ctx, sessionsManager, conf.Sessions.ReconcileInterval)
+	})
 
-	if conf.Provider.AutoStopOnStartup {
-		err := discovery.StopAllUnregisteredInstances(context.Background(), provider, store.Keys())
+	var prewarmer *prewarm.Prewarmer
+	if conf.Prewarm.Enabled {
+		historyStorage, err := storage.Namespace("prewarm")
 		if err != nil {
-			log.Warnf("Stopping unregistered instances had an error: %v", err)
+			return err
+		}
+		if historyStorage.Enabled() {
+			defer saveHistory(historyStorage, history)
+			loadHistory(historyStorage, history)
+			defer saveEvery(conf.Storage.SnapshotInterval, func() { saveHistory(historyStorage, history) })()
+		}
+
+		prewarmer = prewarm.NewPrewarmer(sessionsManager, history, prewarm.SystemClock, conf.Prewarm, conf.Sessions.DefaultDuration)
+		elector.WhileLeading(prewarmer.Run)
+		log.Infof("pre-warming instances %v before their predicted first request", conf.Prewarm.Lookahead)
+	}
+
+	ledger := savings.NewLedger(time.Now())
+	ledgerStorage, err := storage.Namespace("savings")
+	if err != nil {
+		return err
+	}
+	if ledgerStorage.Enabled() {
+		defer saveLedger(ledgerStorage, ledger)
+		loadLedger(ledgerStorage, ledger)
+		defer saveEvery(conf.Storage.SnapshotInterval, func() { saveLedger(ledgerStorage, ledger) })()
+	}
+	periods, unsubscribeSavings := bus.Subscribe(statsBufferSize, events.InstanceStarting, events.InstanceStopped, events.InstanceUnrecoverable)
+	defer unsubscribeSavings()
+	go ledger.Run(context.Background(), periods)
+	// The instances already running were not seen starting
+	go ledger.Seed(context.Background(), provider, append(ledger.Instances(), managedInstances(sessionsManager.Groups().Groups())...), time.Now())
+	labels, _ := provider.(providers.LabelsProvider)
+	reporter := savings.NewReporter(ledger, labels, func() []string {
+		return managedInstances(sessionsManager.Groups().Groups())
+	})
+
+	registered := func() []string {
+		// The draining instances are stopped once their drain period ends
+		names := store.Keys()
+		for name := range stopper.Draining() {
+			names = append(names, name)
+		}
+		return names
+	}
+	adopt := func(name string) {
+		sessionsManager.WarmSession([]string{name}, conf.Sessions.DefaultDuration)
+	}
+	reaper, err := discovery.NewReaper(provider, bus, registered, adopt, conf.Provider)
+	if err != nil {
+		return err
+	}
+	if reaper.Enabled() {
+		if elector == nil {
+			// The orphans are handled before the server starts
+			reaper.Startup(context.Background())
+			elector.WhileLeading(reaper.Run)
+		} else {
+			// Each new leader handles the instances without a session in the shared store,
+			// the leadership is acquired concurrently with the first requests
+			elector.WhileLeading(func(ctx context.Context) {
+				reaper.Startup(ctx)
+				reaper.Run(ctx)
+			})
 		}
 	}
 
@@ -80,23 +241,80 @@ func Start(conf config.Config) error {
 		}
 	}
 
-	http.Start(conf.Server, conf.Strategy, conf.Sessions, sessionsManager, t)
+	health, _ := provider.(providers.HealthReporter)
+	http.Start(conf.Server, conf.Strategy, conf.Sessions, sessionsManager, t, prewarmer, health, tracker, reporter)
 
 	return nil
 }
 
-func onSessionExpires(provider providers.Provider) func(key string, instance instance.State) {
-	return func(_key string, _instance instance.State) {
-		go func(key string, instance instance.State) {
-			log.Debugf("stopping %s...", key)
-			err := provider.Stop(context.Background(), key)
+// newElector returns the leader elector, nil if the leader election is disabled
+func newElector(conf config.LeaderElection, s storage.Storage) (*leader.Elector, error) {
+	if shared, ok := s.(*storage.RedisStorage); ok {
+		return leader.New(conf, shared.Client(), shared.Prefix())
+	}
+	return leader.New(conf, nil, "")
+}
+
+// newSessionsStore returns the sessions store of the storage backend. The persistent store is returned
+// as well when the backend persists each change, it is nil otherwise.
+// The expiration interval only applies to the redis backend, the other stores remove the expired
+// sessions within tinykv.Resolution. The flush interval only applies to the persistent store.
+func newSessionsStore(s storage.Storage, expirationInterval time.Duration, flushInterval time.Duration, onExpire func(string, instance.State)) (tinykv.KV[instance.State], *storage.PersistentKV[instance.State]) {
+	switch s := s.(type) {
+	case *storage.RedisStorage:
+		return rediskv.New(s.Client(), s.Prefix()+"sessions:", expirationInterval, onExpire), nil
+	case storage.KVStorage:
+		persistent := storage.NewPersistentKV(s, tinykv.Resolution, flushInterval, onExpire)
+		return persistent, persistent
+	}
+	return tinykv.New(tinykv.Resolution, onExpire), nil
+}
+
+// saveEvery calls save at each interval until the returned function is called, it then waits for
+// the save in progress so that it does not overwrite the save on exit
+func saveEvery(interval time.Duration, save func()) (stop func()) {
+	if interval <= 0 {
+		return func() {}
+	}
 
-			if err != nil {
-				log.Warnf("error stopping %s: %s", key, err.Error())
-			} else {
-				log.Debugf("stopped %s", key)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				save()
 			}
-		}(_key, _instance)
+		}
+	}()
+
+	return func() {
+		cancel()
+		<-done
+	}
+}
+
+// reconcileEvery reconciles the sessions right away, then at each interval until ctx is done
+func reconcileEvery(ctx context.Context, sessions sessions.Manager, interval time.Duration) {
+	sessions.Reconcile(ctx)
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			sessions.Reconcile(ctx)
+		}
 	}
 }
 
@@ -104,6 +322,7 @@ func loadSessions(storage storage.Storage, sessions sessions.Manager) {
 	reader, err := storage.Reader()
 	if err != nil {
 		log.Error("error loading sessions", err)
+		return
 	}
 	err = sessions.LoadSessions(reader)
 	if err != nil {
@@ -123,6 +342,87 @@ func saveSessions(storage storage.Storage, sessions sessions.Manager) {
 	}
 }
 
+func loadHistory(storage storage.Storage, history *prewarm.History) {
+	reader, err := storage.Reader()
+	if err != nil {
+		log.Error("error loading requests history", err)
+		return
+	}
+	err = history.Load(reader)
+	if err != nil {
+		log.Error("error loading requests history", err)
+	}
+}
+
+func saveHistory(storage storage.Storage, history *prewarm.History) {
+	writer, err := storage.Writer()
+	if err != nil {
+		log.Error("error saving requests history", err)
+		return
+	}
+	err = history.Save(writer)
+	if err != nil {
+		log.Error("error saving requests history", err)
+	}
+}
+
+func loadLedger(storage storage.Storage, ledger *savings.Ledger) {
+	reader, err := storage.Reader()
+	if err != nil {
+		log.Error("error loading savings ledger", err)
+		return
+	}
+	err = ledger.Load(reader)
+	if err != nil {
+		log.Error("error loading savings ledger", err)
+	}
+}
+
+func saveLedger(storage storage.Storage, ledger *savings.Ledger) {
+	writer, err := storage.Writer()
+	if err != nil {
+		log.Error("error saving savings ledger", err)
+		return
+	}
+	err = ledger.Save(writer)
+	if err != nil {
+		log.Error("error saving savings ledger", err)
+	}
+}
+
+func loadStopFailures(storage storage.Storage, stopper *sessions.Stopper) {
+	reader, err := storage.Reader()
+	if err != nil {
+		log.Error("error loading stop failures", err)
+		return
+	}
+	err = stopper.Load(reader)
+	if err != nil {
+		log.Error("error loading stop failures", err)
+	}
+}
+
+func saveStopFailures(storage storage.Storage, stopper *sessions.Stopper) {
+	writer, err := storage.Writer()
+	if err != nil {
+		log.Error("error saving stop failures", err)
+		return
+	}
+	err = stopper.Save(writer)
+	if err != nil {
+		log.Error("error saving stop failures", err)
+	}
+}
+
+// managedInstances returns the instances of all the groups
+func managedInstances(groups map[string][]string) []string {
+	var names []string
+	for _, instances := range groups {
+		names = append(names, instances...)
+	}
+	return names
+}
+
 func NewProvider(config config.Provider) (providers.Provider, error) {
 	if err := config.IsValid(); err != nil {
 		return nil, err
@@ -0,0 +1,206 @@
+// Package savings records when the instances are running and reports the
+// uptime avoided by stopping them.
+package savings
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/storage"
+	log "github.com/sirupsen/logrus"
+)
+
+// Retention is how long the running periods are kept
+const Retention = 90 * 24 * time.Hour
+
+// Period is a time range during which an instance was running, End is nil while it is still running
+type Period struct {
+	Start time.Time  `json:"start"`
+	End   *time.Time `json:"end,omitempty"`
+}
+
+// Ledger records the running periods of the instances from the lifecycle events
+type Ledger struct {
+	mu sync.RWMutex
+	// since is when the ledger started recording, the instances are considered stopped before their first period
+	since   time.Time
+	periods map[string][]Period
+}
+
+func NewLedger(since time.Time) *Ledger {
+	return &Ledger{
+		since:   since,
+		periods: make(map[string][]Period),
+	}
+}
+
+// Run records the events until the context is done or the channel is closed
+func (l *Ledger) Run(ctx context.Context, received <-chan events.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-received:
+			if !ok {
+				return
+			}
+			l.Record(event)
+		}
+	}
+}
+
+// Record opens a period when an instance is starting and closes it when it stopped or could not start
+func (l *Ledger) Record(event events.Event) {
+	switch event.Type {
+	case events.InstanceStarting:
+		l.observe(event.Instance, true, event.Time)
+	case events.InstanceStopped, events.InstanceUnrecoverable:
+		l.observe(event.Instance, false, event.Time)
+	}
+}
+
+// Seed observes the current state of the instances when the recording starts, so that the instances
+// already running, whose start was not recorded, are not counted as stopped
+func (l *Ledger) Seed(ctx context.Context, provider providers.Provider, names []string, at time.Time) {
+	for _, name := range names {
+		state, err := provider.GetState(ctx, name)
+		if err != nil {
+			log.Warnf("could not read the state of %s to seed the savings ledger: %v", name, err)
+			continue
+		}
+		l.observe(name, state.CurrentReplicas > 0, at)
+	}
+}
+
+// observe opens a period if the instance is running and has no open period, and closes
+// the open period if the instance is not running
+func (l *Ledger) observe(name string, running bool, at time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	periods := l.periods[name]
+	open := len(periods) > 0 && periods[len(periods)-1].End == nil
+
+	switch {
+	case running && !open:
+		periods = append(periods, Period{Start: at})
+	case !running && open:
+		end := at
+		periods[len(periods)-1].End = &end
+	default:
+		return
+	}
+
+	l.periods[name] = prune(periods, at.Add(-Retention))
+}
+
+// Since returns when the ledger started recording
+func (l *Ledger) Since() time.Time {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.since
+}
+
+// Instances returns the instances with at least one period, sorted
+func (l *Ledger) Instances() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	names := make([]string, 0, len(l.periods))
+	for name := range l.periods {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Running returns how long the instance was running between from and to
+func (l *Ledger) Running(name string, from time.Time, to time.Time) time.Duration {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	var running time.Duration
+	for _, period := range l.periods[name] {
+		end := to
+		if period.End != nil && period.End.Before(to) {
+			end = *period.End
+		}
+		start := period.Start
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(start) {
+			running += end.Sub(start)
+		}
+	}
+	return running
+}
+
+func (l *Ledger) Load(reader io.ReadCloser) error {
+	defer reader.Close()
+	return json.NewDecoder(reader).Decode(l)
+}
+
+func (l *Ledger) Save(writer io.WriteCloser) error {
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return storage.Close(writer, encoder.Encode(l))
+}
+
+type ledgerJSON struct {
+	Since   time.Time           `json:"since"`
+	SavedAt time.Time           `json:"savedAt"`
+	Periods map[string][]Period `json:"periods"`
+}
+
+func (l *Ledger) MarshalJSON() ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return json.Marshal(ledgerJSON{Since: l.since, SavedAt: time.Now(), Periods: l.periods})
+}
+
+func (l *Ledger) UnmarshalJSON(b []byte) error {
+	var saved ledgerJSON
+	if err := json.Unmarshal(b, &saved); err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	// The recording started with the saved ledger
+	if !saved.Since.IsZero() && saved.Since.Before(l.since) {
+		l.since = saved.Since
+	}
+	// Nothing was recorded after the ledger was saved, the periods still open are closed when it was saved,
+	// or now for the ledgers saved without their time. The instances still running are seeded again.
+	closedAt := saved.SavedAt
+	if closedAt.IsZero() {
+		closedAt = time.Now()
+	}
+	for name, periods := range saved.Periods {
+		if last := len(periods) - 1; last >= 0 && periods[last].End == nil {
+			end := closedAt
+			if end.Before(periods[last].Start) {
+				end = periods[last].Start
+			}
+			periods[last].End = &end
+		}
+		l.periods[name] = periods
+	}
+	return nil
+}
+
+// prune removes the periods which ended before the given time
+func prune(periods []Period, before time.Time) []Period {
+	i := 0
+	for i < len(periods) && periods[i].End != nil && periods[i].End.Before(before) {
+		i++
+	}
+	return periods[i:]
+}
@@ -0,0 +1,107 @@
+package savings
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions/mocks"
+	"gotest.tools/v3/assert"
+)
+
+var day = time.Date(2024, 10, 21, 0, 0, 0, 0, time.UTC)
+
+func record(l *Ledger, name string, t events.Type, at time.Time) {
+	l.Record(events.Event{Type: t, Instance: name, Time: at})
+}
+
+func TestLedger_Running(t *testing.T) {
+	ledger := NewLedger(day)
+	record(ledger, "nginx", events.InstanceStarting, day.Add(1*time.Hour))
+	record(ledger, "nginx", events.InstanceReady, day.Add(1*time.Hour+time.Minute))
+	// Starting again while running does not open a new period
+	record(ledger, "nginx", events.InstanceStarting, day.Add(2*time.Hour))
+	record(ledger, "nginx", events.InstanceStopped, day.Add(3*time.Hour))
+	record(ledger, "nginx", events.InstanceStopped, day.Add(4*time.Hour))
+	record(ledger, "nginx", events.InstanceStarting, day.Add(10*time.Hour))
+	record(ledger, "apache", events.InstanceStarting, day.Add(5*time.Hour))
+	record(ledger, "apache", events.InstanceUnrecoverable, day.Add(5*time.Hour+time.Minute))
+
+	assert.DeepEqual(t, ledger.Instances(), []string{"apache", "nginx"})
+	assert.Equal(t, ledger.Running("nginx", day, day.Add(24*time.Hour)), 16*time.Hour)
+	assert.Equal(t, ledger.Running("nginx", day.Add(2*time.Hour), day.Add(11*time.Hour)), 2*time.Hour)
+	assert.Equal(t, ledger.Running("apache", day, day.Add(24*time.Hour)), time.Minute)
+	assert.Equal(t, ledger.Running("whoami", day, day.Add(24*time.Hour)), time.Duration(0))
+}
+
+func TestLedger_Retention(t *testing.T) {
+	ledger := NewLedger(day)
+	record(ledger, "nginx", events.InstanceStarting, day)
+	record(ledger, "nginx", events.InstanceStopped, day.Add(time.Hour))
+	later := day.Add(Retention + 2*time.Hour)
+	record(ledger, "nginx", events.InstanceStarting, later)
+
+	assert.Equal(t, len(ledger.periods["nginx"]), 1)
+	assert.Assert(t, ledger.periods["nginx"][0].Start.Equal(later))
+}
+
+type nopWriteCloser struct{ io.Writer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestLedger_SaveLoad(t *testing.T) {
+	ledger := NewLedger(day)
+	record(ledger, "nginx", events.InstanceStarting, day.Add(time.Hour))
+	record(ledger, "nginx", events.InstanceStopped, day.Add(2*time.Hour))
+	record(ledger, "apache", events.InstanceStarting, day.Add(3*time.Hour))
+
+	buf := &bytes.Buffer{}
+	assert.NilError(t, ledger.Save(nopWriteCloser{buf}))
+
+	loaded := NewLedger(day.Add(48 * time.Hour))
+	assert.NilError(t, loaded.Load(io.NopCloser(buf)))
+
+	assert.Assert(t, loaded.Since().Equal(day))
+	assert.Equal(t, loaded.Running("nginx", day, day.Add(24*time.Hour)), time.Hour)
+	assert.Equal(t, loaded.Running("apache", day, day.Add(24*time.Hour)), 21*time.Hour)
+}
+
+func TestLedger_Seed(t *testing.T) {
+	provider := mocks.NewProviderMock()
+	provider.On("GetState", "nginx").Return(instance.ReadyInstanceState("nginx", 1), nil)
+	provider.On("GetState", "apache").Return(instance.NotReadyInstanceState("apache", 0, 1), nil)
+	provider.On("GetState", "whoami").Return(instance.State{}, errors.New("not found"))
+
+	ledger := NewLedger(day)
+	record(ledger, "apache", events.InstanceStarting, day.Add(-time.Hour))
+	ledger.Seed(context.Background(), provider, []string{"nginx", "apache", "whoami"}, day)
+
+	// The instance running before the recording started is not counted as stopped
+	assert.Equal(t, ledger.Running("nginx", day, day.Add(time.Hour)), time.Hour)
+	// The instance stopped while the recording was not running is closed
+	assert.Equal(t, ledger.Running("apache", day, day.Add(time.Hour)), time.Duration(0))
+	assert.Equal(t, ledger.Running("whoami", day, day.Add(time.Hour)), time.Duration(0))
+}
+
+func TestLedger_Restart(t *testing.T) {
+	provider := mocks.NewProviderMock()
+	provider.On("GetState", "nginx").Return(instance.ReadyInstanceState("nginx", 1), nil)
+	provider.On("GetState", "apache").Return(instance.NotReadyInstanceState("apache", 0, 1), nil)
+
+	// Sablier was stopped at 4h while both instances were running, and restarted at 10h
+	saved := `{"since":"2024-10-21T00:00:00Z","savedAt":"2024-10-21T04:00:00Z","periods":{` +
+		`"nginx":[{"start":"2024-10-21T01:00:00Z"}],"apache":[{"start":"2024-10-21T02:00:00Z"}]}}`
+	restartedAt := day.Add(10 * time.Hour)
+	ledger := NewLedger(restartedAt)
+	assert.NilError(t, ledger.Load(io.NopCloser(bytes.NewBufferString(saved))))
+	ledger.Seed(context.Background(), provider, ledger.Instances(), restartedAt)
+
+	// The time Sablier was not running is not counted, the running instance is recorded again from the restart
+	assert.Equal(t, ledger.Running("nginx", day, day.Add(12*time.Hour)), 5*time.Hour)
+	assert.Equal(t, ledger.Running("apache", day, day.Add(12*time.Hour)), 2*time.Hour)
+}
@@ -0,0 +1,200 @@
+package savings
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/acouvreur/sablier/app/discovery"
+	"github.com/acouvreur/sablier/app/providers"
+	log "github.com/sirupsen/logrus"
+)
+
+// DefaultCostUnit is the unit of a cost label holding a single amount
+const DefaultCostUnit = "cost"
+
+// InstanceReport is the uptime avoided for an instance over the report window
+type InstanceReport struct {
+	Instance string `json:"instance"`
+	// ManagedSeconds is the part of the window during which the instance was recorded
+	ManagedSeconds float64 `json:"managedSeconds"`
+	RunningSeconds float64 `json:"runningSeconds"`
+	StoppedSeconds float64 `json:"stoppedSeconds"`
+	// SavedRatio is the part of the managed time during which the instance was stopped
+	SavedRatio float64 `json:"savedRatio"`
+	// CostPerHour is read from the instance cost label, by unit
+	CostPerHour map[string]float64 `json:"costPerHour,omitempty"`
+	// Savings is the cost of the stopped time, by unit
+	Savings map[string]float64 `json:"savings,omitempty"`
+}
+
+// Report is the uptime avoided for all the instances over a window
+type Report struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Instances []InstanceReport   `json:"instances"`
+	Savings   map[string]float64 `json:"savings"`
+}
+
+// Reporter builds the reports from the ledger and the cost labels of the instances
+type Reporter struct {
+	ledger *Ledger
+	// labels reads the cost labels, nil if the provider has no labels
+	labels providers.LabelsProvider
+	// instances returns the instances currently managed by Sablier
+	instances func() []string
+}
+
+func NewReporter(ledger *Ledger, labels providers.LabelsProvider, instances func() []string) *Reporter {
+	return &Reporter{
+		ledger:    ledger,
+		labels:    labels,
+		instances: instances,
+	}
+}
+
+// Report returns the uptime avoided between from and to for the managed instances
+// and the instances with recorded periods
+func (r *Reporter) Report(ctx context.Context, from time.Time, to time.Time) Report {
+	names := r.ledger.Instances()
+	if r.instances != nil {
+		names = append(names, r.instances()...)
+	}
+	sort.Strings(names)
+
+	report := Report{
+		From:      from,
+		To:        to,
+		Instances: []InstanceReport{},
+		Savings:   map[string]float64{},
+	}
+
+	managedFrom := from
+	if since := r.ledger.Since(); since.After(managedFrom) {
+		managedFrom = since
+	}
+	managed := max(to.Sub(managedFrom), 0)
+
+	for i, name := range names {
+		if i > 0 && names[i-1] == name {
+			continue
+		}
+
+		running := min(r.ledger.Running(name, managedFrom, to), managed)
+		stopped := managed - running
+		instance := InstanceReport{
+			Instance:       name,
+			ManagedSeconds: managed.Seconds(),
+			RunningSeconds: running.Seconds(),
+			StoppedSeconds: stopped.Seconds(),
+			CostPerHour:    r.cost(ctx, name),
+		}
+		if managed > 0 {
+			instance.SavedRatio = stopped.Seconds() / managed.Seconds()
+		}
+		if len(instance.CostPerHour) > 0 {
+			instance.Savings = make(map[string]float64, len(instance.CostPerHour))
+			for unit, amount := range instance.CostPerHour {
+				instance.Savings[unit] = amount * stopped.Hours()
+				report.Savings[unit] += instance.Savings[unit]
+			}
+		}
+		report.Instances = append(report.Instances, instance)
+	}
+
+	return report
+}
+
+func (r *Reporter) cost(ctx context.Context, name string) map[string]float64 {
+	if r.labels == nil {
+		return nil
+	}
+
+	labels, err := r.labels.GetLabels(ctx, name)
+	if err != nil {
+		log.Warnf("could not read the labels of %s: %v", name, err)
+		return nil
+	}
+
+	value, ok := labels[discovery.LabelCost]
+	if !ok {
+		return nil
+	}
+
+	cost, err := ParseCost(value)
+	if err != nil {
+		log.Warnf("ignoring label %s=%s of %s: %v", discovery.LabelCost, value, name, err)
+		return nil
+	}
+	return cost
+}
+
+// ParseCost parses a cost per hour, either a single amount ("0.12") or
+// amounts by unit ("cpu=0.5,memory=2,eur=0.12")
+func ParseCost(value string) (map[string]float64, error) {
+	cost := make(map[string]float64)
+	for _, part := range strings.Split(value, ",") {
+		unit, amount, found := strings.Cut(strings.TrimSpace(part), "=")
+		if !found {
+			unit, amount = DefaultCostUnit, unit
+		}
+
+		unit = strings.TrimSpace(unit)
+		parsed, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err != nil || parsed < 0 || unit == "" {
+			return nil, fmt.Errorf("\"%s\" is not a valid cost per hour", part)
+		}
+		cost[unit] = parsed
+	}
+	return cost, nil
+}
+
+// WriteCSV writes a line per instance and unit, instances without cost have a single line without unit
+func (r Report) WriteCSV(writer io.Writer) error {
+	w := csv.NewWriter(writer)
+	err := w.Write([]string{"instance", "from", "to", "managed_seconds", "running_seconds", "stopped_seconds", "saved_ratio", "unit", "cost_per_hour", "savings"})
+	if err != nil {
+		return err
+	}
+
+	formatFloat := func(f float64) string {
+		return strconv.FormatFloat(f, 'f', -1, 64)
+	}
+
+	for _, instance := range r.Instances {
+		line := []string{
+			instance.Instance,
+			r.From.Format(time.RFC3339),
+			r.To.Format(time.RFC3339),
+			formatFloat(instance.ManagedSeconds),
+			formatFloat(instance.RunningSeconds),
+			formatFloat(instance.StoppedSeconds),
+			formatFloat(instance.SavedRatio),
+		}
+
+		units := make([]string, 0, len(instance.CostPerHour))
+		for unit := range instance.CostPerHour {
+			units = append(units, unit)
+		}
+		sort.Strings(units)
+
+		if len(units) == 0 {
+			if err := w.Write(append(line, "", "", "")); err != nil {
+				return err
+			}
+		}
+		for _, unit := range units {
+			if err := w.Write(append(line, unit, formatFloat(instance.CostPerHour[unit]), formatFloat(instance.Savings[unit]))); err != nil {
+				return err
+			}
+		}
+	}
+
+	w.Flush()
+	return w.Error()
+}
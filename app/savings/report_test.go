@@ -0,0 +1,93 @@
+package savings
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"gotest.tools/v3/assert"
+)
+
+type labelsMock map[string]map[string]string
+
+func (m labelsMock) GetLabels(_ context.Context, name string) (map[string]string, error) {
+	return m[name], nil
+}
+
+func TestParseCost(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    map[string]float64
+		wantErr bool
+	}{
+		{value: "0.12", want: map[string]float64{"cost": 0.12}},
+		{value: "cpu=0.5, memory=2,eur=0.1", want: map[string]float64{"cpu": 0.5, "memory": 2, "eur": 0.1}},
+		{value: "eur=cheap", wantErr: true},
+		{value: "-1", wantErr: true},
+		{value: "=1", wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := ParseCost(tt.value)
+			if tt.wantErr {
+				assert.Assert(t, err != nil)
+				return
+			}
+			assert.NilError(t, err)
+			assert.DeepEqual(t, got, tt.want)
+		})
+	}
+}
+
+func TestReporter_Report(t *testing.T) {
+	ledger := NewLedger(day.Add(-time.Hour))
+	record(ledger, "nginx", events.InstanceStarting, day.Add(6*time.Hour))
+	record(ledger, "nginx", events.InstanceStopped, day.Add(12*time.Hour))
+
+	labels := labelsMock{
+		"nginx":  {"sablier.cost": "eur=0.5,cpu=2"},
+		"apache": {"sablier.cost": "free"},
+	}
+	reporter := NewReporter(ledger, labels, func() []string { return []string{"apache", "nginx"} })
+
+	report := reporter.Report(context.Background(), day, day.Add(24*time.Hour))
+
+	assert.DeepEqual(t, report.Instances, []InstanceReport{
+		{
+			Instance:       "apache",
+			ManagedSeconds: 86400,
+			StoppedSeconds: 86400,
+			SavedRatio:     1,
+		},
+		{
+			Instance:       "nginx",
+			ManagedSeconds: 86400,
+			RunningSeconds: 21600,
+			StoppedSeconds: 64800,
+			SavedRatio:     0.75,
+			CostPerHour:    map[string]float64{"eur": 0.5, "cpu": 2},
+			Savings:        map[string]float64{"eur": 9, "cpu": 36},
+		},
+	})
+	assert.DeepEqual(t, report.Savings, map[string]float64{"eur": 9, "cpu": 36})
+
+	buf := &bytes.Buffer{}
+	assert.NilError(t, report.WriteCSV(buf))
+	assert.Equal(t, buf.String(), `instance,from,to,managed_seconds,running_seconds,stopped_seconds,saved_ratio,unit,cost_per_hour,savings
+apache,2024-10-21T00:00:00Z,2024-10-22T00:00:00Z,86400,0,86400,1,,,
+nginx,2024-10-21T00:00:00Z,2024-10-22T00:00:00Z,86400,21600,64800,0.75,cpu,2,36
+nginx,2024-10-21T00:00:00Z,2024-10-22T00:00:00Z,86400,21600,64800,0.75,eur,0.5,9
+`)
+}
+
+func TestReporter_ReportSinceLedgerStart(t *testing.T) {
+	ledger := NewLedger(day.Add(12 * time.Hour))
+	reporter := NewReporter(ledger, nil, func() []string { return []string{"nginx"} })
+
+	report := reporter.Report(context.Background(), day, day.Add(24*time.Hour))
+
+	assert.Equal(t, report.Instances[0].ManagedSeconds, float64(43200))
+	assert.Equal(t, report.Instances[0].StoppedSeconds, float64(43200))
+}
@@ -0,0 +1,37 @@
+package sessions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/pkg/durations"
+	log "github.com/sirupsen/logrus"
+)
+
+// instanceStoppedAt records when the instance stopped, to enforce its cool-down period
+func (s *SessionsManager) instanceStoppedAt(name string, at time.Time) {
+	if s.coolDown(name) > 0 {
+		s.stoppedAt.Store(name, at)
+	}
+}
+
+// coolingDown returns the state of an instance that cannot be started yet, if so
+func (s *SessionsManager) coolingDown(name string) (instance.State, bool) {
+	value, ok := s.stoppedAt.Load(name)
+	if !ok {
+		return instance.State{}, false
+	}
+
+	availableAt := value.(time.Time).Add(s.coolDown(name))
+	remaining := time.Until(availableAt)
+	if remaining <= 0 {
+		s.stoppedAt.Delete(name)
+		return instance.State{}, false
+	}
+
+	log.Debugf("%s is cooling down for %v", name, remaining)
+	state := instance.NotReadyInstanceState(name, 0, 0)
+	state.Message = fmt.Sprintf("instance is cooling down, it can be started again in %s", durations.Humanize(max(remaining, time.Second)))
+	return state, true
+}
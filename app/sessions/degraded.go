@@ -0,0 +1,32 @@
+package sessions
+
+import (
+	"fmt"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	log "github.com/sirupsen/logrus"
+)
+
+// providerDegraded returns true if the provider reports that it is degraded
+func (s *SessionsManager) providerDegraded() bool {
+	reporter, ok := s.provider.(providers.HealthReporter)
+	return ok && reporter.Degraded()
+}
+
+// transientState returns a not ready state for an instance whose provider call failed with a transient error,
+// so that the request is retried on the next refresh instead of failing
+func transientState(name string, err error) (*instance.State, bool) {
+	if providers.Classify(err) != providers.Transient {
+		return nil, false
+	}
+
+	log.Warnf("transient error from the provider for %s: %v", name, err)
+	return &instance.State{
+		Name:            name,
+		CurrentReplicas: 0,
+		DesiredReplicas: 1,
+		Status:          instance.NotReady,
+		Message:         fmt.Sprintf("the provider is temporarily unavailable, retrying: %v", err),
+	}, true
+}
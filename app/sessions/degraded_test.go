@@ -0,0 +1,57 @@
+package sessions
+
+import (
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions/mocks"
+	"gotest.tools/v3/assert"
+)
+
+type degradedProviderMock struct {
+	*mocks.ProviderMock
+}
+
+func (degradedProviderMock) Degraded() bool {
+	return true
+}
+
+func TestSessionsManager_RequestSessionTransientStartError(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	providermock.On("Start", "nginx").Return(syscall.ECONNREFUSED).Once()
+
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+
+	state, _ := session.Instances.Load("nginx")
+	assert.NilError(t, state.(InstanceState).Error)
+	assert.Equal(t, state.(InstanceState).Instance.Status, instance.NotReady)
+	assert.Assert(t, strings.Contains(state.(InstanceState).Instance.Message, "temporarily unavailable"))
+	_, exists := s.store.Get("nginx")
+	assert.Assert(t, !exists)
+
+	// The start is tried again by the next request
+	providermock.On("Start", "nginx").Return(nil).Once()
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	session = s.RequestSession([]string{"nginx"}, time.Minute)
+	assert.Assert(t, session.IsReady())
+	assert.Assert(t, !session.ProviderDegraded)
+}
+
+func TestSessionsManager_RequestSessionTransientStateError(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.provider = degradedProviderMock{providermock}
+	providermock.On("GetState", "nginx").Return(instance.State{}, syscall.ECONNRESET)
+	s.store.Put("nginx", instance.State{Name: "nginx", CurrentReplicas: 1, DesiredReplicas: 2, Status: instance.NotReady}, time.Minute)
+
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+
+	state, _ := session.Instances.Load("nginx")
+	assert.NilError(t, state.(InstanceState).Error)
+	assert.Equal(t, state.(InstanceState).Instance.CurrentReplicas, int32(1))
+	assert.Assert(t, strings.Contains(state.(InstanceState).Instance.Message, "temporarily unavailable"))
+	assert.Assert(t, session.ProviderDegraded)
+}
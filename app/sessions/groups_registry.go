@@ -0,0 +1,162 @@
+package sessions
+
+import (
+	"slices"
+	"sync"
+)
+
+// GroupsRegistry holds the instances belonging to each group.
+// It is safe for concurrent use.
+type GroupsRegistry struct {
+	mx     sync.RWMutex
+	groups map[string][]string
+
+	subscribers map[chan string]struct{}
+}
+
+func NewGroupsRegistry(groups map[string][]string) *GroupsRegistry {
+	r := &GroupsRegistry{
+		groups:      make(map[string][]string),
+		subscribers: make(map[chan string]struct{}),
+	}
+	for group, names := range groups {
+		r.groups[group] = slices.Clone(names)
+	}
+	return r
+}
+
+// Get returns the instances of the group
+func (r *GroupsRegistry) Get(group string) []string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+	return slices.Clone(r.groups[group])
+}
+
+// Groups returns a copy of all the groups
+func (r *GroupsRegistry) Groups() map[string][]string {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	groups := make(map[string][]string, len(r.groups))
+	for group, names := range r.groups {
+		groups[group] = slices.Clone(names)
+	}
+	return groups
+}
+
+// GroupOf returns the group of the instance
+func (r *GroupsRegistry) GroupOf(name string) (string, bool) {
+	r.mx.RLock()
+	defer r.mx.RUnlock()
+
+	for group, names := range r.groups {
+		if slices.Contains(names, name) {
+			return group, true
+		}
+	}
+	return "", false
+}
+
+// Add adds the instance to the group, removing it from its previous group if any
+func (r *GroupsRegistry) Add(group string, name string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if slices.Contains(r.groups[group], name) {
+		return
+	}
+
+	changed := r.remove(name)
+	r.groups[group] = append(r.groups[group], name)
+	r.notify(append(changed, group)...)
+}
+
+// Remove removes the instance from its group
+func (r *GroupsRegistry) Remove(name string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	r.notify(r.remove(name)...)
+}
+
+// Replace replaces all the groups, it is used to resync the registry with the provider
+func (r *GroupsRegistry) Replace(groups map[string][]string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	var changed []string
+	for group, names := range r.groups {
+		if !sameMembers(names, groups[group]) {
+			changed = append(changed, group)
+		}
+	}
+	for group := range groups {
+		if _, ok := r.groups[group]; !ok {
+			changed = append(changed, group)
+		}
+	}
+
+	r.groups = make(map[string][]string, len(groups))
+	for group, names := range groups {
+		r.groups[group] = slices.Clone(names)
+	}
+	r.notify(changed...)
+}
+
+// Subscribe returns a channel receiving the name of each group whose members changed.
+// Notifications are dropped when the channel buffer is full.
+// The returned function must be called to unsubscribe.
+func (r *GroupsRegistry) Subscribe(buffer int) (<-chan string, func()) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	changes := make(chan string, buffer)
+	r.subscribers[changes] = struct{}{}
+
+	return changes, func() {
+		r.mx.Lock()
+		defer r.mx.Unlock()
+		if _, ok := r.subscribers[changes]; ok {
+			delete(r.subscribers, changes)
+			close(changes)
+		}
+	}
+}
+
+// remove must be called with the lock held, it returns the groups that changed
+func (r *GroupsRegistry) remove(name string) (changed []string) {
+	for group, names := range r.groups {
+		if i := slices.Index(names, name); i >= 0 {
+			r.groups[group] = slices.Delete(names, i, i+1)
+			if len(r.groups[group]) == 0 {
+				delete(r.groups, group)
+			}
+			changed = append(changed, group)
+		}
+	}
+	return changed
+}
+
+// notify must be called with the lock held
+func (r *GroupsRegistry) notify(groups ...string) {
+	for subscriber := range r.subscribers {
+		for _, group := range groups {
+			select {
+			case subscriber <- group:
+			default:
+			}
+		}
+	}
+}
+
+func sameMembers(a []string, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, name := range a {
+		if !slices.Contains(b, name) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,64 @@
+package sessions
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestGroupsRegistry(t *testing.T) {
+	t.Run("instances are added and removed incrementally", func(t *testing.T) {
+		registry := NewGroupsRegistry(map[string][]string{
+			"default": {"nginx"},
+		})
+
+		registry.Add("default", "apache")
+		registry.Add("internal", "whoami")
+		assert.DeepEqual(t, registry.Get("default"), []string{"nginx", "apache"})
+		assert.DeepEqual(t, registry.Get("internal"), []string{"whoami"})
+
+		registry.Remove("nginx")
+		assert.DeepEqual(t, registry.Get("default"), []string{"apache"})
+
+		group, ok := registry.GroupOf("whoami")
+		assert.Assert(t, ok)
+		assert.Equal(t, group, "internal")
+	})
+
+	t.Run("an instance moved to another group leaves its previous group", func(t *testing.T) {
+		registry := NewGroupsRegistry(map[string][]string{
+			"default": {"nginx", "apache"},
+		})
+
+		registry.Add("internal", "nginx")
+
+		assert.DeepEqual(t, registry.Groups(), map[string][]string{
+			"default":  {"apache"},
+			"internal": {"nginx"},
+		})
+	})
+
+	t.Run("subscribers are notified of the changed groups", func(t *testing.T) {
+		registry := NewGroupsRegistry(map[string][]string{
+			"default":  {"nginx"},
+			"internal": {"whoami"},
+		})
+
+		changes, unsubscribe := registry.Subscribe(10)
+
+		registry.Add("default", "apache")
+		registry.Replace(map[string][]string{
+			"default":  {"nginx", "apache"},
+			"internal": {"whoami"},
+			"new":      {"traefik"},
+		})
+		registry.Remove("whoami")
+		unsubscribe()
+
+		var got []string
+		for group := range changes {
+			got = append(got, group)
+		}
+		assert.DeepEqual(t, got, []string{"default", "new", "internal"})
+	})
+}
@@ -8,7 +8,7 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// watchGroups watches indefinitely for new groups
+// watchGroups periodically lists all the groups to resync the groups registry
 func watchGroups(ctx context.Context, provider providers.Provider, frequency time.Duration, send chan<- map[string][]string) {
 	ticker := time.NewTicker(frequency)
 	for {
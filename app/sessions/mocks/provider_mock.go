@@ -48,6 +48,16 @@ func (provider *ProviderMock) Wait() {
 	provider.wg.Wait()
 }
 
+func (provider *ProviderMock) Start(ctx context.Context, name string) error {
+	args := provider.Mock.Called(name)
+	return args.Error(0)
+}
+
+func (provider *ProviderMock) Stop(ctx context.Context, name string) error {
+	args := provider.Mock.Called(name)
+	return args.Error(0)
+}
+
 func (provider *ProviderMock) GetState(ctx context.Context, name string) (instance.State, error) {
 	args := provider.Mock.Called(name)
 	return args.Get(0).(instance.State), args.Error(1)
@@ -57,6 +67,20 @@ func (provider *ProviderMock) GetGroups(ctx context.Context) (map[string][]strin
 	return make(map[string][]string), nil
 }
 
+// ProviderWithLabelsMock is a ProviderMock also implementing providers.LabelsProvider
+type ProviderWithLabelsMock struct {
+	*ProviderMock
+}
+
+func NewProviderWithLabelsMock() *ProviderWithLabelsMock {
+	return &ProviderWithLabelsMock{ProviderMock: NewProviderMock()}
+}
+
+func (provider *ProviderWithLabelsMock) GetLabels(ctx context.Context, name string) (map[string]string, error) {
+	args := provider.Mock.Called(name)
+	return args.Get(0).(map[string]string), args.Error(1)
+}
+
 type KVMock[T any] struct {
 	wg sync.WaitGroup
 
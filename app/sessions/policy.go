@@ -0,0 +1,159 @@
+package sessions
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/acouvreur/sablier/app/discovery"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	log "github.com/sirupsen/logrus"
+)
+
+// Policy holds the session settings defined on the instance itself with labels or annotations.
+// When set, they take precedence over the values of the request, which take precedence over the configuration.
+type Policy struct {
+	SessionDuration time.Duration
+	MaxLifetime     time.Duration
+	CoolDown        time.Duration
+	StartTimeout    time.Duration
+	Theme           string
+	DisplayName     string
+	// PoolSize is the number of warm standby instances kept in the group of the instance
+	PoolSize int
+}
+
+// ParsePolicy reads the policy from the instance labels, invalid values are ignored
+func ParsePolicy(name string, labels map[string]string) Policy {
+	return Policy{
+		SessionDuration: parseDurationLabel(name, labels, discovery.LabelSessionDuration),
+		MaxLifetime:     parseDurationLabel(name, labels, discovery.LabelMaxLifetime),
+		CoolDown:        parseDurationLabel(name, labels, discovery.LabelCoolDown),
+		StartTimeout:    parseDurationLabel(name, labels, discovery.LabelStartTimeout),
+		Theme:           labels[discovery.LabelTheme],
+		DisplayName:     labels[discovery.LabelDisplayName],
+		PoolSize:        parseIntLabel(name, labels, discovery.LabelPoolSize),
+	}
+}
+
+func parseIntLabel(name string, labels map[string]string, label string) int {
+	value, ok := labels[label]
+	if !ok {
+		return 0
+	}
+
+	i, err := strconv.Atoi(value)
+	if err != nil || i < 0 {
+		log.Warnf("ignoring label %s=%s of %s: not a valid positive integer", label, value, name)
+		return 0
+	}
+	return i
+}
+
+func parseDurationLabel(name string, labels map[string]string, label string) time.Duration {
+	value, ok := labels[label]
+	if !ok {
+		return 0
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration < 0 {
+		log.Warnf("ignoring label %s=%s of %s: not a valid duration", label, value, name)
+		return 0
+	}
+	return duration
+}
+
+// policyFailureTTL is how long a failure to read the labels is cached, the default policy applies meanwhile
+const policyFailureTTL = 10 * time.Second
+
+// policyFailure is cached when the labels could not be read, so that an unhealthy provider is not
+// called each time the policy is used
+type policyFailure struct {
+	retryAt time.Time
+}
+
+// policy returns the policy of the instance, labels are only read once per session
+func (s *SessionsManager) policy(name string) Policy {
+	policy, _ := s.loadPolicy(name)
+	return policy
+}
+
+// loadPolicy returns the policy of the instance, and false if its labels could not be read
+func (s *SessionsManager) loadPolicy(name string) (Policy, bool) {
+	if cached, ok := s.policies.Load(name); ok {
+		switch cached := cached.(type) {
+		case Policy:
+			return cached, true
+		case policyFailure:
+			if time.Now().Before(cached.retryAt) {
+				return Policy{}, false
+			}
+		}
+	}
+
+	labeler, ok := s.provider.(providers.LabelsProvider)
+	if !ok {
+		return Policy{}, true
+	}
+
+	labels, err := labeler.GetLabels(s.ctx, name)
+	if err != nil {
+		log.Warnf("could not read the labels of %s, the default policy applies for %v: %v", name, policyFailureTTL, err)
+		s.policies.Store(name, policyFailure{retryAt: time.Now().Add(policyFailureTTL)})
+		return Policy{}, false
+	}
+
+	policy := ParsePolicy(name, labels)
+	s.policies.Store(name, policy)
+	return policy, true
+}
+
+// sessionDuration applies the instance session duration over the requested one
+func (s *SessionsManager) sessionDuration(name string, requested time.Duration) time.Duration {
+	if duration := s.policy(name).SessionDuration; duration > 0 {
+		return duration
+	}
+	return requested
+}
+
+// maxLifetime returns the maximum lifetime of the instance sessions, zero if unlimited
+func (s *SessionsManager) maxLifetime(name string) time.Duration {
+	if maxLifetime := s.policy(name).MaxLifetime; maxLifetime > 0 {
+		return maxLifetime
+	}
+	return s.config.MaxLifetime
+}
+
+// coolDown returns the period during which the instance cannot be started again once stopped
+func (s *SessionsManager) coolDown(name string) time.Duration {
+	if coolDown := s.policy(name).CoolDown; coolDown > 0 {
+		return coolDown
+	}
+	return s.config.CoolDown
+}
+
+// hardStopAt returns when the session will be stopped regardless of its activity
+func (s *SessionsManager) hardStopAt(state *instance.State) (time.Time, bool) {
+	maxLifetime := s.maxLifetime(state.Name)
+	if maxLifetime <= 0 || state.StartedAt == nil || state.Pinned {
+		return time.Time{}, false
+	}
+	return state.StartedAt.Add(maxLifetime), true
+}
+
+// capLifetime shortens the duration so that the session does not outlive its maximum lifetime
+func (s *SessionsManager) capLifetime(state *instance.State, duration time.Duration) time.Duration {
+	hardStopAt, ok := s.hardStopAt(state)
+	if !ok {
+		return duration
+	}
+	maxLifetime := s.maxLifetime(state.Name)
+
+	remaining := time.Until(hardStopAt)
+	if remaining < duration {
+		log.Debugf("session of %s reaches its maximum lifetime of %v in %v", state.Name, maxLifetime, remaining)
+		return max(remaining, 0)
+	}
+	return duration
+}
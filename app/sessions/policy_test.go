@@ -0,0 +1,239 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions/mocks"
+	"github.com/acouvreur/sablier/config"
+	"github.com/acouvreur/sablier/pkg/tinykv"
+	"github.com/stretchr/testify/mock"
+	"gotest.tools/v3/assert"
+)
+
+func TestParsePolicy(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels map[string]string
+		want   Policy
+	}{
+		{
+			name:   "no labels",
+			labels: map[string]string{},
+			want:   Policy{},
+		},
+		{
+			name: "all labels",
+			labels: map[string]string{
+				"sablier.session-duration": "30m",
+				"sablier.max-lifetime":     "8h",
+				"sablier.theme":            "ghost",
+				"sablier.display-name":     "My App",
+			},
+			want: Policy{
+				SessionDuration: 30 * time.Minute,
+				MaxLifetime:     8 * time.Hour,
+				Theme:           "ghost",
+				DisplayName:     "My App",
+			},
+		},
+		{
+			name: "invalid durations are ignored",
+			labels: map[string]string{
+				"sablier.session-duration": "forever",
+				"sablier.max-lifetime":     "-1h",
+			},
+			want: Policy{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.DeepEqual(t, ParsePolicy("nginx", tt.labels), tt.want)
+		})
+	}
+}
+
+func TestSessionsManager_RequestSessionWithPolicy(t *testing.T) {
+	store := tinykv.New[instance.State](time.Minute)
+	defer store.Stop()
+
+	providermock := mocks.NewProviderWithLabelsMock()
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+	providermock.On("GetLabels", "nginx").Return(map[string]string{
+		"sablier.session-duration": "2h",
+		"sablier.max-lifetime":     "1h",
+		"sablier.theme":            "ghost",
+	}, nil).Once()
+
+	s := &SessionsManager{
+		ctx:      context.Background(),
+		store:    store,
+		provider: providermock,
+	}
+
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+	s.RequestSession([]string{"nginx"}, time.Minute)
+
+	assert.Equal(t, session.Theme, "ghost")
+	entry, ok := store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	// The label session duration replaces the requested one, capped by the maximum lifetime
+	remaining := time.Until(entry.ExpiresAt())
+	assert.Assert(t, remaining > 59*time.Minute && remaining <= time.Hour, remaining)
+	// The session reports the duration applied instead of the requested one
+	assert.Assert(t, session.SessionDuration > 59*time.Minute && session.SessionDuration <= time.Hour, session.SessionDuration)
+	providermock.AssertNumberOfCalls(t, "GetLabels", 1)
+}
+
+func TestSessionsManager_PolicyFailureIsCached(t *testing.T) {
+	providermock := mocks.NewProviderWithLabelsMock()
+	providermock.On("GetLabels", "nginx").Return(map[string]string{}, errors.New("connection refused")).Once()
+	providermock.On("GetLabels", "nginx").Return(map[string]string{"sablier.theme": "ghost"}, nil).Once()
+	s := &SessionsManager{
+		ctx:      context.Background(),
+		provider: providermock,
+	}
+
+	for i := 0; i < 5; i++ {
+		assert.Equal(t, s.policy("nginx"), Policy{})
+	}
+	providermock.AssertNumberOfCalls(t, "GetLabels", 1)
+
+	// The labels are read again once the failure expired
+	s.policies.Store("nginx", policyFailure{retryAt: time.Now().Add(-time.Second)})
+	assert.Equal(t, s.policy("nginx").Theme, "ghost")
+	providermock.AssertNumberOfCalls(t, "GetLabels", 2)
+}
+
+func TestSessionsManager_RequestSessionHardStop(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.MaxLifetime = time.Hour
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	session := s.RequestSession([]string{"nginx"}, 2*time.Hour)
+
+	remaining := time.Until(session.HardStopAt)
+	assert.Assert(t, remaining > 59*time.Minute && remaining <= time.Hour, remaining)
+	entry, ok := s.store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Assert(t, time.Until(entry.ExpiresAt()) <= time.Hour)
+}
+
+func TestSessionsManager_RequestSessionCoolingDown(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.CoolDown = time.Hour
+	providermock.On("Stop", "nginx").Return(nil)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Minute)
+
+	s.StopSession(context.Background(), []string{"nginx"})
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+
+	providermock.AssertNotCalled(t, "Start", "nginx")
+	assert.Assert(t, !session.IsReady())
+	state, _ := session.Instances.Load("nginx")
+	assert.Assert(t, strings.Contains(state.(InstanceState).Instance.Message, "cooling down"))
+
+	s.stoppedAt.Store("nginx", time.Now().Add(-2*time.Hour))
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	session = s.RequestSession([]string{"nginx"}, time.Minute)
+	assert.Assert(t, session.IsReady())
+}
+
+func TestSessionsManager_RequestSessionStartTimeout(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.StartTimeout = time.Minute
+	s.config.StopOnStartTimeout = true
+	s.config.StartBackoff = time.Hour
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.NotReady, DesiredReplicas: 3}, nil).Once()
+	stopped := make(chan struct{})
+	providermock.On("Stop", "nginx").Return(nil).Run(func(mock.Arguments) { close(stopped) })
+
+	startedAt := time.Now().Add(-2 * time.Minute)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &startedAt}, time.Minute)
+
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+
+	state, _ := session.Instances.Load("nginx")
+	assert.Equal(t, state.(InstanceState).Instance.Status, instance.Unrecoverable)
+	assert.Assert(t, strings.Contains(state.(InstanceState).Instance.Message, "not ready after 1 minute"))
+	_, exists := s.store.Get("nginx")
+	assert.Assert(t, !exists)
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the instance was not stopped")
+	}
+
+	// The instance is not started again before the backoff elapsed
+	session = s.RequestSession([]string{"nginx"}, time.Minute)
+	providermock.AssertNotCalled(t, "Start", "nginx")
+	state, _ = session.Instances.Load("nginx")
+	assert.Assert(t, strings.Contains(state.(InstanceState).Instance.Message, "can be started again in"))
+	assert.Equal(t, state.(InstanceState).Instance.DesiredReplicas, int32(3))
+
+	s.startFailures.Store("nginx", startFailure{count: 1, retryAt: time.Now().Add(-time.Second)})
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	session = s.RequestSession([]string{"nginx"}, time.Minute)
+	assert.Assert(t, session.IsReady())
+	_, failed := s.startFailures.Load("nginx")
+	assert.Assert(t, !failed)
+}
+
+func TestSessionsManager_StartTimeoutStopRetried(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.StartTimeout = time.Minute
+	s.config.StopOnStartTimeout = true
+	conf := config.NewSessionsConfig()
+	conf.StopBackoff = time.Millisecond
+	s.stopper = NewStopper(providermock, nil, conf)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.NotReady}, nil)
+	providermock.On("Stop", "nginx").Return(errors.New("connection refused")).Once()
+	stopped := make(chan struct{})
+	providermock.On("Stop", "nginx").Return(nil).Run(func(mock.Arguments) { close(stopped) })
+
+	startedAt := time.Now().Add(-2 * time.Minute)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &startedAt}, time.Minute)
+	s.RequestSession([]string{"nginx"}, time.Minute)
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatal("the failed stop was not retried")
+	}
+}
+
+func TestSessionsManager_StartTimeoutNotStoppedByFollower(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.StartTimeout = time.Minute
+	s.config.StopOnStartTimeout = true
+	s.leader = followerMock{}
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.NotReady}, nil)
+
+	startedAt := time.Now().Add(-2 * time.Minute)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.NotReady, StartedAt: &startedAt}, time.Minute)
+	s.RequestSession([]string{"nginx"}, time.Minute)
+
+	providermock.AssertNotCalled(t, "Stop", "nginx")
+}
+
+func TestSessionsManager_StartBackoff(t *testing.T) {
+	s := &SessionsManager{}
+	s.config.StartBackoff = 10 * time.Second
+	s.config.StartMaxBackoff = time.Minute
+
+	assert.Equal(t, s.startBackoff(1), 10*time.Second)
+	assert.Equal(t, s.startBackoff(2), 20*time.Second)
+	assert.Equal(t, s.startBackoff(3), 40*time.Second)
+	assert.Equal(t, s.startBackoff(4), time.Minute)
+	assert.Equal(t, s.startBackoff(10), time.Minute)
+}
@@ -0,0 +1,287 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
+)
+
+var (
+	ErrNotAPool      = errors.New("group is not a pool")
+	ErrPoolExhausted = errors.New("pool is exhausted")
+	ErrClaimRequired = errors.New("a claim is required to request a pool")
+)
+
+// pools keeps track of the pool instances being claimed or started as standby.
+//
+// The claims themselves are held by the sessions in the store, so that they
+// survive restarts: a pool instance with a session is either a standby or claimed.
+type pools struct {
+	mu       sync.Mutex
+	reserved map[string]struct{}
+	// claims deduplicates the concurrent requests of the same claim, so that they get the same instance
+	claims singleflight.Group
+	// sizes caches the poolSize of each group
+	sizes sync.Map
+}
+
+// poolSize is the size of a pool computed from the policies of its members
+type poolSize struct {
+	members []string
+	size    int
+}
+
+// poolSize returns the number of standby instances of the group, zero if it is not a pool.
+// It is computed again when the members of the group change.
+func (s *SessionsManager) poolSize(group string) int {
+	if s.groups == nil {
+		return 0
+	}
+
+	members := s.groups.Get(group)
+	if cached, ok := s.pools.sizes.Load(group); ok && sameMembers(cached.(poolSize).members, members) {
+		return cached.(poolSize).size
+	}
+
+	size := 0
+	complete := true
+	for _, name := range members {
+		policy, ok := s.loadPolicy(name)
+		complete = complete && ok
+		size = max(size, policy.PoolSize)
+	}
+	// The size is computed again by the next request when the labels of a member could not be read
+	if complete {
+		s.pools.sizes.Store(group, poolSize{members: members, size: size})
+	}
+	return size
+}
+
+// IsPool returns true if the group keeps warm standby instances
+func (s *SessionsManager) IsPool(group string) bool {
+	return s.poolSize(group) > 0
+}
+
+// ClaimSession assigns an instance of the pool to the claim and requests its session.
+//
+// The same claim keeps the same instance until its session expires. A standby instance
+// is preferred, a stopped one is started otherwise.
+func (s *SessionsManager) ClaimSession(group string, claim string, duration time.Duration) (*SessionState, error) {
+	if claim == "" {
+		return nil, fmt.Errorf("%w: %s", ErrClaimRequired, group)
+	}
+
+	session, err, _ := s.pools.claims.Do(group+"/"+claim, func() (any, error) {
+		return s.claimSession(group, claim, duration)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return session.(*SessionState), nil
+}
+
+func (s *SessionsManager) claimSession(group string, claim string, duration time.Duration) (*SessionState, error) {
+	name, err := s.claim(group, claim)
+	if err != nil {
+		return nil, err
+	}
+	defer s.release(name)
+
+	state, err := s.claimInstance(name, claim, duration)
+	sessionState := &SessionState{
+		Instances: &sync.Map{},
+		Assigned:  name,
+	}
+	sessionState.Instances.Store(name, InstanceState{
+		Instance: state,
+		Error:    err,
+	})
+	sessionState.SessionDuration = s.effectiveDuration(sessionState, duration)
+
+	// The replacements are reserved right away and started in the background
+	s.replenishPool(group)
+
+	return sessionState, nil
+}
+
+// ClaimReadySession claims an instance of the pool and waits for it to be ready
+func (s *SessionsManager) ClaimReadySession(ctx context.Context, group string, claim string, duration time.Duration, timeout time.Duration) (*SessionState, error) {
+	session, err := s.ClaimSession(group, claim, duration)
+	if err != nil {
+		return nil, err
+	}
+	if session.IsReady() {
+		return session, nil
+	}
+
+	// The claim is held by the session, the next requests keep the same instance
+	session, err = s.RequestReadySession(ctx, []string{session.Assigned}, duration, timeout)
+	if err != nil {
+		return nil, err
+	}
+	session.Assigned = sessionAssigned(session)
+	return session, nil
+}
+
+// claim picks the instance of the pool assigned to the claim and reserves it
+func (s *SessionsManager) claim(group string, claim string) (string, error) {
+	if s.poolSize(group) == 0 {
+		return "", fmt.Errorf("%w: %s", ErrNotAPool, group)
+	}
+
+	members := s.groups.Get(group)
+	sort.Strings(members)
+
+	s.pools.mu.Lock()
+	defer s.pools.mu.Unlock()
+	if s.pools.reserved == nil {
+		s.pools.reserved = make(map[string]struct{})
+	}
+
+	var standby, ready, stopped string
+	for _, name := range members {
+		if _, reserved := s.pools.reserved[name]; reserved {
+			continue
+		}
+
+		state, exists := s.store.Get(name)
+		switch {
+		case exists && state.Claim == claim && !state.Standby:
+			s.pools.reserved[name] = struct{}{}
+			return name, nil
+		case exists && state.Standby && state.IsReady() && ready == "":
+			ready = name
+		case exists && state.Standby && standby == "":
+			standby = name
+		case !exists && stopped == "":
+			if _, cooling := s.coolingDown(name); !cooling {
+				stopped = name
+			}
+		}
+	}
+
+	for _, name := range []string{ready, standby, stopped} {
+		if name != "" {
+			log.Debugf("assigning %s of pool %s to claim \"%s\"", name, group, claim)
+			s.pools.reserved[name] = struct{}{}
+			return name, nil
+		}
+	}
+
+	return "", fmt.Errorf("%w: all the %d instances of %s are claimed", ErrPoolExhausted, len(members), group)
+}
+
+func (s *SessionsManager) release(name string) {
+	s.pools.mu.Lock()
+	defer s.pools.mu.Unlock()
+	delete(s.pools.reserved, name)
+}
+
+// claimInstance requests the session of the reserved instance on behalf of the claim
+func (s *SessionsManager) claimInstance(name string, claim string, duration time.Duration) (*instance.State, error) {
+	state, err := s.requestSessionInstance(name, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	if state.Standby || state.Claim != claim {
+		// The session of the claim starts now, not when the standby was started
+		now := time.Now()
+		state.StartedAt = &now
+		state.Standby = false
+		state.Pinned = false
+		state.Claim = claim
+		s.ExpiresAfter(state, s.sessionDuration(name, duration))
+	}
+
+	return state, nil
+}
+
+// replenishPool starts standby instances until the pool has its size
+func (s *SessionsManager) replenishPool(group string) {
+	size := s.poolSize(group)
+	if size == 0 {
+		return
+	}
+
+	members := s.groups.Get(group)
+	sort.Strings(members)
+
+	s.pools.mu.Lock()
+	if s.pools.reserved == nil {
+		s.pools.reserved = make(map[string]struct{})
+	}
+	standbys := 0
+	var candidates []string
+	for _, name := range members {
+		state, exists := s.store.Get(name)
+		if exists && state.Standby {
+			standbys++
+			continue
+		}
+		if _, reserved := s.pools.reserved[name]; reserved || exists {
+			continue
+		}
+		if _, cooling := s.coolingDown(name); cooling {
+			continue
+		}
+		candidates = append(candidates, name)
+	}
+	candidates = candidates[:min(len(candidates), max(size-standbys, 0))]
+	for _, name := range candidates {
+		s.pools.reserved[name] = struct{}{}
+	}
+	s.pools.mu.Unlock()
+
+	for _, name := range candidates {
+		go func(name string) {
+			defer s.release(name)
+
+			log.Debugf("starting %s as a standby of pool %s", name, group)
+			state, err := s.requestSessionInstance(name, pinnedDuration)
+			if err != nil {
+				log.Warnf("could not start %s as a standby of pool %s: %v", name, group, err)
+				return
+			}
+			state.Standby = true
+			state.Pinned = true
+			s.ExpiresAfter(state, pinnedDuration)
+		}(name)
+	}
+}
+
+// replenishPools replenishes all the pools
+func (s *SessionsManager) replenishPools() {
+	if s.groups == nil {
+		return
+	}
+	for group := range s.groups.Groups() {
+		s.replenishPool(group)
+	}
+}
+
+// poolInstanceStopped starts a replacement when a pool instance stopped
+func (s *SessionsManager) poolInstanceStopped(name string) {
+	if s.groups == nil || !s.isLeader() {
+		return
+	}
+	if group, ok := s.groups.GroupOf(name); ok {
+		s.replenishPool(group)
+	}
+}
+
+func sessionAssigned(session *SessionState) string {
+	var assigned string
+	session.Instances.Range(func(key, value any) bool {
+		assigned = key.(string)
+		return false
+	})
+	return assigned
+}
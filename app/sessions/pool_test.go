@@ -0,0 +1,158 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/stretchr/testify/mock"
+	"gotest.tools/v3/assert"
+)
+
+func newPoolTestManager(t *testing.T) *SessionsManager {
+	s, providermock := newControlTestManager(t)
+	s.groups = NewGroupsRegistry(map[string][]string{"preview": {"preview-1", "preview-2", "preview-3"}})
+	for _, name := range s.groups.Get("preview") {
+		s.policies.Store(name, Policy{PoolSize: 1})
+		providermock.On("GetState", name).Return(instance.State{Name: name, Status: instance.Ready}, nil)
+	}
+	providermock.On("Start", mock.Anything).Return(nil)
+	return s
+}
+
+func waitForStandbys(t *testing.T, s *SessionsManager, count int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		standbys := 0
+		for _, session := range s.ListSessions() {
+			if session.Standby {
+				standbys++
+			}
+		}
+		if standbys == count {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("the pool did not reach %d standby instances", count)
+}
+
+// waitForReservations waits for the background standby starts to complete
+func waitForReservations(t *testing.T, s *SessionsManager) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		s.pools.mu.Lock()
+		reserved := len(s.pools.reserved)
+		s.pools.mu.Unlock()
+		if reserved == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatal("pool instances are still reserved")
+}
+
+func TestSessionsManager_ClaimSession(t *testing.T) {
+	s := newPoolTestManager(t)
+	s.replenishPool("preview")
+	waitForStandbys(t, s, 1)
+
+	alice, err := s.ClaimSession("preview", "alice", time.Minute)
+	assert.NilError(t, err)
+	assert.Equal(t, alice.Assigned, "preview-1")
+	assert.Assert(t, alice.IsReady())
+
+	entry, ok := s.store.GetEntry("preview-1")
+	assert.Assert(t, ok)
+	assert.Equal(t, entry.Value().Claim, "alice")
+	assert.Assert(t, !entry.Value().Standby && !entry.Value().Pinned)
+	assert.Assert(t, time.Until(entry.ExpiresAt()) <= time.Minute)
+
+	// A replacement standby is started in the background
+	waitForStandbys(t, s, 1)
+
+	again, err := s.ClaimSession("preview", "alice", time.Minute)
+	assert.NilError(t, err)
+	assert.Equal(t, again.Assigned, "preview-1")
+
+	waitForReservations(t, s)
+	bob, err := s.ClaimSession("preview", "bob", time.Minute)
+	assert.NilError(t, err)
+	assert.Equal(t, bob.Assigned, "preview-2")
+}
+
+func TestSessionsManager_ClaimSessionExhausted(t *testing.T) {
+	s := newPoolTestManager(t)
+
+	for _, claim := range []string{"alice", "bob", "carol"} {
+		_, err := s.ClaimSession("preview", claim, time.Minute)
+		assert.NilError(t, err)
+		waitForReservations(t, s)
+	}
+
+	_, err := s.ClaimSession("preview", "dave", time.Minute)
+	assert.Assert(t, errors.Is(err, ErrPoolExhausted))
+}
+
+func TestSessionsManager_ClaimSessionNotAPool(t *testing.T) {
+	s := newPoolTestManager(t)
+	s.groups.Add("default", "nginx")
+	s.policies.Store("nginx", Policy{})
+
+	_, err := s.ClaimSession("default", "alice", time.Minute)
+	assert.Assert(t, errors.Is(err, ErrNotAPool))
+}
+
+func TestSessionsManager_RequestSessionGroupOfPool(t *testing.T) {
+	s := newPoolTestManager(t)
+
+	session, err := s.RequestSessionGroup("preview", time.Minute)
+
+	assert.Assert(t, errors.Is(err, ErrClaimRequired))
+	assert.Assert(t, session == nil)
+	_, err = s.RequestReadySessionGroup(context.Background(), "preview", time.Minute, time.Second)
+	assert.Assert(t, errors.Is(err, ErrClaimRequired))
+	assert.Equal(t, len(s.ListSessions()), 0)
+}
+
+type followerMock struct{}
+
+func (followerMock) IsLeader() bool                         { return false }
+func (followerMock) WhileLeading(func(ctx context.Context)) {}
+
+func TestSessionsManager_PoolNotReplenishedByFollower(t *testing.T) {
+	s := newPoolTestManager(t)
+	s.leader = followerMock{}
+
+	s.poolInstanceStopped("preview-1")
+
+	waitForReservations(t, s)
+	assert.Equal(t, len(s.ListSessions()), 0)
+}
+
+func TestSessionsManager_ClaimSessionWithoutClaim(t *testing.T) {
+	s := newPoolTestManager(t)
+
+	_, err := s.ClaimSession("preview", "", time.Minute)
+
+	assert.Assert(t, errors.Is(err, ErrClaimRequired))
+	waitForReservations(t, s)
+	assert.Equal(t, len(s.ListSessions()), 0)
+}
+
+func TestSessionsManager_PoolSizeComputedWhenTheGroupChanges(t *testing.T) {
+	s := newPoolTestManager(t)
+	assert.Equal(t, s.poolSize("preview"), 1)
+
+	// The policies are not read again while the members of the group are the same
+	s.policies.Store("preview-1", Policy{PoolSize: 2})
+	assert.Equal(t, s.poolSize("preview"), 1)
+
+	s.groups.Add("preview", "preview-4")
+	s.policies.Store("preview-4", Policy{PoolSize: 2})
+	assert.Equal(t, s.poolSize("preview"), 2)
+}
@@ -0,0 +1,53 @@
+package sessions
+
+import "sync"
+
+// readinessWaiters dispatches instance readiness notifications to the requests waiting for them.
+// The zero value is ready to use.
+type readinessWaiters struct {
+	mx      sync.Mutex
+	waiters map[string]map[chan struct{}]struct{}
+}
+
+// subscribe returns a channel receiving a value each time one of the given instances
+// is reported as possibly ready. The returned function must be called to unsubscribe.
+func (r *readinessWaiters) subscribe(names []string) (<-chan struct{}, func()) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	if r.waiters == nil {
+		r.waiters = make(map[string]map[chan struct{}]struct{})
+	}
+
+	notify := make(chan struct{}, 1)
+	for _, name := range names {
+		if r.waiters[name] == nil {
+			r.waiters[name] = make(map[chan struct{}]struct{})
+		}
+		r.waiters[name][notify] = struct{}{}
+	}
+
+	return notify, func() {
+		r.mx.Lock()
+		defer r.mx.Unlock()
+		for _, name := range names {
+			delete(r.waiters[name], notify)
+			if len(r.waiters[name]) == 0 {
+				delete(r.waiters, name)
+			}
+		}
+	}
+}
+
+func (r *readinessWaiters) notify(name string) {
+	r.mx.Lock()
+	defer r.mx.Unlock()
+
+	for waiter := range r.waiters[name] {
+		// The waiter re-checks all of its instances, a pending notification is enough
+		select {
+		case waiter <- struct{}{}:
+		default:
+		}
+	}
+}
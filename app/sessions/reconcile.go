@@ -0,0 +1,94 @@
+package sessions
+
+import (
+	"context"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/pkg/tinykv"
+	log "github.com/sirupsen/logrus"
+)
+
+// Reconcile checks the stored sessions against the provider. The sessions of the instances which
+// no longer exist are removed, the outdated states are fixed, the instances whose session
+// expired while Sablier was not running are stopped, and the stop failures of the instances
+// which stopped are cleared.
+func (s *SessionsManager) Reconcile(ctx context.Context) {
+	for name, entry := range s.store.Entries() {
+		state, err := s.provider.GetState(ctx, name)
+		switch {
+		case providers.Classify(err) == providers.NotFound:
+			log.Infof("%s no longer exists, removing its session", name)
+			s.store.Delete(name)
+			s.states.Delete(name)
+			s.policies.Delete(name)
+		case err != nil:
+			log.Warnf("could not reconcile the session of %s: %v", name, err)
+		default:
+			s.reconcileState(name, entry, state)
+		}
+	}
+
+	s.expiredWhileDown.Range(func(key, value any) bool {
+		name := key.(string)
+		s.expiredWhileDown.Delete(name)
+		s.stopExpiredWhileDown(ctx, name, value.(instance.State))
+		return true
+	})
+
+	// The stop failures might have been restored, the instances stopped since are no longer reported
+	for name := range s.stopper.Failed() {
+		state, err := s.provider.GetState(ctx, name)
+		if providers.Classify(err) == providers.NotFound || (err == nil && state.CurrentReplicas == 0) {
+			log.Infof("%s which could not be stopped is no longer running", name)
+			s.stopper.Stopped(name)
+		}
+	}
+}
+
+// reconcileState replaces the stored state by the state reported by the provider, the session expiration is kept
+func (s *SessionsManager) reconcileState(name string, entry tinykv.Entry[instance.State], state instance.State) {
+	stored := entry.Value()
+	if stored.Status == state.Status && stored.CurrentReplicas == state.CurrentReplicas &&
+		stored.DesiredReplicas == state.DesiredReplicas && stored.Message == state.Message {
+		return
+	}
+
+	// The session was requested meanwhile, its state is already up to date
+	current, ok := s.store.GetEntry(name)
+	if !ok || !current.ExpiresAt().Equal(entry.ExpiresAt()) {
+		return
+	}
+
+	log.Infof("the stored status of %s was %s, it is now %s", name, stored.Status, state.Status)
+	stored.Status = state.Status
+	stored.CurrentReplicas = state.CurrentReplicas
+	stored.DesiredReplicas = state.DesiredReplicas
+	stored.Message = state.Message
+	s.store.Put(name, stored, time.Until(entry.ExpiresAt()))
+	s.instanceReady(name)
+}
+
+// stopExpiredWhileDown stops an instance whose loaded session had already expired,
+// unless it no longer exists or was requested again since
+func (s *SessionsManager) stopExpiredWhileDown(ctx context.Context, name string, state instance.State) {
+	if _, ok := s.store.Get(name); ok {
+		return
+	}
+	if _, err := s.provider.GetState(ctx, name); err != nil {
+		if providers.Classify(err) != providers.NotFound {
+			log.Warnf("could not stop %s whose session expired while Sablier was not running: %v", name, err)
+		}
+		return
+	}
+
+	log.Infof("the session of %s expired while Sablier was not running, stopping it", name)
+	if s.stopper != nil {
+		s.stopper.OnExpire(name, state)
+		return
+	}
+	if err := s.provider.Stop(ctx, name); err != nil {
+		log.Warnf("could not stop %s: %v", name, err)
+	}
+}
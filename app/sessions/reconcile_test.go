@@ -0,0 +1,100 @@
+package sessions
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/providers/dockerswarm"
+	"github.com/acouvreur/sablier/app/providers/mocks"
+	"github.com/docker/docker/api/types/swarm"
+	"github.com/stretchr/testify/mock"
+	"gotest.tools/v3/assert"
+)
+
+func TestSessionsManager_ReconcileRemovesMissingInstances(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Hour)
+	providermock.On("GetState", "nginx").Return(instance.State{}, fmt.Errorf("nginx: %w", providers.ErrNotFound))
+
+	s.Reconcile(context.Background())
+
+	_, ok := s.store.Get("nginx")
+	assert.Assert(t, !ok)
+}
+
+func TestSessionsManager_ReconcileRemovesDeletedSwarmServices(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	clientMock := mocks.NewDockerAPIClientMock()
+	clientMock.On("ServiceList", mock.Anything, mock.Anything).Return([]swarm.Service{}, nil)
+	s.provider = &dockerswarm.DockerSwarmProvider{Client: clientMock}
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Hour)
+
+	s.Reconcile(context.Background())
+
+	_, ok := s.store.Get("nginx")
+	assert.Assert(t, !ok)
+}
+
+func TestSessionsManager_ReconcileKeepsSessionsOnError(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Hour)
+	providermock.On("GetState", "nginx").Return(instance.State{}, errors.New("connection refused"))
+
+	s.Reconcile(context.Background())
+
+	_, ok := s.store.Get("nginx")
+	assert.Assert(t, ok)
+}
+
+func TestSessionsManager_ReconcileFixesOutdatedStates(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready, CurrentReplicas: 1, DesiredReplicas: 1}, time.Hour)
+	before, _ := s.store.GetEntry("nginx")
+	providermock.On("GetState", "nginx").Return(instance.NotReadyInstanceState("nginx", 0, 1), nil)
+
+	s.Reconcile(context.Background())
+
+	entry, ok := s.store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, entry.Value().Status, instance.NotReady)
+	assert.Equal(t, entry.Value().CurrentReplicas, int32(0))
+	assert.Assert(t, entry.ExpiresAt().Sub(before.ExpiresAt()) < time.Second)
+}
+
+func TestSessionsManager_ReconcileStopsSessionsExpiredWhileDown(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	expiredAt := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339Nano)
+	saved := &buffer{}
+	saved.WriteString(`{"version":2,"sessions":{` +
+		`"nginx":{"value":{"name":"nginx","status":"ready"},"expiresAt":"` + expiredAt + `"},` +
+		`"apache":{"value":{"name":"apache","status":"ready"},"expiresAt":"` + expiredAt + `"}}}`)
+	assert.NilError(t, s.LoadSessions(saved))
+	providermock.On("GetState", "nginx").Return(instance.ReadyInstanceState("nginx", 1), nil)
+	providermock.On("GetState", "apache").Return(instance.State{}, providers.ErrNotFound)
+	providermock.On("Stop", "nginx").Return(nil)
+
+	s.Reconcile(context.Background())
+
+	providermock.AssertCalled(t, "Stop", "nginx")
+	providermock.AssertNotCalled(t, "Stop", "apache")
+
+	// The instances are stopped once
+	s.Reconcile(context.Background())
+	providermock.AssertNumberOfCalls(t, "Stop", 1)
+}
+
+func TestSessionsManager_ReconcileStopsRestoredSessionsExpiredWhileDown(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.ExpiredWhileDown(map[string]instance.State{"nginx": {Name: "nginx", Status: instance.Ready}})
+	providermock.On("GetState", "nginx").Return(instance.ReadyInstanceState("nginx", 1), nil)
+	providermock.On("Stop", "nginx").Return(nil)
+
+	s.Reconcile(context.Background())
+
+	providermock.AssertCalled(t, "Stop", "nginx")
+}
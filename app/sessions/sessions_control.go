@@ -0,0 +1,119 @@
+package sessions
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	log "github.com/sirupsen/logrus"
+)
+
+// ExtendSession adds the duration to the remaining time of each running session
+func (s *SessionsManager) ExtendSession(names []string, duration time.Duration) *SessionState {
+	return s.forEachInstance(names, func(name string) (*instance.State, error) {
+		entry, exists := s.store.GetEntry(name)
+		if !exists {
+			return nil, fmt.Errorf("session %s does not exist", name)
+		}
+
+		state := entry.Value()
+		remaining := time.Until(entry.ExpiresAt())
+		log.Debugf("extending %s by %v", name, duration)
+		s.ExpiresAfter(&state, remaining+duration)
+		return &state, nil
+	})
+}
+
+// StopSession stops the instances immediately and removes their sessions
+func (s *SessionsManager) StopSession(ctx context.Context, names []string) *SessionState {
+	return s.forEachInstance(names, func(name string) (*instance.State, error) {
+		log.Debugf("stopping %s on demand...", name)
+		err := s.provider.Stop(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+
+		s.instanceStoppedAt(name, time.Now())
+		s.store.Delete(name)
+		s.states.Delete(name)
+
+		state := instance.NotReadyInstanceState(name, 0, 0)
+		return &state, nil
+	})
+}
+
+// PinSession requests the sessions and prevents them from expiring until they are unpinned.
+// The instances which cannot be started yet, cooling down or backing off after a start timeout, are not pinned.
+func (s *SessionsManager) PinSession(names []string) *SessionState {
+	return s.forEachInstance(names, func(name string) (*instance.State, error) {
+		if state, backoff := s.backingOff(name); backoff {
+			return nil, fmt.Errorf("%s cannot be pinned: %s", name, state.Message)
+		}
+		if state, cooling := s.coolingDown(name); cooling {
+			return nil, fmt.Errorf("%s cannot be pinned: %s", name, state.Message)
+		}
+
+		state, err := s.requestSessionInstance(name, pinnedDuration)
+		if err != nil {
+			return nil, err
+		}
+
+		log.Debugf("pinning %s", name)
+		state.Pinned = true
+		s.ExpiresAfter(state, pinnedDuration)
+		return state, nil
+	})
+}
+
+// UnpinSession lets the sessions expire again after the given duration
+func (s *SessionsManager) UnpinSession(names []string, duration time.Duration) *SessionState {
+	return s.forEachInstance(names, func(name string) (*instance.State, error) {
+		state, exists := s.store.Get(name)
+		if !exists {
+			return nil, fmt.Errorf("session %s does not exist", name)
+		}
+
+		log.Debugf("unpinning %s, expiring in %v", name, duration)
+		state.Pinned = false
+		s.ExpiresAfter(&state, duration)
+		return &state, nil
+	})
+}
+
+func (s *SessionsManager) forEachInstance(names []string, action func(name string) (*instance.State, error)) *SessionState {
+	if len(names) == 0 {
+		return nil
+	}
+
+	var wg sync.WaitGroup
+
+	sessionState := &SessionState{
+		Instances: &sync.Map{},
+	}
+
+	wg.Add(len(names))
+
+	for i := 0; i < len(names); i++ {
+		go func(name string) {
+			defer wg.Done()
+			state, err := action(name)
+			if state == nil {
+				state = &instance.State{Name: name, Status: instance.Unrecoverable}
+			}
+			if err != nil {
+				state.Message = err.Error()
+			}
+
+			sessionState.Instances.Store(name, InstanceState{
+				Instance: state,
+				Error:    err,
+			})
+		}(names[i])
+	}
+
+	wg.Wait()
+
+	return sessionState
+}
@@ -0,0 +1,141 @@
+package sessions
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions/mocks"
+	"github.com/acouvreur/sablier/pkg/tinykv"
+	"gotest.tools/v3/assert"
+)
+
+func newControlTestManager(t *testing.T) (*SessionsManager, *mocks.ProviderMock) {
+	store := tinykv.New[instance.State](time.Minute)
+	t.Cleanup(store.Stop)
+
+	providermock := mocks.NewProviderMock()
+
+	return &SessionsManager{
+		ctx:      context.Background(),
+		store:    store,
+		provider: providermock,
+	}, providermock
+}
+
+func TestSessionsManager_ExtendSession(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Minute)
+
+	session := s.ExtendSession([]string{"nginx", "apache"}, time.Hour)
+
+	entry, ok := s.store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Assert(t, time.Until(entry.ExpiresAt()) > time.Hour)
+
+	apache, _ := session.Instances.Load("apache")
+	assert.ErrorContains(t, apache.(InstanceState).Error, "session apache does not exist")
+}
+
+func TestSessionsManager_StopSession(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Minute)
+	providermock.On("Stop", "nginx").Return(nil)
+
+	session := s.StopSession(context.Background(), []string{"nginx"})
+
+	providermock.AssertCalled(t, "Stop", "nginx")
+	_, ok := s.store.Get("nginx")
+	assert.Assert(t, !ok)
+	assert.Assert(t, !session.IsReady())
+}
+
+func TestSessionsManager_PinSession(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Minute)
+
+	s.PinSession([]string{"nginx"})
+
+	// Requesting a pinned session does not shorten it
+	s.RequestSession([]string{"nginx"}, time.Minute)
+	entry, ok := s.store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Assert(t, entry.Value().Pinned)
+	assert.Assert(t, time.Until(entry.ExpiresAt()) > 24*time.Hour)
+
+	s.UnpinSession([]string{"nginx"}, time.Minute)
+	entry, ok = s.store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Assert(t, !entry.Value().Pinned)
+	assert.Assert(t, time.Until(entry.ExpiresAt()) <= time.Minute)
+}
+
+func TestSessionsManager_PinSessionRefusedWhileCoolingDown(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.CoolDown = time.Hour
+	providermock.On("Stop", "nginx").Return(nil)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Minute)
+	s.StopSession(context.Background(), []string{"nginx"})
+
+	session := s.PinSession([]string{"nginx"})
+
+	providermock.AssertNotCalled(t, "Start", "nginx")
+	state, _ := session.Instances.Load("nginx")
+	assert.ErrorContains(t, state.(InstanceState).Error, "cooling down")
+	assert.Equal(t, state.(InstanceState).Instance.Status, instance.Unrecoverable)
+	_, ok := s.store.Get("nginx")
+	assert.Assert(t, !ok)
+}
+
+func TestSessionsManager_PinSessionRefusedWhileBackingOff(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.startFailures.Store("nginx", startFailure{count: 1, retryAt: time.Now().Add(time.Minute), desiredReplicas: 1})
+
+	session := s.PinSession([]string{"nginx"})
+
+	providermock.AssertNotCalled(t, "Start", "nginx")
+	state, _ := session.Instances.Load("nginx")
+	assert.ErrorContains(t, state.(InstanceState).Error, "failed to become ready")
+	_, ok := s.store.Get("nginx")
+	assert.Assert(t, !ok)
+}
+
+func TestSessionsManager_PublishesLifecycleEvents(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.events = events.NewBus()
+	received, unsubscribe := s.events.Subscribe(10)
+	defer unsubscribe()
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	s.RequestSession([]string{"nginx"}, time.Minute)
+	s.RequestSession([]string{"nginx"}, time.Minute)
+
+	assert.Equal(t, len(received), 3)
+	assert.Equal(t, (<-received).Type, events.SessionRequested)
+	assert.Equal(t, (<-received).Type, events.InstanceStarting)
+	assert.Equal(t, (<-received).Type, events.InstanceReady)
+}
+
+type requestRecorderMock struct {
+	names []string
+}
+
+func (r *requestRecorderMock) RecordRequest(name string, _ time.Time) {
+	r.names = append(r.names, name)
+}
+
+func TestSessionsManager_WarmSessionIsNotRecorded(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	recorder := &requestRecorderMock{}
+	s.recorder = recorder
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Minute)
+
+	s.WarmSession([]string{"nginx"}, time.Minute)
+	assert.Equal(t, len(recorder.names), 0)
+
+	s.RequestSession([]string{"nginx"}, time.Minute)
+	assert.DeepEqual(t, recorder.names, []string{"nginx"})
+}
@@ -0,0 +1,105 @@
+package sessions
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+)
+
+// SessionInfo describes a session tracked by the sessions manager
+type SessionInfo struct {
+	Name            string     `json:"name"`
+	Group           string     `json:"group,omitempty"`
+	Status          string     `json:"status"`
+	CurrentReplicas int32      `json:"currentReplicas"`
+	DesiredReplicas int32      `json:"desiredReplicas"`
+	Message         string     `json:"message,omitempty"`
+	Pinned          bool       `json:"pinned,omitempty"`
+	Standby         bool       `json:"standby,omitempty"`
+	Claim           string     `json:"claim,omitempty"`
+	StartedAt       *time.Time `json:"startedAt,omitempty"`
+	LastRequestedAt *time.Time `json:"lastRequestedAt,omitempty"`
+	ExpiresAt       time.Time  `json:"expiresAt"`
+}
+
+// ListSessions returns all the sessions, sorted by name
+func (s *SessionsManager) ListSessions() []SessionInfo {
+	entries := s.store.Entries()
+
+	sessions := make([]SessionInfo, 0, len(entries))
+	for name, entry := range entries {
+		sessions = append(sessions, s.sessionInfo(name, entry.Value(), entry.ExpiresAt()))
+	}
+	draining := s.stopper.Draining()
+	for name, stopAt := range draining {
+		if _, ok := entries[name]; !ok {
+			sessions = append(sessions, s.drainingSessionInfo(name, stopAt))
+		}
+	}
+	for name, failure := range s.stopper.Failed() {
+		_, exists := entries[name]
+		if _, ok := draining[name]; !ok && !exists {
+			sessions = append(sessions, s.stopFailedSessionInfo(name, failure))
+		}
+	}
+
+	sort.SliceStable(sessions, func(i, j int) bool {
+		return strings.Compare(sessions[i].Name, sessions[j].Name) == -1
+	})
+
+	return sessions
+}
+
+// GetSession returns the session of the instance
+func (s *SessionsManager) GetSession(name string) (SessionInfo, bool) {
+	entry, ok := s.store.GetEntry(name)
+	if !ok {
+		if stopAt, draining := s.stopper.Draining()[name]; draining {
+			return s.drainingSessionInfo(name, stopAt), true
+		}
+		if failure, failed := s.stopper.Failed()[name]; failed {
+			return s.stopFailedSessionInfo(name, failure), true
+		}
+		return SessionInfo{}, false
+	}
+	return s.sessionInfo(name, entry.Value(), entry.ExpiresAt()), true
+}
+
+func (s *SessionsManager) sessionInfo(name string, state instance.State, expiresAt time.Time) SessionInfo {
+	var group string
+	if s.groups != nil {
+		group, _ = s.groups.GroupOf(name)
+	}
+
+	return SessionInfo{
+		Name:            name,
+		Group:           group,
+		Status:          state.Status,
+		CurrentReplicas: state.CurrentReplicas,
+		DesiredReplicas: state.DesiredReplicas,
+		Message:         state.Message,
+		Pinned:          state.Pinned,
+		Standby:         state.Standby,
+		Claim:           state.Claim,
+		StartedAt:       state.StartedAt,
+		LastRequestedAt: state.LastRequestedAt,
+		ExpiresAt:       expiresAt,
+	}
+}
+
+// drainingSessionInfo describes an expired session whose instance is not stopped yet
+func (s *SessionsManager) drainingSessionInfo(name string, stopAt time.Time) SessionInfo {
+	info := s.sessionInfo(name, instance.State{Name: name, Status: instance.Draining}, stopAt)
+	info.Message = "session expired, the instance is draining before being stopped"
+	return info
+}
+
+// stopFailedSessionInfo describes an expired session whose instance could not be stopped
+func (s *SessionsManager) stopFailedSessionInfo(name string, failure StopFailure) SessionInfo {
+	info := s.sessionInfo(name, instance.State{Name: name, Status: instance.StopFailed}, failure.At)
+	info.Message = fmt.Sprintf("session expired, the instance could not be stopped after %d attempts: %v", failure.Attempts, failure.Err)
+	return info
+}
@@ -1,6 +1,7 @@
 package sessions
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
@@ -9,41 +10,102 @@ import (
 	"sync"
 	"time"
 
+	"github.com/acouvreur/sablier/app/events"
 	"github.com/acouvreur/sablier/app/instance"
 	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/storage"
+	"github.com/acouvreur/sablier/config"
 	"github.com/acouvreur/sablier/pkg/tinykv"
 	log "github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 )
 
-const defaultRefreshFrequency = 2 * time.Second
+const defaultGroupsResyncInterval = 1 * time.Minute
+
+// pinnedDuration is the duration used for pinned sessions, which are expected to never expire
+const pinnedDuration = 100 * 365 * 24 * time.Hour
+const defaultReadinessPollInterval = 500 * time.Millisecond
+const defaultReadinessMaxPollInterval = 5 * time.Second
 
 type Manager interface {
 	RequestSession(names []string, duration time.Duration) *SessionState
-	RequestSessionGroup(group string, duration time.Duration) *SessionState
+	RequestSessionGroup(group string, duration time.Duration) (*SessionState, error)
 	RequestReadySession(ctx context.Context, names []string, duration time.Duration, timeout time.Duration) (*SessionState, error)
 	RequestReadySessionGroup(ctx context.Context, group string, duration time.Duration, timeout time.Duration) (*SessionState, error)
 
+	// ClaimSession and ClaimReadySession assign an instance of a pool group to the claim
+	ClaimSession(group string, claim string, duration time.Duration) (*SessionState, error)
+	ClaimReadySession(ctx context.Context, group string, claim string, duration time.Duration, timeout time.Duration) (*SessionState, error)
+
+	// WarmSession requests the session on behalf of Sablier, the request is not recorded
+	WarmSession(names []string, duration time.Duration) *SessionState
+
+	ExtendSession(names []string, duration time.Duration) *SessionState
+	StopSession(ctx context.Context, names []string) *SessionState
+	PinSession(names []string) *SessionState
+	UnpinSession(names []string, duration time.Duration) *SessionState
+
+	Groups() *GroupsRegistry
+	ListSessions() []SessionInfo
+	GetSession(name string) (SessionInfo, bool)
+
 	LoadSessions(io.ReadCloser) error
 	SaveSessions(io.WriteCloser) error
+	// ExpiredWhileDown records the restored sessions which expired while Sablier was not running,
+	// their instances are stopped by the next reconciliation of the leader
+	ExpiredWhileDown(sessions map[string]instance.State)
+
+	// Reconcile checks the stored sessions against the state of the instances
+	Reconcile(ctx context.Context)
 
 	Stop()
 }
 
+// RequestRecorder records the sessions requested by the users
+type RequestRecorder interface {
+	RecordRequest(name string, at time.Time)
+}
+
+// Leader tells whether this replica is the leader of the replicas sharing the sessions, see leader.Elector.
+// Only the leader watches the group events and replenishes the pools.
+type Leader interface {
+	IsLeader() bool
+	// WhileLeading runs fn each time the leadership is acquired, its context is cancelled when it is lost
+	WhileLeading(fn func(ctx context.Context))
+}
+
 type SessionsManager struct {
 	ctx    context.Context
 	cancel context.CancelFunc
 
 	store    tinykv.KV[instance.State]
 	provider providers.Provider
-	groups   map[string][]string
+	stopper  *Stopper
+	events   *events.Bus
+	recorder RequestRecorder
+	leader   Leader
+	groups   *GroupsRegistry
+	config   config.Sessions
+
+	// inflight deduplicates concurrent starts and state checks of the same instance
+	inflight singleflight.Group
+	states   stateCache
+
+	readiness readinessWaiters
+	policies  sync.Map
+	stoppedAt sync.Map
+	pools     pools
+	// startFailures holds the instances which did not become ready in time
+	startFailures sync.Map
+	// expiredWhileDown holds the loaded sessions which expired while Sablier was not running
+	expiredWhileDown sync.Map
 }
 
-func NewSessionsManager(store tinykv.KV[instance.State], provider providers.Provider) Manager {
+func NewSessionsManager(store tinykv.KV[instance.State], provider providers.Provider, stopper *Stopper, bus *events.Bus, recorder RequestRecorder, leader Leader, conf config.Sessions) Manager {
 	ctx, cancel := context.WithCancel(context.Background())
 
 	groups, err := provider.GetGroups(ctx)
 	if err != nil {
-		groups = make(map[string][]string)
 		log.Warn("could not get groups", err)
 	}
 
@@ -52,7 +114,12 @@ func NewSessionsManager(store tinykv.KV[instance.State], provider providers.Prov
 		cancel:   cancel,
 		store:    store,
 		provider: provider,
-		groups:   groups,
+		stopper:  stopper,
+		events:   bus,
+		recorder: recorder,
+		leader:   leader,
+		groups:   NewGroupsRegistry(groups),
+		config:   conf,
 	}
 
 	sm.initWatchers()
@@ -62,18 +129,93 @@ func NewSessionsManager(store tinykv.KV[instance.State], provider providers.Prov
 
 func (sm *SessionsManager) initWatchers() {
 	updateGroups := make(chan map[string][]string)
-	go watchGroups(sm.ctx, sm.provider, defaultRefreshFrequency, updateGroups)
+	go watchGroups(sm.ctx, sm.provider, sm.groupsResyncInterval(), updateGroups)
 	go sm.consumeGroups(updateGroups)
 
+	if notifier, ok := sm.provider.(providers.GroupsNotifier); ok {
+		// The other replicas only resync their groups periodically
+		sm.whileLeading(func(ctx context.Context) {
+			// The notifiers might keep sending after returning, the channel is never closed
+			groupChanged := make(chan providers.GroupEvent)
+			go notifier.NotifyGroupChanged(ctx, groupChanged)
+			sm.consumeGroupEvents(ctx, groupChanged)
+		})
+	}
+
 	instanceStopped := make(chan string)
 	go sm.provider.NotifyInstanceStopped(sm.ctx, instanceStopped)
 	go sm.consumeInstanceStopped(instanceStopped)
+
+	if notifier, ok := sm.provider.(providers.ReadinessNotifier); ok {
+		instanceReady := make(chan string)
+		go notifier.NotifyInstanceReady(sm.ctx, instanceReady)
+		go sm.consumeInstanceReady(instanceReady)
+	}
 }
 
 func (sm *SessionsManager) consumeGroups(receive chan map[string][]string) {
 	for groups := range receive {
-		sm.groups = groups
+		sm.groups.Replace(groups)
+		if sm.isLeader() {
+			go sm.replenishPools()
+		}
+	}
+}
+
+// consumeGroupEvents applies the group events until ctx is done
+func (sm *SessionsManager) consumeGroupEvents(ctx context.Context, receive chan providers.GroupEvent) {
+	for {
+		var event providers.GroupEvent
+		select {
+		case <-ctx.Done():
+			return
+		case event = <-receive:
+		}
+
+		// Labels might have changed, they are read again on the next request
+		sm.policies.Delete(event.Instance)
+		sm.pools.sizes.Delete(event.Group)
+		if event.Removed {
+			log.Debugf("received event instance %s left its group", event.Instance)
+			sm.groups.Remove(event.Instance)
+		} else {
+			log.Debugf("received event instance %s joined group %s", event.Instance, event.Group)
+			sm.groups.Add(event.Group, event.Instance)
+		}
+	}
+}
+
+// isLeader returns true if this replica is the leader, or the only replica
+func (sm *SessionsManager) isLeader() bool {
+	return sm.leader == nil || sm.leader.IsLeader()
+}
+
+// whileLeading runs fn while this replica is the leader, until the manager is stopped
+func (sm *SessionsManager) whileLeading(fn func(ctx context.Context)) {
+	if sm.leader == nil {
+		go fn(sm.ctx)
+		return
 	}
+
+	sm.leader.WhileLeading(func(ctx context.Context) {
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel()
+		stop := context.AfterFunc(sm.ctx, cancel)
+		defer stop()
+		fn(ctx)
+	})
+}
+
+// Groups returns the registry of the discovered groups
+func (sm *SessionsManager) Groups() *GroupsRegistry {
+	return sm.groups
+}
+
+func (sm *SessionsManager) groupsResyncInterval() time.Duration {
+	if sm.config.GroupsResyncInterval <= 0 {
+		return defaultGroupsResyncInterval
+	}
+	return sm.config.GroupsResyncInterval
 }
 
 func (sm *SessionsManager) consumeInstanceStopped(instanceStopped chan string) {
@@ -81,23 +223,82 @@ func (sm *SessionsManager) consumeInstanceStopped(instanceStopped chan string) {
 		// Will delete from the store containers that have been stop either by external sources
 		// or by the internal expiration loop, if the deleted entry does not exist, it doesn't matter
 		log.Debugf("received event instance %s is stopped, removing from store", instance)
+		sm.instanceStoppedAt(instance, time.Now())
+		sm.stopper.Stopped(instance)
+		sm.publish(events.InstanceStopped, instance, "")
 		sm.store.Delete(instance)
+		sm.states.Delete(instance)
+		sm.policies.Delete(instance)
+		go sm.poolInstanceStopped(instance)
 	}
 }
 
+func (sm *SessionsManager) consumeInstanceReady(instanceReady chan string) {
+	for instance := range instanceReady {
+		log.Tracef("received event instance %s might be ready", instance)
+		sm.instanceReady(instance)
+	}
+}
+
+func (sm *SessionsManager) instanceReady(name string) {
+	// The cached state is outdated, the next check must reach the provider
+	sm.states.Delete(name)
+	sm.readiness.notify(name)
+}
+
+// LoadSessions loads the sessions saved by any version of Sablier, they are migrated to the current format
 func (sm *SessionsManager) LoadSessions(reader io.ReadCloser) error {
 	defer reader.Close()
-	return json.NewDecoder(reader).Decode(sm.store)
+
+	b, err := io.ReadAll(reader)
+	if err != nil {
+		return err
+	}
+	sessions, err := storage.Load(b)
+	if err != nil {
+		return err
+	}
+
+	// The instances of the sessions which expired meanwhile are stopped by the next reconciliation
+	var entries map[string]struct {
+		Value     instance.State `json:"value"`
+		ExpiresAt time.Time      `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(sessions, &entries); err != nil {
+		return err
+	}
+	now := time.Now()
+	expired := make(map[string]instance.State)
+	for name, entry := range entries {
+		if !entry.ExpiresAt.After(now) {
+			expired[name] = entry.Value
+		}
+	}
+	sm.ExpiredWhileDown(expired)
+
+	return json.Unmarshal(sessions, sm.store)
 }
 
+func (sm *SessionsManager) ExpiredWhileDown(sessions map[string]instance.State) {
+	for name, state := range sessions {
+		sm.expiredWhileDown.Store(name, state)
+	}
+}
+
+// SaveSessions saves the sessions in the versioned envelope, the writer is aborted on failure
 func (sm *SessionsManager) SaveSessions(writer io.WriteCloser) error {
-	defer writer.Close()
+	sessions := &bytes.Buffer{}
+	encoder := json.NewEncoder(sessions)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(sm.store); err != nil {
+		return storage.Close(writer, err)
+	}
 
-	encoder := json.NewEncoder(writer)
+	encoder = json.NewEncoder(writer)
 	encoder.SetEscapeHTML(false)
 	encoder.SetIndent("", "  ")
 
-	return encoder.Encode(sm.store)
+	return storage.Close(writer, encoder.Encode(storage.Wrap(sessions.Bytes())))
 }
 
 type InstanceState struct {
@@ -107,6 +308,19 @@ type InstanceState struct {
 
 type SessionState struct {
 	Instances *sync.Map
+
+	// Theme and DisplayName are defined by the instances policies, empty if none of them define it
+	Theme       string
+	DisplayName string
+	// HardStopAt is the earliest time at which an instance reaches its maximum lifetime, zero if none
+	HardStopAt time.Time
+	// SessionDuration is the shortest session duration applied to the instances, once their
+	// session duration labels and maximum lifetimes are taken into account
+	SessionDuration time.Duration
+	// Assigned is the instance of the pool assigned to the request, empty if the group is not a pool
+	Assigned string
+	// ProviderDegraded is true when the provider is failing, the instances might take longer to start
+	ProviderDegraded bool
 }
 
 func (s *SessionState) IsReady() bool {
@@ -133,6 +347,17 @@ func (s *SessionState) Status() string {
 }
 
 func (s *SessionsManager) RequestSession(names []string, duration time.Duration) (sessionState *SessionState) {
+	if s.recorder != nil {
+		now := time.Now()
+		for _, name := range names {
+			s.recorder.RecordRequest(name, now)
+		}
+	}
+
+	return s.WarmSession(names, duration)
+}
+
+func (s *SessionsManager) WarmSession(names []string, duration time.Duration) (sessionState *SessionState) {
 
 	if len(names) == 0 {
 		return nil
@@ -160,22 +385,73 @@ func (s *SessionsManager) RequestSession(names []string, duration time.Duration)
 
 	wg.Wait()
 
+	sessionState.ProviderDegraded = s.providerDegraded()
+
+	for _, name := range names {
+		policy := s.policy(name)
+		if sessionState.Theme == "" {
+			sessionState.Theme = policy.Theme
+		}
+		if sessionState.DisplayName == "" {
+			sessionState.DisplayName = policy.DisplayName
+		}
+	}
+
+	sessionState.Instances.Range(func(key, value any) bool {
+		state := value.(InstanceState).Instance
+		if state == nil {
+			return true
+		}
+		if hardStopAt, ok := s.hardStopAt(state); ok && (sessionState.HardStopAt.IsZero() || hardStopAt.Before(sessionState.HardStopAt)) {
+			sessionState.HardStopAt = hardStopAt
+		}
+		return true
+	})
+	sessionState.SessionDuration = s.effectiveDuration(sessionState, duration)
+
 	return sessionState
 }
 
-func (s *SessionsManager) RequestSessionGroup(group string, duration time.Duration) (sessionState *SessionState) {
+// effectiveDuration returns the shortest session duration applied to the instances of the session,
+// the requested duration if none applies
+func (s *SessionsManager) effectiveDuration(sessionState *SessionState, requested time.Duration) time.Duration {
+	effective := time.Duration(-1)
+	sessionState.Instances.Range(func(key, value any) bool {
+		state := value.(InstanceState).Instance
+		if state == nil || state.Pinned {
+			return true
+		}
+		duration := s.capLifetime(state, s.sessionDuration(key.(string), requested))
+		if effective < 0 || duration < effective {
+			effective = duration
+		}
+		return true
+	})
+	if effective < 0 {
+		return requested
+	}
+	return effective
+}
+
+// RequestSessionGroup requests the sessions of all the instances of the group.
+// A pool is only requested through a claim, ErrClaimRequired is returned otherwise.
+func (s *SessionsManager) RequestSessionGroup(group string, duration time.Duration) (sessionState *SessionState, err error) {
 
 	if len(group) == 0 {
-		return nil
+		return nil, nil
 	}
 
-	names := s.groups[group]
+	names := s.groups.Get(group)
 
 	if len(names) == 0 {
-		return nil
+		return nil, nil
+	}
+
+	if s.IsPool(group) {
+		return nil, fmt.Errorf("%w: %s", ErrClaimRequired, group)
 	}
 
-	return s.RequestSession(names, duration)
+	return s.RequestSession(names, duration), nil
 }
 
 func (s *SessionsManager) requestSessionInstance(name string, duration time.Duration) (*instance.State, error) {
@@ -183,17 +459,32 @@ func (s *SessionsManager) requestSessionInstance(name string, duration time.Dura
 		return nil, errors.New("instance name cannot be empty")
 	}
 
+	duration = s.sessionDuration(name, duration)
+
 	requestState, exists := s.store.Get(name)
 
 	if !exists {
-		log.Debugf("starting [%s]...", name)
+		// The instance might still be stopping after its start timed out, the stop is not cancelled
+		if state, backoff := s.backingOff(name); backoff {
+			return &state, nil
+		}
 
-		err := s.provider.Start(s.ctx, name)
-		if err != nil {
-			return nil, err
+		// A draining instance is still running, its session starts again and keeps its start time,
+		// so that the session does not outlive its maximum lifetime
+		var startedAt *time.Time
+		if expired, running := s.stopper.Cancel(name); running {
+			startedAt = expired.StartedAt
 		}
 
-		state, err := s.provider.GetState(s.ctx, name)
+		if state, cooling := s.coolingDown(name); cooling {
+			return &state, nil
+		}
+
+		state, err := s.startInstance(name, duration, startedAt)
+		if transient, ok := transientState(name, err); ok {
+			// Nothing is stored so that the start is tried again by the next request
+			return transient, nil
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -203,29 +494,128 @@ func (s *SessionsManager) requestSessionInstance(name string, duration time.Dura
 		requestState.DesiredReplicas = state.DesiredReplicas
 		requestState.Status = state.Status
 		requestState.Message = state.Message
+		requestState.StartedAt = state.StartedAt
 
 		log.Debugf("status for [%s]=[%s]", name, requestState.Status)
 	} else if requestState.Status != instance.Ready {
 		log.Debugf("checking [%s]...", name)
-		state, err := s.provider.GetState(s.ctx, name)
-		if err != nil {
+		state, err := s.getState(name)
+		if transient, ok := transientState(name, err); ok {
+			// The last known state is kept until the provider answers again
+			state = requestState
+			state.Message = transient.Message
+		} else if err != nil {
 			return nil, err
 		}
 
+		if state.Status != requestState.Status {
+			s.publishState(state)
+		}
+
 		requestState.Name = state.Name
 		requestState.CurrentReplicas = state.CurrentReplicas
 		requestState.DesiredReplicas = state.DesiredReplicas
 		requestState.Status = state.Status
 		requestState.Message = state.Message
 		log.Debugf("status for %s=%s", name, requestState.Status)
+
+		if s.startTimedOut(&requestState) {
+			return s.abortStart(&requestState), nil
+		}
+	}
+
+	if requestState.IsReady() {
+		s.startSucceeded(name)
 	}
 
+	now := time.Now()
+	requestState.LastRequestedAt = &now
+
 	log.Debugf("expiring %+v in %v", requestState, duration)
 	// Refresh the duration
 	s.ExpiresAfter(&requestState, duration)
 	return &requestState, nil
 }
 
+// startInstance starts the instance only once for all the concurrent callers.
+// The session is registered before the other callers are released so that
+// subsequent requests find it in the store instead of issuing a new start.
+// The session starts now, unless startedAt is set.
+func (s *SessionsManager) startInstance(name string, duration time.Duration, startedAt *time.Time) (instance.State, error) {
+	v, err, shared := s.inflight.Do("start:"+name, func() (interface{}, error) {
+		// A concurrent start may have completed between the store lookup and this call
+		if state, exists := s.store.Get(name); exists {
+			return state, nil
+		}
+
+		s.publish(events.SessionRequested, name, fmt.Sprintf("session of %s", duration))
+
+		log.Debugf("starting [%s]...", name)
+		s.publish(events.InstanceStarting, name, "")
+		err := s.provider.Start(s.ctx, name)
+		if err != nil {
+			if providers.Classify(err) != providers.Transient {
+				s.publish(events.InstanceUnrecoverable, name, err.Error())
+			}
+			return instance.State{}, err
+		}
+
+		state, err := s.provider.GetState(s.ctx, name)
+		if err != nil {
+			return instance.State{}, err
+		}
+		s.states.Put(name, state)
+		s.publishState(state)
+
+		if startedAt == nil {
+			now := time.Now()
+			startedAt = &now
+		}
+		state.Name = name
+		state.StartedAt = startedAt
+		s.ExpiresAfter(&state, duration)
+		return state, nil
+	})
+	if shared {
+		log.Tracef("start of [%s] was shared with concurrent requests", name)
+	}
+
+	return v.(instance.State), err
+}
+
+func (s *SessionsManager) publish(t events.Type, name string, message string) {
+	s.events.Publish(events.Event{Type: t, Instance: name, Message: message})
+}
+
+// publishState publishes the event matching the new state of an instance, if any
+func (s *SessionsManager) publishState(state instance.State) {
+	switch state.Status {
+	case instance.Ready:
+		s.publish(events.InstanceReady, state.Name, "")
+	case instance.Unrecoverable:
+		s.publish(events.InstanceUnrecoverable, state.Name, state.Message)
+	}
+}
+
+// getState returns the provider state of the instance, using the short-lived cache
+// and deduplicating concurrent calls for the same instance.
+func (s *SessionsManager) getState(name string) (instance.State, error) {
+	if state, ok := s.states.Get(name); ok {
+		return state, nil
+	}
+
+	v, err, _ := s.inflight.Do("state:"+name, func() (interface{}, error) {
+		state, err := s.provider.GetState(s.ctx, name)
+		if err != nil {
+			return instance.State{}, err
+		}
+		s.states.Put(name, state)
+		return state, nil
+	})
+
+	return v.(instance.State), err
+}
+
 func (s *SessionsManager) RequestReadySession(ctx context.Context, names []string, duration time.Duration, timeout time.Duration) (*SessionState, error) {
 
 	session := s.RequestSession(names, duration)
@@ -233,37 +623,45 @@ func (s *SessionsManager) RequestReadySession(ctx context.Context, names []strin
 		return session, nil
 	}
 
-	ticker := time.NewTicker(5 * time.Second)
-	readiness := make(chan *SessionState)
-	quit := make(chan struct{})
-
-	go func() {
-		for {
-			select {
-			case <-ticker.C:
-				session := s.RequestSession(names, duration)
-				if session.IsReady() {
-					readiness <- session
-				}
-			case <-quit:
-				ticker.Stop()
-				return
-			}
+	readiness, unsubscribe := s.readiness.subscribe(names)
+	defer unsubscribe()
+
+	deadline := time.NewTimer(timeout)
+	defer deadline.Stop()
+
+	interval := s.readinessPollInterval()
+	for {
+		select {
+		case <-ctx.Done():
+			log.Debug("request cancelled by user, stopping timeout")
+			return nil, fmt.Errorf("request cancelled by user")
+		case <-deadline.C:
+			return nil, fmt.Errorf("session was not ready after %s", timeout.String())
+		case <-readiness:
+			log.Tracef("readiness notification received for %v", names)
+		case <-time.After(interval):
+			interval = min(2*interval, s.readinessMaxPollInterval())
+		}
+
+		session := s.RequestSession(names, duration)
+		if session.IsReady() {
+			return session, nil
 		}
-	}()
+	}
+}
+
+func (s *SessionsManager) readinessPollInterval() time.Duration {
+	if s.config.ReadinessPollInterval <= 0 {
+		return defaultReadinessPollInterval
+	}
+	return s.config.ReadinessPollInterval
+}
 
-	select {
-	case <-ctx.Done():
-		log.Debug("request cancelled by user, stopping timeout")
-		close(quit)
-		return nil, fmt.Errorf("request cancelled by user")
-	case status := <-readiness:
-		close(quit)
-		return status, nil
-	case <-time.After(timeout):
-		close(quit)
-		return nil, fmt.Errorf("session was not ready after %s", timeout.String())
+func (s *SessionsManager) readinessMaxPollInterval() time.Duration {
+	if s.config.ReadinessMaxPollInterval <= 0 {
+		return defaultReadinessMaxPollInterval
 	}
+	return max(s.config.ReadinessMaxPollInterval, s.readinessPollInterval())
 }
 
 func (s *SessionsManager) RequestReadySessionGroup(ctx context.Context, group string, duration time.Duration, timeout time.Duration) (sessionState *SessionState, err error) {
@@ -272,16 +670,25 @@ func (s *SessionsManager) RequestReadySessionGroup(ctx context.Context, group st
 		return nil, fmt.Errorf("group is mandatory")
 	}
 
-	names := s.groups[group]
+	names := s.groups.Get(group)
 
 	if len(names) == 0 {
 		return nil, fmt.Errorf("group has no member")
 	}
 
+	if s.IsPool(group) {
+		return nil, fmt.Errorf("%w: %s", ErrClaimRequired, group)
+	}
+
 	return s.RequestReadySession(ctx, names, duration, timeout)
 }
 
 func (s *SessionsManager) ExpiresAfter(instance *instance.State, duration time.Duration) {
+	if instance.Pinned {
+		duration = pinnedDuration
+	} else {
+		duration = s.capLifetime(instance, duration)
+	}
 	s.store.Put(instance.Name, *instance, duration)
 }
 
@@ -302,8 +709,16 @@ func (s *SessionState) MarshalJSON() ([]byte, error) {
 		return true
 	})
 
-	return json.Marshal(map[string]any{
+	session := map[string]any{
 		"instances": instances,
 		"status":    s.Status(),
-	})
+	}
+	if s.Assigned != "" {
+		session["assigned"] = s.Assigned
+	}
+	if s.ProviderDegraded {
+		session["providerDegraded"] = true
+	}
+
+	return json.Marshal(session)
 }
@@ -1,15 +1,21 @@
 package sessions
 
 import (
+	"bytes"
 	"context"
+	"strings"
 	"sync"
 	"testing"
 	"time"
 
 	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
 	"github.com/acouvreur/sablier/app/sessions/mocks"
+	"github.com/acouvreur/sablier/config"
+	"github.com/acouvreur/sablier/pkg/tinykv"
 	"github.com/stretchr/testify/mock"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
 )
 
 func TestSessionState_IsReady(t *testing.T) {
@@ -108,7 +114,7 @@ func TestNewSessionsManagerEvents(t *testing.T) {
 			kv.Add(len(tt.stoppedInstances))
 			kv.Mock.On("Delete", mock.AnythingOfType("string")).Return()
 
-			NewSessionsManager(kv, provider)
+			NewSessionsManager(kv, provider, nil, nil, nil, nil, config.NewSessionsConfig())
 
 			// The provider watches notifications from a Goroutine, must wait
 			provider.Wait()
@@ -197,3 +203,153 @@ func TestSessionsManager_RequestReadySession(t *testing.T) {
 		assert.NilError(t, <-errchan)
 	})
 }
+
+func TestSessionsManager_RequestSessionDeduplicatesStarts(t *testing.T) {
+
+	t.Run("concurrent requests for a cold instance start it once", func(t *testing.T) {
+		store := tinykv.New[instance.State](time.Minute)
+		defer store.Stop()
+
+		providermock := mocks.NewProviderMock()
+		providermock.On("Start", "nginx").After(50 * time.Millisecond).Return(nil)
+		providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.NotReady}, nil)
+
+		s := &SessionsManager{
+			ctx:      context.Background(),
+			store:    store,
+			provider: providermock,
+		}
+
+		var wg sync.WaitGroup
+		for i := 0; i < 20; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				s.RequestSession([]string{"nginx"}, time.Minute)
+			}()
+		}
+		wg.Wait()
+
+		providermock.AssertNumberOfCalls(t, "Start", 1)
+		providermock.AssertNumberOfCalls(t, "GetState", 1)
+	})
+}
+
+func TestSessionsManager_RequestReadySessionNotifiedByProvider(t *testing.T) {
+
+	t.Run("request ready session returns as soon as the instance is notified ready", func(t *testing.T) {
+		kvmock := mocks.NewKVMock()
+		kvmock.On("Get", mock.Anything).Return(instance.State{Name: "nginx", Status: instance.NotReady}, true)
+
+		providermock := mocks.NewProviderMock()
+		providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.NotReady}, nil).Once()
+		providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+		s := &SessionsManager{
+			ctx:      context.Background(),
+			store:    kvmock,
+			provider: providermock,
+			config: config.Sessions{
+				ReadinessPollInterval:    time.Hour,
+				ReadinessMaxPollInterval: time.Hour,
+			},
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		go func() {
+			for {
+				select {
+				case <-done:
+					return
+				case <-time.After(10 * time.Millisecond):
+					s.instanceReady("nginx")
+				}
+			}
+		}()
+
+		session, err := s.RequestReadySession(context.Background(), []string{"nginx"}, time.Minute, 5*time.Second)
+
+		assert.NilError(t, err)
+		assert.Assert(t, session.IsReady())
+	})
+}
+
+type buffer struct {
+	bytes.Buffer
+}
+
+func (b *buffer) Close() error { return nil }
+
+func TestSessionsManager_LoadSessionsBeforeEnvelope(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339Nano)
+	legacy := &buffer{}
+	legacy.WriteString(`{"nginx":{"value":{"name":"nginx","status":"ready"},"expiresAt":"` + expiresAt + `"}}`)
+
+	assert.NilError(t, s.LoadSessions(legacy))
+
+	state, ok := s.store.Get("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, state.Status, instance.Ready)
+}
+
+func TestSessionsManager_SaveSessions(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	s.store.Put("nginx", instance.State{Name: "nginx", Status: instance.Ready}, time.Hour)
+
+	saved := &buffer{}
+	assert.NilError(t, s.SaveSessions(saved))
+	assert.Assert(t, strings.HasPrefix(saved.String(), "{\n  \"version\": 2,\n  \"sessions\": {"))
+
+	other, _ := newControlTestManager(t)
+	assert.NilError(t, other.LoadSessions(saved))
+	_, ok := other.store.Get("nginx")
+	assert.Assert(t, ok)
+}
+
+// groupsNotifierMock sends the group events after NotifyGroupChanged returns, like the informers
+type groupsNotifierMock struct {
+	*mocks.ProviderMock
+	events []providers.GroupEvent
+	sent   chan struct{}
+}
+
+func (provider *groupsNotifierMock) NotifyGroupChanged(ctx context.Context, event chan<- providers.GroupEvent) {
+	go func() {
+		defer close(provider.sent)
+		for _, e := range provider.events {
+			time.Sleep(10 * time.Millisecond)
+			providers.SendGroupEvent(ctx, event, e)
+		}
+	}()
+}
+
+func TestNewSessionsManager_GroupEventsSentAfterNotifierReturns(t *testing.T) {
+	provider := &groupsNotifierMock{
+		ProviderMock: mocks.NewProviderMock(),
+		events: []providers.GroupEvent{
+			{Instance: "nginx", Group: "web"},
+			{Instance: "apache", Group: "web"},
+			{Instance: "nginx", Removed: true},
+		},
+		sent: make(chan struct{}),
+	}
+	provider.Add(1)
+	kv := tinykv.New[instance.State](time.Minute)
+	defer kv.Stop()
+
+	s := NewSessionsManager(kv, provider, nil, nil, nil, nil, config.NewSessionsConfig())
+	defer s.Stop()
+
+	<-provider.sent
+	poll.WaitOn(t, func(t poll.LogT) poll.Result {
+		if group, ok := s.Groups().GroupOf("apache"); !ok || group != "web" {
+			return poll.Continue("apache is not in the group web")
+		}
+		if _, ok := s.Groups().GroupOf("nginx"); ok {
+			return poll.Continue("nginx is still in a group")
+		}
+		return poll.Success()
+	})
+}
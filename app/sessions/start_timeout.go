@@ -0,0 +1,126 @@
+package sessions
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/pkg/durations"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultStartBackoff = 10 * time.Second
+const defaultStartMaxBackoff = 5 * time.Minute
+
+// startFailure counts the consecutive starts which did not complete in time
+type startFailure struct {
+	count   int
+	retryAt time.Time
+	// desiredReplicas is the number of replicas the instance was started with
+	desiredReplicas int32
+}
+
+// startTimeout returns the time an instance has to become ready once started, zero if unlimited
+func (s *SessionsManager) startTimeout(name string) time.Duration {
+	if timeout := s.policy(name).StartTimeout; timeout > 0 {
+		return timeout
+	}
+	return s.config.StartTimeout
+}
+
+// startTimedOut returns true if the instance did not become ready before its start deadline
+func (s *SessionsManager) startTimedOut(state *instance.State) bool {
+	timeout := s.startTimeout(state.Name)
+	return timeout > 0 && state.StartedAt != nil && !state.IsReady() && time.Since(*state.StartedAt) > timeout
+}
+
+// abortStart ends the session of an instance which did not become ready in time.
+// The instance is stopped if configured, and cannot be started again until its backoff elapsed.
+func (s *SessionsManager) abortStart(state *instance.State) *instance.State {
+	name := state.Name
+
+	count := 1
+	if value, ok := s.startFailures.Load(name); ok {
+		count = value.(startFailure).count + 1
+	}
+	backoff := s.startBackoff(count)
+	s.startFailures.Store(name, startFailure{count: count, retryAt: time.Now().Add(backoff), desiredReplicas: state.DesiredReplicas})
+
+	s.store.Delete(name)
+	s.states.Delete(name)
+
+	if s.config.StopOnStartTimeout {
+		s.stopNotReady(name)
+	}
+
+	state.Status = instance.Unrecoverable
+	state.Message = fmt.Sprintf("instance was not ready after %s, it can be started again in %s", durations.Humanize(s.startTimeout(name)), durations.Humanize(backoff))
+	log.Warnf("%s: %s", name, state.Message)
+	s.publish(events.InstanceUnrecoverable, name, state.Message)
+	return state
+}
+
+// stopNotReady stops an instance which was not ready in time, the instances are stopped by the leader only
+func (s *SessionsManager) stopNotReady(name string) {
+	if !s.isLeader() {
+		log.Debugf("%s was not ready in time, it is stopped by the leader", name)
+		return
+	}
+
+	log.Debugf("stopping %s which was not ready in time...", name)
+	if s.stopper != nil {
+		s.stopper.Stop(s.ctx, name)
+		return
+	}
+	go func() {
+		if err := s.provider.Stop(s.ctx, name); err != nil {
+			log.Warnf("error stopping %s: %v", name, err)
+		}
+	}()
+}
+
+// startBackoff doubles the initial backoff for each consecutive failure, up to the maximum
+func (s *SessionsManager) startBackoff(failures int) time.Duration {
+	backoff := s.config.StartBackoff
+	if backoff <= 0 {
+		backoff = defaultStartBackoff
+	}
+	maxBackoff := s.config.StartMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultStartMaxBackoff
+	}
+
+	for i := 1; i < failures && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// backingOff returns the state of an instance which cannot be started yet after failed starts, if so
+func (s *SessionsManager) backingOff(name string) (instance.State, bool) {
+	value, ok := s.startFailures.Load(name)
+	if !ok {
+		return instance.State{}, false
+	}
+
+	failure := value.(startFailure)
+	remaining := time.Until(failure.retryAt)
+	if remaining <= 0 {
+		return instance.State{}, false
+	}
+
+	state := instance.State{
+		Name:            name,
+		CurrentReplicas: 0,
+		DesiredReplicas: failure.desiredReplicas,
+		Status:          instance.Unrecoverable,
+		Message:         fmt.Sprintf("instance failed to become ready %d time(s), it can be started again in %s", failure.count, durations.Humanize(max(remaining, time.Second))),
+	}
+	return state, true
+}
+
+// startSucceeded resets the backoff once the instance is ready
+func (s *SessionsManager) startSucceeded(name string) {
+	s.startFailures.Delete(name)
+}
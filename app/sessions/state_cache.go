@@ -0,0 +1,56 @@
+package sessions
+
+import (
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+)
+
+const defaultStateCacheTTL = 1 * time.Second
+
+type cachedState struct {
+	state     instance.State
+	fetchedAt time.Time
+}
+
+// stateCache keeps the latest provider state of each instance for a short period of time,
+// so that bursts of requests for the same instance do not all hit the provider API.
+// The zero value is ready to use.
+type stateCache struct {
+	ttl    time.Duration
+	states sync.Map
+}
+
+func (c *stateCache) Get(name string) (instance.State, bool) {
+	value, ok := c.states.Load(name)
+	if !ok {
+		return instance.State{}, false
+	}
+
+	cached := value.(cachedState)
+	if time.Since(cached.fetchedAt) > c.timeToLive() {
+		c.states.Delete(name)
+		return instance.State{}, false
+	}
+
+	return cached.state, true
+}
+
+func (c *stateCache) Put(name string, state instance.State) {
+	c.states.Store(name, cachedState{
+		state:     state,
+		fetchedAt: time.Now(),
+	})
+}
+
+func (c *stateCache) Delete(name string) {
+	c.states.Delete(name)
+}
+
+func (c *stateCache) timeToLive() time.Duration {
+	if c.ttl <= 0 {
+		return defaultStateCacheTTL
+	}
+	return c.ttl
+}
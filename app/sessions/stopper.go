@@ -0,0 +1,448 @@
+package sessions
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/discovery"
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/providers"
+	"github.com/acouvreur/sablier/app/storage"
+	"github.com/acouvreur/sablier/config"
+	log "github.com/sirupsen/logrus"
+)
+
+const defaultPreStopTimeout = 30 * time.Second
+const defaultStopTimeout = 30 * time.Second
+const defaultStopBackoff = 5 * time.Second
+const defaultStopMaxBackoff = 1 * time.Minute
+
+// Stopper stops the instances whose session expired.
+//
+// Before being stopped, an instance is draining: its pre-stop hook runs, then its
+// drain period elapses. Requesting the session while it is draining cancels the stop.
+//
+// A failed stop is retried with a backoff. Once the retries are exhausted, the instance is
+// marked as stop-failed until it stops or its session is requested again.
+type Stopper struct {
+	provider providers.Provider
+	events   *events.Bus
+	config   config.Sessions
+	client   *http.Client
+
+	mu       sync.Mutex
+	draining map[string]*drain
+	failed   map[string]StopFailure
+}
+
+// StopFailure describes an instance which could not be stopped
+type StopFailure struct {
+	At       time.Time
+	Attempts int
+	Err      error
+
+	// state is the state of the expired session
+	state instance.State
+}
+
+type drain struct {
+	// state is the state of the expired session
+	state    instance.State
+	stopAt   time.Time
+	cancel   context.CancelFunc
+	stopping bool
+	// stopped is closed when the stop attempt in progress ends
+	stopped chan struct{}
+}
+
+type preStopHook func(ctx context.Context) error
+
+func NewStopper(provider providers.Provider, bus *events.Bus, conf config.Sessions) *Stopper {
+	return &Stopper{
+		provider: provider,
+		events:   bus,
+		config:   conf,
+		client:   &http.Client{},
+		draining: make(map[string]*drain),
+		failed:   make(map[string]StopFailure),
+	}
+}
+
+// OnExpire is the callback of the sessions store, it stops the instance once drained
+func (s *Stopper) OnExpire(name string, state instance.State) {
+	go s.drainAndStop(context.Background(), name, state, true)
+}
+
+// Stop stops the instance right away, without draining it. The stop is retried like the stop of
+// an expired session, until the retries are exhausted or ctx is done.
+func (s *Stopper) Stop(ctx context.Context, name string) {
+	go s.drainAndStop(ctx, name, instance.State{}, false)
+}
+
+// Cancel cancels the drain of the instance and returns the state of its expired session if it is still running.
+// If the instance is already being stopped, it waits for the stop attempt to complete.
+func (s *Stopper) Cancel(name string) (instance.State, bool) {
+	if s == nil {
+		return instance.State{}, false
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if failure, ok := s.failed[name]; ok {
+		delete(s.failed, name)
+		return failure.state, true
+	}
+	for {
+		d, ok := s.draining[name]
+		if !ok {
+			return instance.State{}, false
+		}
+		if !d.stopping {
+			d.cancel()
+			delete(s.draining, name)
+			log.Debugf("%s was requested while draining, its stop is cancelled", name)
+			return d.state, true
+		}
+
+		stopped := d.stopped
+		s.mu.Unlock()
+		<-stopped
+		s.mu.Lock()
+	}
+}
+
+// Draining returns the draining instances with the time at which they will be stopped
+func (s *Stopper) Draining() map[string]time.Time {
+	if s == nil {
+		return map[string]time.Time{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	draining := make(map[string]time.Time, len(s.draining))
+	for name, d := range s.draining {
+		draining[name] = d.stopAt
+	}
+	return draining
+}
+
+// Failed returns the instances which could not be stopped
+func (s *Stopper) Failed() map[string]StopFailure {
+	if s == nil {
+		return map[string]StopFailure{}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	failed := make(map[string]StopFailure, len(s.failed))
+	for name, failure := range s.failed {
+		failed[name] = failure
+	}
+	return failed
+}
+
+// Load restores the stop failures saved by Save, the instances which stopped since are cleared by the reconciliation
+func (s *Stopper) Load(reader io.ReadCloser) error {
+	defer reader.Close()
+
+	var saved map[string]stopFailureJSON
+	if err := json.NewDecoder(reader).Decode(&saved); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for name, failure := range saved {
+		if _, ok := s.failed[name]; ok {
+			continue
+		}
+		s.failed[name] = StopFailure{At: failure.At, Attempts: failure.Attempts, Err: errors.New(failure.Error), state: failure.State}
+	}
+	return nil
+}
+
+// Save writes the stop failures so that the instances are still reported as stop-failed after a restart
+func (s *Stopper) Save(writer io.WriteCloser) error {
+	s.mu.Lock()
+	saved := make(map[string]stopFailureJSON, len(s.failed))
+	for name, failure := range s.failed {
+		saved[name] = stopFailureJSON{At: failure.At, Attempts: failure.Attempts, Error: failure.Err.Error(), State: failure.state}
+	}
+	s.mu.Unlock()
+
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return storage.Close(writer, encoder.Encode(saved))
+}
+
+type stopFailureJSON struct {
+	At       time.Time      `json:"at"`
+	Attempts int            `json:"attempts"`
+	Error    string         `json:"error"`
+	State    instance.State `json:"state"`
+}
+
+// Stopped clears the failure of an instance which stopped
+func (s *Stopper) Stopped(name string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.failed, name)
+}
+
+// drainAndStop stops the instance, once drained if graceful is true
+func (s *Stopper) drainAndStop(ctx context.Context, name string, state instance.State, graceful bool) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	// The drain is registered before its labels are read, so that a request in between cancels the stop
+	d := &drain{
+		state:    state,
+		stopAt:   time.Now().Add(s.config.DrainPeriod),
+		cancel:   cancel,
+		stopping: !graceful,
+		stopped:  make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	if _, ok := s.draining[name]; ok {
+		s.mu.Unlock()
+		return
+	}
+	s.draining[name] = d
+	s.mu.Unlock()
+
+	defer func() {
+		s.mu.Lock()
+		if s.draining[name] == d {
+			delete(s.draining, name)
+		}
+		s.mu.Unlock()
+	}()
+
+	if graceful {
+		s.events.Publish(events.Event{Type: events.SessionExpired, Instance: name})
+
+		labels := s.labels(name)
+		drainPeriod := s.drainPeriod(name, labels)
+		hook := s.preStopHook(name, labels)
+
+		s.mu.Lock()
+		if ctx.Err() != nil {
+			s.mu.Unlock()
+			return
+		}
+		d.stopAt = time.Now().Add(drainPeriod)
+		d.stopping = hook == nil && drainPeriod <= 0
+		s.mu.Unlock()
+
+		if !d.stopping {
+			log.Debugf("%s is draining for %v", name, drainPeriod)
+			if !s.drain(ctx, name, hook, drainPeriod) {
+				return
+			}
+
+			s.mu.Lock()
+			if ctx.Err() != nil {
+				s.mu.Unlock()
+				return
+			}
+			d.stopping = true
+			s.mu.Unlock()
+		}
+	}
+
+	s.stop(ctx, name, d)
+}
+
+// stop stops the instance, a failed stop is retried until the retries are exhausted or the stop is cancelled
+func (s *Stopper) stop(ctx context.Context, name string, d *drain) {
+	var err error
+	attempts := 0
+	for attempts <= max(s.config.StopMaxRetries, 0) {
+		if attempts > 0 {
+			backoff := s.stopBackoff(attempts)
+			log.Warnf("error stopping %s, retrying in %v: %v", name, backoff, err)
+			if !s.waitRetry(ctx, d, backoff) {
+				log.Debugf("%s was requested while its stop was retried", name)
+				return
+			}
+		}
+
+		log.Debugf("stopping %s...", name)
+		attempts++
+		if err = s.attemptStop(name, d); err == nil {
+			log.Debugf("stopped %s", name)
+			return
+		}
+	}
+
+	s.mu.Lock()
+	if ctx.Err() != nil {
+		s.mu.Unlock()
+		return
+	}
+	s.failed[name] = StopFailure{At: time.Now(), Attempts: attempts, Err: err, state: d.state}
+	s.mu.Unlock()
+	log.Errorf("could not stop %s after %d attempts, it is marked as %s: %v", name, attempts, instance.StopFailed, err)
+	s.events.Publish(events.Event{Type: events.InstanceStopFailed, Instance: name, Message: err.Error()})
+}
+
+// attemptStop stops the instance within the stop timeout, the drain ends if it succeeds
+func (s *Stopper) attemptStop(name string, d *drain) error {
+	ctx, cancel := context.WithTimeout(context.Background(), s.stopTimeout())
+	err := s.provider.Stop(ctx, name)
+	cancel()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.failed, name)
+		if s.draining[name] == d {
+			delete(s.draining, name)
+		}
+	} else {
+		// The instance is still running, requesting its session cancels the retries
+		d.stopping = false
+	}
+	close(d.stopped)
+	d.stopped = make(chan struct{})
+	return err
+}
+
+// waitRetry waits before retrying a stop, it returns false if cancelled
+func (s *Stopper) waitRetry(ctx context.Context, d *drain, backoff time.Duration) bool {
+	timer := time.NewTimer(backoff)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	d.stopping = true
+	return true
+}
+
+func (s *Stopper) stopTimeout() time.Duration {
+	if s.config.StopTimeout <= 0 {
+		return defaultStopTimeout
+	}
+	return s.config.StopTimeout
+}
+
+// stopBackoff doubles the initial backoff for each retry, up to the maximum
+func (s *Stopper) stopBackoff(retry int) time.Duration {
+	backoff := s.config.StopBackoff
+	if backoff <= 0 {
+		backoff = defaultStopBackoff
+	}
+	maxBackoff := s.config.StopMaxBackoff
+	if maxBackoff <= 0 {
+		maxBackoff = defaultStopMaxBackoff
+	}
+
+	for i := 1; i < retry && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxBackoff)
+}
+
+// drain runs the pre-stop hook and waits for the drain period, it returns false if cancelled
+func (s *Stopper) drain(ctx context.Context, name string, hook preStopHook, drainPeriod time.Duration) bool {
+	if hook != nil {
+		hookCtx, cancel := context.WithTimeout(ctx, s.preStopTimeout())
+		err := hook(hookCtx)
+		cancel()
+		if err != nil {
+			log.Warnf("pre-stop hook of %s failed: %v", name, err)
+		}
+	}
+
+	timer := time.NewTimer(drainPeriod)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+func (s *Stopper) labels(name string) map[string]string {
+	labelsProvider, ok := s.provider.(providers.LabelsProvider)
+	if !ok {
+		return map[string]string{}
+	}
+
+	labels, err := labelsProvider.GetLabels(context.Background(), name)
+	if err != nil {
+		log.Warnf("could not read the labels of %s: %v", name, err)
+		return map[string]string{}
+	}
+	return labels
+}
+
+func (s *Stopper) drainPeriod(name string, labels map[string]string) time.Duration {
+	if drainPeriod := parseDurationLabel(name, labels, discovery.LabelDrainPeriod); drainPeriod > 0 {
+		return drainPeriod
+	}
+	return s.config.DrainPeriod
+}
+
+func (s *Stopper) preStopTimeout() time.Duration {
+	if s.config.PreStopTimeout <= 0 {
+		return defaultPreStopTimeout
+	}
+	return s.config.PreStopTimeout
+}
+
+// preStopHook returns the hook defined by the instance labels, nil if none
+func (s *Stopper) preStopHook(name string, labels map[string]string) preStopHook {
+	if url := labels[discovery.LabelPreStopHTTP]; url != "" {
+		return func(ctx context.Context) error {
+			log.Debugf("calling pre-stop hook of %s: POST %s", name, url)
+			req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, nil)
+			if err != nil {
+				return err
+			}
+			resp, err := s.client.Do(req)
+			if err != nil {
+				return err
+			}
+			defer resp.Body.Close()
+			if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+				return fmt.Errorf("POST %s returned %s", url, resp.Status)
+			}
+			return nil
+		}
+	}
+
+	if command := labels[discovery.LabelPreStopExec]; command != "" {
+		executor, ok := s.provider.(providers.Executor)
+		if !ok {
+			log.Warnf("%s defines a pre-stop exec hook but the provider cannot run commands", name)
+			return nil
+		}
+		return func(ctx context.Context) error {
+			log.Debugf("running pre-stop hook of %s: %s", name, command)
+			return executor.Exec(ctx, name, []string{"sh", "-c", command})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,280 @@
+package sessions
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/sessions/mocks"
+	"github.com/acouvreur/sablier/config"
+	"github.com/stretchr/testify/mock"
+	"gotest.tools/v3/assert"
+)
+
+func TestStopper_StopsWithoutDrain(t *testing.T) {
+	providermock := mocks.NewProviderMock()
+	providermock.On("Stop", "nginx").Return(nil)
+	stopper := NewStopper(providermock, nil, config.NewSessionsConfig())
+
+	stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+
+	providermock.AssertCalled(t, "Stop", "nginx")
+	assert.Equal(t, len(stopper.Draining()), 0)
+}
+
+func TestStopper_RunsPreStopHookBeforeStopping(t *testing.T) {
+	hooked := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.Method, http.MethodPost)
+		hooked <- struct{}{}
+	}))
+	defer server.Close()
+
+	providermock := mocks.NewProviderWithLabelsMock()
+	providermock.On("GetLabels", "nginx").Return(map[string]string{
+		"sablier.pre-stop.http": server.URL,
+		"sablier.drain-period":  "10ms",
+	}, nil)
+	providermock.On("Stop", "nginx").Return(nil)
+	stopper := NewStopper(providermock, nil, config.NewSessionsConfig())
+
+	stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+
+	assert.Equal(t, len(hooked), 1)
+	providermock.AssertCalled(t, "Stop", "nginx")
+}
+
+func TestStopper_CancelWhileDraining(t *testing.T) {
+	providermock := mocks.NewProviderMock()
+	conf := config.NewSessionsConfig()
+	conf.DrainPeriod = time.Hour
+	stopper := NewStopper(providermock, nil, conf)
+	startedAt := time.Now().Add(-time.Hour)
+
+	done := make(chan struct{})
+	go func() {
+		stopper.drainAndStop(context.Background(), "nginx", instance.State{Name: "nginx", StartedAt: &startedAt}, true)
+		close(done)
+	}()
+
+	for len(stopper.Draining()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	assert.Assert(t, time.Until(stopper.Draining()["nginx"]) > 59*time.Minute)
+
+	expired, running := stopper.Cancel("nginx")
+	assert.Assert(t, running)
+	assert.Equal(t, *expired.StartedAt, startedAt)
+	<-done
+
+	providermock.AssertNotCalled(t, "Stop", "nginx")
+	_, running = stopper.Cancel("nginx")
+	assert.Assert(t, !running)
+}
+
+func TestSessionsManager_RequestSessionWhileDraining(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	conf := config.NewSessionsConfig()
+	conf.DrainPeriod = time.Hour
+	s.stopper = NewStopper(providermock, nil, conf)
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	go s.stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+	for len(s.stopper.Draining()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	info, ok := s.GetSession("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, info.Status, "draining")
+
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+
+	assert.Assert(t, session.IsReady())
+	assert.Equal(t, len(s.stopper.Draining()), 0)
+	providermock.AssertNotCalled(t, "Stop", "nginx")
+}
+
+func TestStopper_RetriesFailedStop(t *testing.T) {
+	providermock := mocks.NewProviderMock()
+	providermock.On("Stop", "nginx").Return(errors.New("connection refused")).Once()
+	providermock.On("Stop", "nginx").Return(nil)
+	conf := config.NewSessionsConfig()
+	conf.StopBackoff = time.Millisecond
+	stopper := NewStopper(providermock, nil, conf)
+
+	stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+
+	providermock.AssertNumberOfCalls(t, "Stop", 2)
+	assert.Equal(t, len(stopper.Failed()), 0)
+	assert.Equal(t, len(stopper.Draining()), 0)
+}
+
+func TestStopper_MarksStopFailed(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	providermock.On("Stop", "nginx").Return(errors.New("connection refused"))
+	bus := events.NewBus()
+	received, unsubscribe := bus.Subscribe(10, events.InstanceStopFailed)
+	defer unsubscribe()
+	conf := config.NewSessionsConfig()
+	conf.StopMaxRetries = 2
+	conf.StopBackoff = time.Millisecond
+	s.stopper = NewStopper(providermock, bus, conf)
+
+	s.stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+
+	providermock.AssertNumberOfCalls(t, "Stop", 3)
+	assert.Equal(t, s.stopper.Failed()["nginx"].Attempts, 3)
+	event := <-received
+	assert.Equal(t, event.Instance, "nginx")
+	assert.Equal(t, event.Message, "connection refused")
+
+	info, ok := s.GetSession("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, info.Status, instance.StopFailed)
+	assert.Equal(t, info.Message, "session expired, the instance could not be stopped after 3 attempts: connection refused")
+	assert.Equal(t, len(s.ListSessions()), 1)
+
+	s.stopper.Stopped("nginx")
+	_, ok = s.GetSession("nginx")
+	assert.Assert(t, !ok)
+}
+
+type nopWriteCloser struct{ *bytes.Buffer }
+
+func (nopWriteCloser) Close() error { return nil }
+
+func TestStopper_StopFailedAfterRestart(t *testing.T) {
+	providermock := mocks.NewProviderMock()
+	providermock.On("Stop", "nginx").Return(errors.New("connection refused"))
+	conf := config.NewSessionsConfig()
+	conf.StopMaxRetries = 0
+	stopper := NewStopper(providermock, nil, conf)
+	stopper.drainAndStop(context.Background(), "nginx", instance.State{Name: "nginx"}, true)
+	buf := &bytes.Buffer{}
+	assert.NilError(t, stopper.Save(nopWriteCloser{buf}))
+
+	s, restarted := newControlTestManager(t)
+	s.stopper = NewStopper(restarted, nil, conf)
+	assert.NilError(t, s.stopper.Load(io.NopCloser(buf)))
+
+	info, ok := s.GetSession("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, info.Status, instance.StopFailed)
+	assert.Equal(t, info.Message, "session expired, the instance could not be stopped after 1 attempts: connection refused")
+
+	// The failure is kept while the instance is still running, and cleared once it stopped
+	restarted.On("GetState", "nginx").Return(instance.ReadyInstanceState("nginx", 1), nil).Once()
+	s.Reconcile(context.Background())
+	assert.Equal(t, len(s.stopper.Failed()), 1)
+
+	restarted.On("GetState", "nginx").Return(instance.NotReadyInstanceState("nginx", 0, 1), nil).Once()
+	s.Reconcile(context.Background())
+	assert.Equal(t, len(s.stopper.Failed()), 0)
+}
+
+type blockingStopProvider struct {
+	*mocks.ProviderMock
+}
+
+func (p blockingStopProvider) Stop(ctx context.Context, _ string) error {
+	<-ctx.Done()
+	return ctx.Err()
+}
+
+func TestStopper_StopTimeout(t *testing.T) {
+	conf := config.NewSessionsConfig()
+	conf.StopTimeout = 10 * time.Millisecond
+	conf.StopMaxRetries = 0
+	stopper := NewStopper(blockingStopProvider{mocks.NewProviderMock()}, nil, conf)
+
+	stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+
+	assert.ErrorIs(t, stopper.Failed()["nginx"].Err, context.DeadlineExceeded)
+}
+
+func TestStopper_CancelWhileRetrying(t *testing.T) {
+	providermock := mocks.NewProviderMock()
+	attempted := make(chan struct{}, 1)
+	providermock.On("Stop", "nginx").Return(errors.New("connection refused")).Run(func(mock.Arguments) {
+		attempted <- struct{}{}
+	})
+	conf := config.NewSessionsConfig()
+	conf.StopBackoff = time.Hour
+	stopper := NewStopper(providermock, nil, conf)
+
+	done := make(chan struct{})
+	go func() {
+		stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+		close(done)
+	}()
+	<-attempted
+
+	// The instance is still running
+	_, running := stopper.Cancel("nginx")
+	assert.Assert(t, running)
+	<-done
+
+	providermock.AssertNumberOfCalls(t, "Stop", 1)
+	assert.Equal(t, len(stopper.Failed()), 0)
+}
+
+func TestSessionsManager_RequestSessionWhileDrainingKeepsMaxLifetime(t *testing.T) {
+	s, providermock := newControlTestManager(t)
+	s.config.MaxLifetime = time.Hour
+	conf := config.NewSessionsConfig()
+	conf.DrainPeriod = time.Hour
+	s.stopper = NewStopper(providermock, nil, conf)
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	startedAt := time.Now().Add(-50 * time.Minute)
+	go s.stopper.drainAndStop(context.Background(), "nginx", instance.State{Name: "nginx", StartedAt: &startedAt}, true)
+	for len(s.stopper.Draining()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	s.RequestSession([]string{"nginx"}, time.Hour)
+
+	// The session keeps its start time, cancelling the drain does not extend its lifetime
+	entry, ok := s.store.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, *entry.Value().StartedAt, startedAt)
+	assert.Assert(t, entry.ExpiresAt().Sub(startedAt.Add(time.Hour)) < time.Second)
+}
+
+func TestSessionsManager_RequestSessionWhileReadingDrainLabels(t *testing.T) {
+	s, _ := newControlTestManager(t)
+	providermock := mocks.NewProviderWithLabelsMock()
+	s.provider = providermock
+	s.stopper = NewStopper(providermock, nil, config.NewSessionsConfig())
+	release := make(chan time.Time)
+	// The labels of the expired session are read by the stopper first
+	providermock.On("GetLabels", "nginx").WaitUntil(release).Return(map[string]string{}, nil).Once()
+	providermock.On("GetLabels", "nginx").Return(map[string]string{}, nil)
+	providermock.On("Start", "nginx").Return(nil)
+	providermock.On("GetState", "nginx").Return(instance.State{Name: "nginx", Status: instance.Ready}, nil)
+
+	done := make(chan struct{})
+	go func() {
+		s.stopper.drainAndStop(context.Background(), "nginx", instance.State{}, true)
+		close(done)
+	}()
+	for len(s.stopper.Draining()) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	session := s.RequestSession([]string{"nginx"}, time.Minute)
+	close(release)
+	<-done
+
+	assert.Assert(t, session.IsReady())
+	providermock.AssertNotCalled(t, "Stop", "nginx")
+}
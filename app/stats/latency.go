@@ -0,0 +1,47 @@
+package stats
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"time"
+)
+
+// Latency holds the percentiles of a set of durations
+type Latency struct {
+	Samples int
+	P50     time.Duration
+	P90     time.Duration
+	P99     time.Duration
+}
+
+func NewLatency(durations []time.Duration) Latency {
+	sorted := slices.Clone(durations)
+	slices.Sort(sorted)
+
+	return Latency{
+		Samples: len(sorted),
+		P50:     percentile(sorted, 50),
+		P90:     percentile(sorted, 90),
+		P99:     percentile(sorted, 99),
+	}
+}
+
+// percentile returns the nearest-rank percentile of sorted durations, zero if there is none
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank, 1)-1]
+}
+
+// MarshalJSON writes the percentiles in milliseconds
+func (l Latency) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Samples int   `json:"samples"`
+		P50     int64 `json:"p50Ms"`
+		P90     int64 `json:"p90Ms"`
+		P99     int64 `json:"p99Ms"`
+	}{l.Samples, l.P50.Milliseconds(), l.P90.Milliseconds(), l.P99.Milliseconds()})
+}
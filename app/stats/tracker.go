@@ -0,0 +1,140 @@
+// Package stats keeps the recent state transitions of the instances and
+// computes their start latency from them.
+package stats
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+)
+
+// HistorySize is the number of transitions kept per instance
+const HistorySize = 100
+
+// Transition is a change of state of an instance
+type Transition struct {
+	Type    events.Type `json:"type"`
+	Time    time.Time   `json:"time"`
+	Message string      `json:"message,omitempty"`
+}
+
+// InstanceStats holds the recent transitions of an instance and its start latency
+type InstanceStats struct {
+	Instance     string       `json:"instance"`
+	Transitions  []Transition `json:"transitions"`
+	StartLatency Latency      `json:"startLatency"`
+}
+
+// Tracker records the transitions of the instances from the lifecycle events
+type Tracker struct {
+	mu          sync.RWMutex
+	transitions map[string][]Transition
+}
+
+func NewTracker() *Tracker {
+	return &Tracker{
+		transitions: make(map[string][]Transition),
+	}
+}
+
+// Run records the events until the context is done or the channel is closed
+func (t *Tracker) Run(ctx context.Context, received <-chan events.Event) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case event, ok := <-received:
+			if !ok {
+				return
+			}
+			t.Record(event)
+		}
+	}
+}
+
+// Record appends the event to the transitions of its instance, dropping the oldest ones
+func (t *Tracker) Record(event events.Event) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	transitions := append(t.transitions[event.Instance], Transition{
+		Type:    event.Type,
+		Time:    event.Time,
+		Message: event.Message,
+	})
+	if len(transitions) > HistorySize {
+		transitions = transitions[len(transitions)-HistorySize:]
+	}
+	t.transitions[event.Instance] = transitions
+}
+
+// Stats returns the stats of all the instances sorted by name
+func (t *Tracker) Stats() []InstanceStats {
+	t.mu.RLock()
+	names := make([]string, 0, len(t.transitions))
+	for name := range t.transitions {
+		names = append(names, name)
+	}
+	t.mu.RUnlock()
+	sort.Strings(names)
+
+	stats := make([]InstanceStats, 0, len(names))
+	for _, name := range names {
+		if s, ok := t.InstanceStats(name); ok {
+			stats = append(stats, s)
+		}
+	}
+	return stats
+}
+
+// InstanceStats returns the stats of an instance, false if it has no transition
+func (t *Tracker) InstanceStats(name string) (InstanceStats, bool) {
+	t.mu.RLock()
+	transitions, ok := t.transitions[name]
+	transitions = append([]Transition(nil), transitions...)
+	t.mu.RUnlock()
+	if !ok {
+		return InstanceStats{}, false
+	}
+
+	return InstanceStats{
+		Instance:     name,
+		Transitions:  transitions,
+		StartLatency: NewLatency(startDurations(transitions)),
+	}, true
+}
+
+// ExpectedStartDuration returns the median start latency of an instance, false without samples
+func (t *Tracker) ExpectedStartDuration(name string) (time.Duration, bool) {
+	t.mu.RLock()
+	durations := startDurations(t.transitions[name])
+	t.mu.RUnlock()
+
+	if len(durations) == 0 {
+		return 0, false
+	}
+	return NewLatency(durations).P50, true
+}
+
+// startDurations returns the durations between each start and the instance becoming ready
+func startDurations(transitions []Transition) []time.Duration {
+	var durations []time.Duration
+	var startedAt time.Time
+	for _, transition := range transitions {
+		switch transition.Type {
+		case events.InstanceStarting:
+			startedAt = transition.Time
+		case events.InstanceReady:
+			if !startedAt.IsZero() {
+				durations = append(durations, transition.Time.Sub(startedAt))
+			}
+			startedAt = time.Time{}
+		case events.InstanceUnrecoverable, events.InstanceStopped:
+			startedAt = time.Time{}
+		}
+	}
+	return durations
+}
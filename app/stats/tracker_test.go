@@ -0,0 +1,83 @@
+package stats
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/app/events"
+	"gotest.tools/v3/assert"
+)
+
+func record(t *Tracker, name string, at time.Time, types ...events.Type) time.Time {
+	for _, eventType := range types {
+		t.Record(events.Event{Type: eventType, Instance: name, Time: at})
+		at = at.Add(time.Second)
+	}
+	return at
+}
+
+func TestTracker_StartLatency(t *testing.T) {
+	tracker := NewTracker()
+	now := time.Date(2024, 10, 21, 9, 0, 0, 0, time.UTC)
+
+	// Starts of 10s, 20s and 30s, a failed start and a stop in the middle of a start are ignored
+	for _, latency := range []time.Duration{20 * time.Second, 10 * time.Second, 30 * time.Second} {
+		tracker.Record(events.Event{Type: events.InstanceStarting, Instance: "nginx", Time: now})
+		tracker.Record(events.Event{Type: events.InstanceReady, Instance: "nginx", Time: now.Add(latency)})
+		now = now.Add(time.Hour)
+	}
+	now = record(tracker, "nginx", now, events.InstanceStarting, events.InstanceUnrecoverable, events.InstanceReady)
+	record(tracker, "nginx", now, events.InstanceStarting, events.InstanceStopped, events.InstanceReady)
+
+	stats, ok := tracker.InstanceStats("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, len(stats.Transitions), 12)
+	assert.DeepEqual(t, stats.StartLatency, Latency{Samples: 3, P50: 20 * time.Second, P90: 30 * time.Second, P99: 30 * time.Second})
+
+	expected, ok := tracker.ExpectedStartDuration("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, expected, 20*time.Second)
+
+	_, ok = tracker.ExpectedStartDuration("apache")
+	assert.Assert(t, !ok)
+}
+
+func TestTracker_HistorySize(t *testing.T) {
+	tracker := NewTracker()
+	now := time.Now()
+
+	for i := 0; i < HistorySize+10; i++ {
+		now = record(tracker, "nginx", now, events.SessionRequested)
+	}
+	record(tracker, "apache", now, events.SessionRequested)
+
+	stats := tracker.Stats()
+	assert.Equal(t, len(stats), 2)
+	assert.Equal(t, stats[0].Instance, "apache")
+	assert.Equal(t, len(stats[1].Transitions), HistorySize)
+}
+
+func TestTracker_Run(t *testing.T) {
+	tracker := NewTracker()
+	received := make(chan events.Event, 1)
+	received <- events.Event{Type: events.InstanceStarting, Instance: "nginx", Time: time.Now()}
+	close(received)
+
+	tracker.Run(context.Background(), received)
+
+	_, ok := tracker.InstanceStats("nginx")
+	assert.Assert(t, ok)
+}
+
+func TestPercentile(t *testing.T) {
+	durations := make([]time.Duration, 0, 100)
+	for i := 100; i > 0; i-- {
+		durations = append(durations, time.Duration(i)*time.Second)
+	}
+
+	latency := NewLatency(durations)
+
+	assert.DeepEqual(t, latency, Latency{Samples: 100, P50: 50 * time.Second, P90: 90 * time.Second, P99: 99 * time.Second})
+	assert.DeepEqual(t, NewLatency(nil), Latency{})
+}
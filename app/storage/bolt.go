@@ -0,0 +1,224 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/acouvreur/sablier/config"
+	log "github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// sessionsBucket holds an entry per session
+	sessionsBucket = []byte("sessions")
+	// namespacesBucket holds the data of each namespace as a single value
+	namespacesBucket = []byte("namespaces")
+)
+
+// openTimeout is how long to wait for the lock of a database opened by another process
+const openTimeout = 5 * time.Second
+
+// Interface guard
+var _ KVStorage = (*BoltStorage)(nil)
+
+// BoltStorage persists the sessions in an embedded bbolt database, each session being a separate
+// entry so that a change is written without rewriting all the sessions. When the changes are
+// written is up to the PersistentKV using it.
+type BoltStorage struct {
+	db *bolt.DB
+}
+
+func NewBoltStorage(conf config.Storage) (*BoltStorage, error) {
+	if conf.File == "" {
+		return nil, fmt.Errorf("the bbolt storage backend requires a file")
+	}
+
+	db, err := bolt.Open(conf.File, 0600, &bolt.Options{Timeout: openTimeout})
+	if err != nil {
+		return nil, fmt.Errorf("could not open %s: %w", conf.File, err)
+	}
+
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, bucket := range [][]byte{sessionsBucket, namespacesBucket} {
+			if _, err := tx.CreateBucketIfNotExists(bucket); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	log.Infof("initialized bbolt storage to %s", conf.File)
+	return &BoltStorage{db: db}, nil
+}
+
+func (bs *BoltStorage) Put(key string, value []byte) error {
+	// Concurrent puts are written in a single transaction
+	return bs.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Put([]byte(key), value)
+	})
+}
+
+func (bs *BoltStorage) Delete(key string) error {
+	return bs.db.Batch(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).Delete([]byte(key))
+	})
+}
+
+func (bs *BoltStorage) Write(puts map[string][]byte, deletes []string) error {
+	return bs.db.Update(func(tx *bolt.Tx) error {
+		bucket := tx.Bucket(sessionsBucket)
+		for key, value := range puts {
+			if err := bucket.Put([]byte(key), value); err != nil {
+				return err
+			}
+		}
+		for _, key := range deletes {
+			if err := bucket.Delete([]byte(key)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (bs *BoltStorage) Entries() (map[string][]byte, error) {
+	entries := make(map[string][]byte)
+	err := bs.db.View(func(tx *bolt.Tx) error {
+		return tx.Bucket(sessionsBucket).ForEach(func(k, v []byte) error {
+			entries[string(k)] = bytes.Clone(v)
+			return nil
+		})
+	})
+	return entries, err
+}
+
+// Reader returns the sessions as a single JSON object in the versioned envelope, in the same format as the file storage
+func (bs *BoltStorage) Reader() (io.ReadCloser, error) {
+	entries, err := bs.Entries()
+	if err != nil {
+		return nil, err
+	}
+
+	sessions, err := LoadRecords(entries)
+	if err != nil {
+		return nil, err
+	}
+	b, err := json.Marshal(sessions)
+	if err != nil {
+		return nil, err
+	}
+	b, err = json.Marshal(Wrap(b))
+	if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(b)), nil
+}
+
+// Writer replaces all the sessions with the JSON object written, in the same format as the file storage.
+// The sessions of an older version are migrated to the current format.
+func (bs *BoltStorage) Writer() (io.WriteCloser, error) {
+	return &bufferedWriter{commit: func(b []byte) error {
+		b, err := Load(b)
+		if err != nil {
+			return err
+		}
+		var sessions map[string]json.RawMessage
+		if err := json.Unmarshal(b, &sessions); err != nil {
+			return err
+		}
+
+		return bs.db.Update(func(tx *bolt.Tx) error {
+			if err := tx.DeleteBucket(sessionsBucket); err != nil {
+				return err
+			}
+			bucket, err := tx.CreateBucket(sessionsBucket)
+			if err != nil {
+				return err
+			}
+			for key, value := range sessions {
+				record, err := WrapRecord(value)
+				if err != nil {
+					return err
+				}
+				if err := bucket.Put([]byte(key), record); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+	}}, nil
+}
+
+// Namespace stores the data as a single value of the namespaces bucket
+func (bs *BoltStorage) Namespace(name string) (Storage, error) {
+	return &boltNamespace{db: bs.db, name: []byte(name)}, nil
+}
+
+func (bs *BoltStorage) Enabled() bool {
+	return true
+}
+
+func (bs *BoltStorage) Close() error {
+	return bs.db.Close()
+}
+
+type boltNamespace struct {
+	db   *bolt.DB
+	name []byte
+}
+
+func (ns *boltNamespace) Reader() (io.ReadCloser, error) {
+	var value []byte
+	err := ns.db.View(func(tx *bolt.Tx) error {
+		value = bytes.Clone(tx.Bucket(namespacesBucket).Get(ns.name))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if value == nil {
+		// Same as a new file storage
+		value = []byte("{}")
+	}
+	return io.NopCloser(bytes.NewReader(value)), nil
+}
+
+func (ns *boltNamespace) Writer() (io.WriteCloser, error) {
+	return &bufferedWriter{commit: func(b []byte) error {
+		return ns.db.Update(func(tx *bolt.Tx) error {
+			return tx.Bucket(namespacesBucket).Put(ns.name, b)
+		})
+	}}, nil
+}
+
+func (ns *boltNamespace) Namespace(name string) (Storage, error) {
+	return &boltNamespace{db: ns.db, name: append(append(bytes.Clone(ns.name), '.'), name...)}, nil
+}
+
+func (ns *boltNamespace) Enabled() bool {
+	return true
+}
+
+// bufferedWriter commits everything written when it is closed
+type bufferedWriter struct {
+	bytes.Buffer
+	commit func([]byte) error
+}
+
+// Abort discards everything written
+func (w *bufferedWriter) Abort() error {
+	w.Reset()
+	return nil
+}
+
+func (w *bufferedWriter) Close() error {
+	return w.commit(w.Bytes())
+}
@@ -0,0 +1,107 @@
+package storage
+
+import (
+	"encoding/json"
+	"io"
+	"path/filepath"
+	"testing"
+
+	"github.com/acouvreur/sablier/config"
+	"gotest.tools/v3/assert"
+)
+
+func newTestBoltStorage(t testing.TB) (*BoltStorage, string) {
+	file := filepath.Join(t.TempDir(), "sablier.db")
+	bs, err := NewBoltStorage(config.Storage{Backend: "bbolt", File: file})
+	assert.NilError(t, err)
+	t.Cleanup(func() { bs.Close() })
+	return bs, file
+}
+
+func TestBoltStorage_Entries(t *testing.T) {
+	bs, file := newTestBoltStorage(t)
+
+	assert.NilError(t, bs.Put("nginx", []byte(`{"value":1}`)))
+	assert.NilError(t, bs.Put("apache", []byte(`{"value":2}`)))
+	assert.NilError(t, bs.Delete("apache"))
+	assert.NilError(t, bs.Close())
+
+	reopened, err := NewBoltStorage(config.Storage{File: file})
+	assert.NilError(t, err)
+	defer reopened.Close()
+
+	entries, err := reopened.Entries()
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entries, map[string][]byte{"nginx": []byte(`{"value":1}`)})
+}
+
+func TestBoltStorage_ReaderWriter(t *testing.T) {
+	bs, _ := newTestBoltStorage(t)
+	assert.NilError(t, bs.Put("apache", []byte(`{"value":2}`)))
+
+	writer, err := bs.Writer()
+	assert.NilError(t, err)
+	_, err = writer.Write([]byte(`{"nginx":{"value":1}}`))
+	assert.NilError(t, err)
+	assert.NilError(t, writer.Close())
+
+	reader, err := bs.Reader()
+	assert.NilError(t, err)
+	var envelope Envelope
+	assert.NilError(t, json.NewDecoder(reader).Decode(&envelope))
+	assert.Equal(t, envelope.Version, SchemaVersion)
+	var sessions map[string]json.RawMessage
+	assert.NilError(t, json.Unmarshal(envelope.Sessions, &sessions))
+	assert.Equal(t, len(sessions), 1)
+	assert.Equal(t, string(sessions["nginx"]), `{"value":1}`)
+
+	// The envelope written by the reader is read back
+	reader, err = bs.Reader()
+	assert.NilError(t, err)
+	writer, err = bs.Writer()
+	assert.NilError(t, err)
+	_, err = io.Copy(writer, reader)
+	assert.NilError(t, err)
+	assert.NilError(t, writer.Close())
+	entries, err := bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, string(entries["nginx"]), `{"version":2,"session":{"value":1}}`)
+}
+
+func TestBoltStorage_Namespace(t *testing.T) {
+	bs, _ := newTestBoltStorage(t)
+	ns, err := bs.Namespace("prewarm")
+	assert.NilError(t, err)
+
+	reader, err := ns.Reader()
+	assert.NilError(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, string(b), "{}")
+
+	writer, err := ns.Writer()
+	assert.NilError(t, err)
+	writer.Write([]byte(`{"nginx":[]}`))
+	assert.NilError(t, writer.Close())
+
+	reader, err = ns.Reader()
+	assert.NilError(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Equal(t, string(b), `{"nginx":[]}`)
+
+	// The sessions are not affected
+	entries, err := bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+func TestNew(t *testing.T) {
+	s, err := New(config.Storage{})
+	assert.NilError(t, err)
+	assert.Assert(t, !s.Enabled())
+
+	_, err = New(config.Storage{Backend: "bbolt"})
+	assert.ErrorContains(t, err, "requires a file")
+
+	_, err = New(config.Storage{Backend: "sqlite"})
+	assert.ErrorContains(t, err, "unknown storage backend sqlite")
+}
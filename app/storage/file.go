@@ -1,9 +1,15 @@
 package storage
 
 import (
+	"bytes"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 
 	"github.com/acouvreur/sablier/config"
 	log "github.com/sirupsen/logrus"
@@ -13,36 +19,58 @@ type Storage interface {
 	Reader() (io.ReadCloser, error)
 	Writer() (io.WriteCloser, error)
 
+	// Namespace returns a storage for other data persisted alongside the sessions
+	Namespace(name string) (Storage, error)
+
 	Enabled() bool
 }
 
+// Aborter is implemented by the writers of the storages. Abort discards what was written
+// instead of committing it, the stored data is left unchanged.
+type Aborter interface {
+	Abort() error
+}
+
+// Close commits the writer when err is nil, and aborts it otherwise. It returns err, or the error
+// of the commit.
+func Close(writer io.WriteCloser, err error) error {
+	if err == nil {
+		return writer.Close()
+	}
+	if aborter, ok := writer.(Aborter); ok {
+		aborter.Abort()
+	} else {
+		writer.Close()
+	}
+	return err
+}
+
+// FileStorage saves the data as JSON in a file. The file is replaced atomically on each save,
+// and the previous versions are kept as snapshots "<file>.1" (the newest) to "<file>.<n>".
 type FileStorage struct {
-	file string
+	file      string
+	snapshots int
+
+	// mu serializes the replacements of the file
+	mu sync.Mutex
 }
 
 func NewFileStorage(config config.Storage) (Storage, error) {
 	storage := &FileStorage{
-		file: config.File,
+		file:      config.File,
+		snapshots: config.Snapshots,
 	}
 
 	if storage.Enabled() {
-		file, err := os.OpenFile(config.File, os.O_RDWR|os.O_CREATE, 0755)
-
-		if err != nil {
-			return nil, err
+		_, err := os.Stat(config.File)
+		if errors.Is(err, os.ErrNotExist) {
+			// Initialize file to an empty JSON
+			err = storage.write([]byte("{}"))
 		}
-		defer file.Close()
-
-		stats, err := file.Stat()
 		if err != nil {
 			return nil, err
 		}
 
-		// Initialize file to an empty JSON3
-		if stats.Size() == 0 {
-			file.WriteString("{}")
-		}
-
 		log.Infof("initialized storage to %s", config.File)
 	} else {
 		log.Warn("no storage configuration provided. all states will be lost upon exit")
@@ -50,18 +78,147 @@ func NewFileStorage(config config.Storage) (Storage, error) {
 	return storage, nil
 }
 
+// Reader reads the file, or its newest valid snapshot if the file is corrupt
 func (fs *FileStorage) Reader() (io.ReadCloser, error) {
 	if !fs.Enabled() {
 		return nil, fmt.Errorf("file storage is not enabled")
 	}
-	return os.OpenFile(fs.file, os.O_RDWR|os.O_CREATE, 0755)
+
+	for i := 0; i <= fs.snapshots; i++ {
+		path := fs.snapshot(i)
+		b, err := os.ReadFile(path)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			log.Warnf("could not read %s: %v", path, err)
+			continue
+		}
+		if !json.Valid(b) {
+			log.Warnf("%s is corrupt, it is skipped", path)
+			continue
+		}
+
+		if i > 0 {
+			log.Warnf("%s is corrupt, loading the snapshot %s instead", fs.file, path)
+		}
+		return io.NopCloser(bytes.NewReader(b)), nil
+	}
+	return nil, fmt.Errorf("neither %s nor its snapshots are valid", fs.file)
 }
 
+// Writer writes to a temporary file which replaces the file when closed, so that
+// the file is never partially written
 func (fs *FileStorage) Writer() (io.WriteCloser, error) {
 	if !fs.Enabled() {
 		return nil, fmt.Errorf("file storage is not enabled")
 	}
-	return os.OpenFile(fs.file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
+
+	temp, err := os.CreateTemp(filepath.Dir(fs.file), filepath.Base(fs.file)+".tmp-*")
+	if err != nil {
+		return nil, err
+	}
+	return &atomicWriter{File: temp, storage: fs}, nil
+}
+
+func (fs *FileStorage) write(b []byte) error {
+	writer, err := fs.Writer()
+	if err != nil {
+		return err
+	}
+	_, err = writer.Write(b)
+	return Close(writer, err)
+}
+
+// snapshot returns the path of the i-th snapshot, the file itself for 0
+func (fs *FileStorage) snapshot(i int) string {
+	if i == 0 {
+		return fs.file
+	}
+	return fmt.Sprintf("%s.%d", fs.file, i)
+}
+
+// replace rotates the snapshots and replaces the file with the temporary file
+func (fs *FileStorage) replace(temp string) error {
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	for i := fs.snapshots; i > 0; i-- {
+		err := os.Rename(fs.snapshot(i-1), fs.snapshot(i))
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := os.Rename(temp, fs.file); err != nil {
+		return err
+	}
+	syncDir(filepath.Dir(fs.file))
+	return nil
+}
+
+// syncDir persists the renames of the directory entries. It is not supported by every
+// platform, the renames are then persisted by the operating system later on.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	defer d.Close()
+	d.Sync()
+}
+
+type atomicWriter struct {
+	*os.File
+	storage *FileStorage
+	// err is the first write error, the file is not replaced when set
+	err error
+}
+
+func (w *atomicWriter) Write(b []byte) (int, error) {
+	n, err := w.File.Write(b)
+	if err != nil && w.err == nil {
+		w.err = err
+	}
+	return n, err
+}
+
+// Abort removes the temporary file, the file is left unchanged
+func (w *atomicWriter) Abort() error {
+	defer os.Remove(w.Name())
+	return w.File.Close()
+}
+
+func (w *atomicWriter) Close() error {
+	if w.err != nil {
+		w.Abort()
+		return fmt.Errorf("%s is left unchanged, writing failed: %w", w.storage.file, w.err)
+	}
+
+	// Nothing to remove once renamed
+	defer os.Remove(w.Name())
+
+	if err := w.Sync(); err != nil {
+		w.File.Close()
+		return err
+	}
+	if err := w.File.Close(); err != nil {
+		return err
+	}
+	return w.storage.replace(w.Name())
+}
+
+// Namespace stores the data next to the sessions file, "sessions.json" becomes "sessions.<name>.json"
+func (fs *FileStorage) Namespace(name string) (Storage, error) {
+	if !fs.Enabled() {
+		return &FileStorage{}, nil
+	}
+
+	ext := filepath.Ext(fs.file)
+	return NewFileStorage(config.Storage{
+		File:      fmt.Sprintf("%s.%s%s", strings.TrimSuffix(fs.file, ext), name, ext),
+		Snapshots: fs.snapshots,
+	})
 }
 
 func (fs *FileStorage) Enabled() bool {
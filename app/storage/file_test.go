@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/acouvreur/sablier/config"
+	"gotest.tools/v3/assert"
+)
+
+func newTestFileStorage(t *testing.T, snapshots int) (Storage, string) {
+	file := filepath.Join(t.TempDir(), "sessions.json")
+	fs, err := NewFileStorage(config.Storage{File: file, Snapshots: snapshots})
+	assert.NilError(t, err)
+	return fs, file
+}
+
+func save(t *testing.T, s Storage, content string) {
+	writer, err := s.Writer()
+	assert.NilError(t, err)
+	_, err = writer.Write([]byte(content))
+	assert.NilError(t, err)
+	assert.NilError(t, writer.Close())
+}
+
+func load(t *testing.T, s Storage) string {
+	reader, err := s.Reader()
+	assert.NilError(t, err)
+	defer reader.Close()
+	b, err := io.ReadAll(reader)
+	assert.NilError(t, err)
+	return string(b)
+}
+
+func TestFileStorage_Snapshots(t *testing.T) {
+	fs, file := newTestFileStorage(t, 2)
+	assert.Equal(t, load(t, fs), "{}")
+
+	save(t, fs, `{"v":1}`)
+	save(t, fs, `{"v":2}`)
+	save(t, fs, `{"v":3}`)
+
+	assert.Equal(t, load(t, fs), `{"v":3}`)
+	b, _ := os.ReadFile(file + ".1")
+	assert.Equal(t, string(b), `{"v":2}`)
+	b, _ = os.ReadFile(file + ".2")
+	assert.Equal(t, string(b), `{"v":1}`)
+	_, err := os.Stat(file + ".3")
+	assert.Assert(t, os.IsNotExist(err))
+
+	// No temporary file is left behind
+	entries, _ := os.ReadDir(filepath.Dir(file))
+	assert.Equal(t, len(entries), 3)
+}
+
+func TestFileStorage_FallsBackToNewestValidSnapshot(t *testing.T) {
+	fs, file := newTestFileStorage(t, 2)
+	save(t, fs, `{"v":1}`)
+	save(t, fs, `{"v":2}`)
+
+	assert.NilError(t, os.WriteFile(file, []byte(`{"v":`), 0600))
+	assert.Equal(t, load(t, fs), `{"v":1}`)
+
+	assert.NilError(t, os.WriteFile(file+".1", nil, 0600))
+	assert.Equal(t, load(t, fs), "{}")
+
+	assert.NilError(t, os.WriteFile(file+".2", []byte("corrupt"), 0600))
+	_, err := fs.Reader()
+	assert.ErrorContains(t, err, "nor its snapshots are valid")
+}
+
+func TestFileStorage_CrashWhileWriting(t *testing.T) {
+	fs, file := newTestFileStorage(t, 0)
+	save(t, fs, `{"v":1}`)
+
+	// The writer is not closed, as on a crash
+	writer, err := fs.Writer()
+	assert.NilError(t, err)
+	writer.Write([]byte(`{"v":`))
+
+	assert.Equal(t, load(t, fs), `{"v":1}`)
+	b, _ := os.ReadFile(file)
+	assert.Equal(t, string(b), `{"v":1}`)
+}
+
+func TestFileStorage_NamespaceKeepsSnapshots(t *testing.T) {
+	fs, file := newTestFileStorage(t, 1)
+	ns, err := fs.Namespace("prewarm")
+	assert.NilError(t, err)
+
+	save(t, ns, `{"v":1}`)
+	save(t, ns, `{"v":2}`)
+
+	b, _ := os.ReadFile(filepath.Join(filepath.Dir(file), "sessions.prewarm.json.1"))
+	assert.Equal(t, string(b), `{"v":1}`)
+}
+
+type failingMarshaler struct{}
+
+func (failingMarshaler) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("encoding failed")
+}
+
+func TestFileStorage_FailingEncoderLeavesFileUnchanged(t *testing.T) {
+	fs, file := newTestFileStorage(t, 1)
+	save(t, fs, `{"v":1}`)
+
+	writer, err := fs.Writer()
+	assert.NilError(t, err)
+	_, err = writer.Write([]byte(`{"v":`))
+	assert.NilError(t, err)
+	err = Close(writer, json.NewEncoder(writer).Encode(failingMarshaler{}))
+	assert.ErrorContains(t, err, "encoding failed")
+
+	assert.Equal(t, load(t, fs), `{"v":1}`)
+	b, _ := os.ReadFile(file + ".1")
+	assert.Equal(t, string(b), "{}")
+	// The temporary file is removed
+	entries, _ := os.ReadDir(filepath.Dir(file))
+	assert.Equal(t, len(entries), 2)
+}
@@ -0,0 +1,207 @@
+package storage
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/acouvreur/sablier/pkg/tinykv"
+	log "github.com/sirupsen/logrus"
+)
+
+// PersistentKV is a tinykv.KV persisting each put and delete in a KVStorage.
+//
+// With a positive flush interval, the changes are not written on the path of the requests: they are
+// collected, a key changed several times being written once, and written together every flush interval
+// and on Stop. A crash loses the changes of the last flush interval.
+// Otherwise each change is written before Put or Delete returns.
+type PersistentKV[T any] struct {
+	tinykv.KV[T]
+	storage       KVStorage
+	onExpire      func(k string, v T)
+	flushInterval time.Duration
+
+	mu sync.Mutex
+	// dirty holds the records to write by key, nil for a deletion
+	dirty map[string][]byte
+	// flushMu serializes the flushes
+	flushMu  sync.Mutex
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+// persistedEntry is the format of a persisted entry, the same as the tinykv JSON format.
+// It is persisted in a versioned Record.
+type persistedEntry[T any] struct {
+	Value     T         `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// NewPersistentKV creates a tinykv.KV whose entries are persisted in the storage, onExpire is called
+// when an entry expires. The changes are written every flushInterval, or as they happen if it is not positive.
+// The entries which expired while Sablier was not running are returned by Restore.
+func NewPersistentKV[T any](storage KVStorage, resolution time.Duration, flushInterval time.Duration, onExpire func(k string, v T)) *PersistentKV[T] {
+	p := &PersistentKV[T]{
+		storage:       storage,
+		onExpire:      onExpire,
+		flushInterval: flushInterval,
+		dirty:         make(map[string][]byte),
+		stop:          make(chan struct{}),
+		done:          make(chan struct{}),
+	}
+	p.KV = tinykv.New(resolution, p.expired)
+	if flushInterval > 0 {
+		go p.flushEvery(flushInterval)
+	} else {
+		close(p.done)
+	}
+	return p
+}
+
+func (p *PersistentKV[T]) Put(k string, v T, expiresAfter time.Duration) error {
+	b, err := json.Marshal(persistedEntry[T]{Value: v, ExpiresAt: time.Now().Add(expiresAfter)})
+	if err != nil {
+		return err
+	}
+	b, err = WrapRecord(b)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	if err := p.KV.Put(k, v, expiresAfter); err != nil {
+		p.mu.Unlock()
+		return err
+	}
+	p.dirty[k] = b
+	p.mu.Unlock()
+
+	p.flushSync()
+	return nil
+}
+
+func (p *PersistentKV[T]) Delete(k string) {
+	p.mu.Lock()
+	p.KV.Delete(k)
+	p.dirty[k] = nil
+	p.mu.Unlock()
+
+	p.flushSync()
+}
+
+// Stop stops the store and writes the pending changes
+func (p *PersistentKV[T]) Stop() {
+	p.KV.Stop()
+	p.stopOnce.Do(func() {
+		close(p.stop)
+		<-p.done
+		p.Flush()
+	})
+}
+
+// flushSync writes the changes right away when they are not written every flush interval
+func (p *PersistentKV[T]) flushSync() {
+	if p.flushInterval <= 0 {
+		p.Flush()
+	}
+}
+
+func (p *PersistentKV[T]) flushEvery(interval time.Duration) {
+	defer close(p.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+			p.Flush()
+		}
+	}
+}
+
+// Flush writes the changes since the last flush in a single transaction. The changes
+// which could not be written are kept for the next flush.
+func (p *PersistentKV[T]) Flush() {
+	p.flushMu.Lock()
+	defer p.flushMu.Unlock()
+
+	p.mu.Lock()
+	dirty := p.dirty
+	p.dirty = make(map[string][]byte)
+	p.mu.Unlock()
+	if len(dirty) == 0 {
+		return
+	}
+
+	puts := make(map[string][]byte, len(dirty))
+	var deletes []string
+	for k, b := range dirty {
+		if b == nil {
+			deletes = append(deletes, k)
+		} else {
+			puts[k] = b
+		}
+	}
+	if err := p.storage.Write(puts, deletes); err != nil {
+		log.Errorf("could not persist the changes of %d sessions, they are retried: %v", len(dirty), err)
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		for k, b := range dirty {
+			// A newer change replaces the failed one
+			if _, changed := p.dirty[k]; !changed {
+				p.dirty[k] = b
+			}
+		}
+	}
+}
+
+// Restore loads the persisted entries of any version, they are migrated to the current format.
+// The entries which expired in the meantime are removed and returned, onExpire is not called
+// for them as the instances are stopped by the leader only.
+func (p *PersistentKV[T]) Restore() (map[string]T, error) {
+	records, err := p.storage.Entries()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := LoadRecords(records)
+	if err != nil {
+		return nil, err
+	}
+
+	expired := make(map[string]T)
+	now := time.Now()
+	for k, b := range entries {
+		var entry persistedEntry[T]
+		if err := json.Unmarshal(b, &entry); err != nil {
+			log.Warnf("ignoring the persisted session of %s: %v", k, err)
+			continue
+		}
+
+		if entry.ExpiresAt.After(now) {
+			p.KV.Put(k, entry.Value, entry.ExpiresAt.Sub(now))
+			continue
+		}
+
+		log.Infof("the session of %s expired while Sablier was not running", k)
+		if err := p.storage.Delete(k); err != nil {
+			log.Errorf("could not delete the persisted session of %s: %v", k, err)
+		}
+		expired[k] = entry.Value
+	}
+	return expired, nil
+}
+
+func (p *PersistentKV[T]) expired(k string, v T) {
+	// The entry may have been put again since it expired
+	p.mu.Lock()
+	if _, ok := p.KV.Get(k); !ok {
+		p.dirty[k] = nil
+	}
+	p.mu.Unlock()
+	p.flushSync()
+	if p.onExpire != nil {
+		p.onExpire(k, v)
+	}
+}
@@ -0,0 +1,169 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+type expirations struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (e *expirations) onExpire(k string, _ string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys = append(e.keys, k)
+}
+
+func (e *expirations) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.keys...)
+}
+
+func TestPersistentKV(t *testing.T) {
+	bs, _ := newTestBoltStorage(t)
+	expired := &expirations{}
+	kv := NewPersistentKV(bs, 10*time.Millisecond, time.Minute, expired.onExpire)
+	defer kv.Stop()
+
+	assert.NilError(t, kv.Put("nginx", "ready", time.Hour))
+	assert.NilError(t, kv.Put("apache", "ready", time.Hour))
+	kv.Delete("apache")
+	assert.NilError(t, kv.Put("whoami", "ready", 20*time.Millisecond))
+
+	// The changes are written on flush
+	entries, err := bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+	kv.Flush()
+
+	entries, err = bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 2)
+	record, err := UnwrapRecord(entries["nginx"])
+	assert.NilError(t, err)
+	assert.Equal(t, record.Version, SchemaVersion)
+	var entry persistedEntry[string]
+	assert.NilError(t, json.Unmarshal(record.Session, &entry))
+	assert.Equal(t, entry.Value, "ready")
+
+	// The expired entries are removed from the storage
+	deadline := time.Now().Add(time.Second)
+	for len(expired.get()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.DeepEqual(t, expired.get(), []string{"whoami"})
+	kv.Flush()
+	entries, err = bs.Entries()
+	assert.NilError(t, err)
+	_, ok := entries["whoami"]
+	assert.Assert(t, !ok)
+}
+
+func TestPersistentKV_Restore(t *testing.T) {
+	bs, _ := newTestBoltStorage(t)
+	put := func(k string, expiresAt time.Time) {
+		b, _ := json.Marshal(persistedEntry[string]{Value: "ready", ExpiresAt: expiresAt})
+		assert.NilError(t, bs.Put(k, b))
+	}
+	put("nginx", time.Now().Add(time.Hour))
+	put("apache", time.Now().Add(-time.Hour))
+	// A record in the current format
+	b, _ := json.Marshal(persistedEntry[string]{Value: "ready", ExpiresAt: time.Now().Add(time.Hour)})
+	b, _ = WrapRecord(b)
+	assert.NilError(t, bs.Put("whoami", b))
+	assert.NilError(t, bs.Put("invalid", []byte("{")))
+
+	expired := &expirations{}
+	kv := NewPersistentKV(bs, time.Minute, time.Minute, expired.onExpire)
+	defer kv.Stop()
+
+	expiredWhileDown, err := kv.Restore()
+	assert.NilError(t, err)
+
+	value, ok := kv.Get("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, value, "ready")
+	entry, _ := kv.GetEntry("nginx")
+	assert.Assert(t, time.Until(entry.ExpiresAt()) > 59*time.Minute)
+	value, ok = kv.Get("whoami")
+	assert.Assert(t, ok)
+	assert.Equal(t, value, "ready")
+
+	// The sessions which expired while Sablier was not running are returned instead of notified
+	assert.DeepEqual(t, expiredWhileDown, map[string]string{"apache": "ready"})
+	assert.Equal(t, len(expired.get()), 0)
+	entries, err := bs.Entries()
+	assert.NilError(t, err)
+	_, ok = entries["apache"]
+	assert.Assert(t, !ok)
+}
+
+func TestPersistentKV_StopWritesPendingChanges(t *testing.T) {
+	bs, _ := newTestBoltStorage(t)
+	kv := NewPersistentKV[string](bs, time.Minute, time.Minute, nil)
+
+	assert.NilError(t, kv.Put("nginx", "ready", time.Hour))
+	assert.NilError(t, kv.Put("nginx", "starting", time.Hour))
+	kv.Stop()
+	kv.Stop()
+
+	entries, err := bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+	record, err := UnwrapRecord(entries["nginx"])
+	assert.NilError(t, err)
+	var entry persistedEntry[string]
+	assert.NilError(t, json.Unmarshal(record.Session, &entry))
+	assert.Equal(t, entry.Value, "starting")
+}
+
+func TestPersistentKV_SynchronousWrites(t *testing.T) {
+	bs, _ := newTestBoltStorage(t)
+	kv := NewPersistentKV[string](bs, time.Minute, 0, nil)
+	defer kv.Stop()
+
+	// Each change is written before it returns
+	assert.NilError(t, kv.Put("nginx", "ready", time.Hour))
+	entries, err := bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 1)
+
+	kv.Delete("nginx")
+	entries, err = bs.Entries()
+	assert.NilError(t, err)
+	assert.Equal(t, len(entries), 0)
+}
+
+// BenchmarkPersistentKV_Put compares writing each change to bbolt with the PersistentKV,
+// which only records the change on the path of the request.
+func BenchmarkPersistentKV_Put(b *testing.B) {
+	b.Run("bbolt", func(b *testing.B) {
+		bs, _ := newTestBoltStorage(b)
+		record := []byte(`{"version":2,"session":{"value":"ready"}}`)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := bs.Put(fmt.Sprintf("instance-%d", i%100), record); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+	b.Run("persistent", func(b *testing.B) {
+		bs, _ := newTestBoltStorage(b)
+		kv := NewPersistentKV[string](bs, time.Minute, time.Minute, nil)
+		defer kv.Stop()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			if err := kv.Put(fmt.Sprintf("instance-%d", i%100), "ready", time.Hour); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
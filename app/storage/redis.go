@@ -0,0 +1,78 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/acouvreur/sablier/config"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// RedisStorage keeps the data in a Redis server shared by several Sablier replicas.
+// The sessions are not written through the storage but stored with their TTL by a rediskv store
+// using the same client, see Client.
+type RedisStorage struct {
+	client *redis.Client
+	prefix string
+	key    string
+}
+
+func NewRedisStorage(conf config.Storage) (*RedisStorage, error) {
+	client := redis.NewClient(&redis.Options{
+		Addr:     conf.Redis.Address,
+		Password: conf.Redis.Password,
+		DB:       conf.Redis.DB,
+	})
+
+	if err := client.Ping(context.Background()).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("could not connect to redis at %s: %w", conf.Redis.Address, err)
+	}
+
+	log.Infof("initialized redis storage to %s", conf.Redis.Address)
+	return &RedisStorage{client: client, prefix: conf.Redis.Prefix, key: conf.Redis.Prefix + "data"}, nil
+}
+
+// Client returns the client connected to the Redis server
+func (rs *RedisStorage) Client() *redis.Client {
+	return rs.client
+}
+
+// Prefix returns the prefix of all the keys
+func (rs *RedisStorage) Prefix() string {
+	return rs.prefix
+}
+
+func (rs *RedisStorage) Reader() (io.ReadCloser, error) {
+	value, err := rs.client.Get(context.Background(), rs.key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		// Same as a new file storage
+		value = []byte("{}")
+	} else if err != nil {
+		return nil, err
+	}
+	return io.NopCloser(bytes.NewReader(value)), nil
+}
+
+func (rs *RedisStorage) Writer() (io.WriteCloser, error) {
+	return &bufferedWriter{commit: func(b []byte) error {
+		return rs.client.Set(context.Background(), rs.key, b, 0).Err()
+	}}, nil
+}
+
+// Namespace stores the data under the key "<prefix>data:<name>"
+func (rs *RedisStorage) Namespace(name string) (Storage, error) {
+	return &RedisStorage{client: rs.client, prefix: rs.prefix, key: rs.key + ":" + name}, nil
+}
+
+func (rs *RedisStorage) Enabled() bool {
+	return true
+}
+
+func (rs *RedisStorage) Close() error {
+	return rs.client.Close()
+}
@@ -0,0 +1,46 @@
+package storage
+
+import (
+	"io"
+	"testing"
+
+	"github.com/acouvreur/sablier/config"
+	"github.com/alicebob/miniredis/v2"
+	"gotest.tools/v3/assert"
+)
+
+func TestRedisStorage_Namespace(t *testing.T) {
+	server := miniredis.RunT(t)
+	s, err := New(config.Storage{Backend: "redis", Redis: config.Redis{Address: server.Addr(), Prefix: "sablier:"}})
+	assert.NilError(t, err)
+	rs := s.(*RedisStorage)
+	t.Cleanup(func() { rs.Close() })
+
+	ns, err := rs.Namespace("prewarm")
+	assert.NilError(t, err)
+
+	reader, err := ns.Reader()
+	assert.NilError(t, err)
+	b, _ := io.ReadAll(reader)
+	assert.Equal(t, string(b), "{}")
+
+	writer, err := ns.Writer()
+	assert.NilError(t, err)
+	writer.Write([]byte(`{"nginx":[]}`))
+	assert.NilError(t, writer.Close())
+
+	reader, err = ns.Reader()
+	assert.NilError(t, err)
+	b, _ = io.ReadAll(reader)
+	assert.Equal(t, string(b), `{"nginx":[]}`)
+	assert.Assert(t, server.Exists("sablier:data:prewarm"))
+}
+
+func TestNewRedisStorage_Unreachable(t *testing.T) {
+	server := miniredis.RunT(t)
+	addr := server.Addr()
+	server.Close()
+
+	_, err := New(config.Storage{Backend: "redis", Redis: config.Redis{Address: addr}})
+	assert.ErrorContains(t, err, "could not connect to redis")
+}
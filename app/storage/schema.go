@@ -0,0 +1,170 @@
+package storage
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+)
+
+// SchemaVersion is the version of the format of the persisted sessions.
+//
+// Version 1 is the sessions written before the envelope, as a map of the tinykv entries:
+// {"<name>": {"value": <instance.State>, "expiresAt": "<time>"}}.
+// Version 2 wraps the same map in the envelope.
+const SchemaVersion = 2
+
+// Envelope wraps the persisted sessions with the version of their format
+type Envelope struct {
+	Version  int             `json:"version"`
+	Sessions json.RawMessage `json:"sessions"`
+}
+
+// Migration upgrades the sessions from the previous version of the format
+type Migration func(sessions json.RawMessage) (json.RawMessage, error)
+
+// migrations holds the migration to each version from the previous one.
+// Changing the format of the sessions requires a new version and its migration.
+var migrations = map[int]Migration{
+	// The sessions are unchanged, only wrapped in the envelope
+	2: func(sessions json.RawMessage) (json.RawMessage, error) { return sessions, nil },
+}
+
+// Wrap returns the envelope of the sessions in the current format
+func Wrap(sessions json.RawMessage) Envelope {
+	return Envelope{Version: SchemaVersion, Sessions: sessions}
+}
+
+// Unwrap returns the envelope of the persisted sessions, the sessions written before the envelope are version 1
+func Unwrap(b []byte) (Envelope, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return Envelope{}, err
+	}
+
+	// A version 1 session could be named "version", but it is an object instead of a number
+	version, versioned := fields["version"]
+	sessions, wrapped := fields["sessions"]
+	if !versioned || !wrapped || len(version) == 0 || bytes.ContainsAny(version[:1], "{[\"") {
+		return Envelope{Version: 1, Sessions: b}, nil
+	}
+
+	var e Envelope
+	if err := json.Unmarshal(version, &e.Version); err != nil {
+		return Envelope{}, fmt.Errorf("invalid version %s: %w", version, err)
+	}
+	e.Sessions = sessions
+	return e, nil
+}
+
+// Migrate upgrades the sessions to the current format
+func Migrate(e Envelope) (Envelope, error) {
+	return migrate(e, migrations, SchemaVersion)
+}
+
+func migrate(e Envelope, migrations map[int]Migration, to int) (Envelope, error) {
+	if e.Version > to {
+		return e, fmt.Errorf("the sessions version %d was written by a newer Sablier, the latest version supported is %d", e.Version, to)
+	}
+	if e.Version < 1 {
+		return e, fmt.Errorf("invalid sessions version %d", e.Version)
+	}
+
+	for e.Version < to {
+		migration, ok := migrations[e.Version+1]
+		if !ok {
+			return e, fmt.Errorf("no migration of the sessions from version %d to %d", e.Version, e.Version+1)
+		}
+		sessions, err := migration(e.Sessions)
+		if err != nil {
+			return e, fmt.Errorf("could not migrate the sessions from version %d to %d: %w", e.Version, e.Version+1, err)
+		}
+		e = Envelope{Version: e.Version + 1, Sessions: sessions}
+	}
+	return e, nil
+}
+
+// Record wraps a single session with the version of its format, it is written by the backends
+// persisting each session on its own. The records written before the version was introduced are version 1.
+type Record struct {
+	Version int             `json:"version"`
+	Session json.RawMessage `json:"session"`
+}
+
+// WrapRecord returns the record of the session in the current format
+func WrapRecord(session json.RawMessage) ([]byte, error) {
+	return json.Marshal(Record{Version: SchemaVersion, Session: session})
+}
+
+// UnwrapRecord returns the record of a persisted session
+func UnwrapRecord(b []byte) (Record, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return Record{}, err
+	}
+
+	version, versioned := fields["version"]
+	session, wrapped := fields["session"]
+	if !versioned || !wrapped {
+		return Record{Version: 1, Session: b}, nil
+	}
+
+	var r Record
+	if err := json.Unmarshal(version, &r.Version); err != nil {
+		return Record{}, fmt.Errorf("invalid version %s: %w", version, err)
+	}
+	r.Session = session
+	return r, nil
+}
+
+// LoadRecords reads the persisted records in any version and returns the sessions in the current format.
+// The invalid records are skipped. The records of each version are migrated together, with the
+// migrations of the sessions map.
+func LoadRecords(records map[string][]byte) (map[string]json.RawMessage, error) {
+	versions := make(map[int]map[string]json.RawMessage)
+	for key, b := range records {
+		r, err := UnwrapRecord(b)
+		if err != nil {
+			log.Warnf("ignoring the persisted session of %s: %v", key, err)
+			continue
+		}
+		if versions[r.Version] == nil {
+			versions[r.Version] = make(map[string]json.RawMessage)
+		}
+		versions[r.Version][key] = r.Session
+	}
+
+	loaded := make(map[string]json.RawMessage, len(records))
+	for version, sessions := range versions {
+		b, err := json.Marshal(sessions)
+		if err != nil {
+			return nil, err
+		}
+		e, err := Migrate(Envelope{Version: version, Sessions: b})
+		if err != nil {
+			return nil, err
+		}
+		var migrated map[string]json.RawMessage
+		if err := json.Unmarshal(e.Sessions, &migrated); err != nil {
+			return nil, err
+		}
+		for key, session := range migrated {
+			loaded[key] = session
+		}
+	}
+	return loaded, nil
+}
+
+// Load reads the persisted sessions in any version and returns them in the current format
+func Load(b []byte) (json.RawMessage, error) {
+	e, err := Unwrap(b)
+	if err != nil {
+		return nil, err
+	}
+	e, err = Migrate(e)
+	if err != nil {
+		return nil, err
+	}
+	return e.Sessions, nil
+}
@@ -0,0 +1,112 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestUnwrap(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		version int
+		want    string
+	}{
+		{
+			name:    "sessions written before the envelope",
+			data:    `{"nginx":{"value":{"name":"nginx"},"expiresAt":"2024-01-01T00:00:00Z"}}`,
+			version: 1,
+			want:    `{"nginx":{"value":{"name":"nginx"},"expiresAt":"2024-01-01T00:00:00Z"}}`,
+		},
+		{
+			name:    "no sessions written before the envelope",
+			data:    `{}`,
+			version: 1,
+			want:    `{}`,
+		},
+		{
+			name:    "sessions named version and sessions written before the envelope",
+			data:    `{"version":{"value":{}},"sessions":{"value":{}}}`,
+			version: 1,
+			want:    `{"version":{"value":{}},"sessions":{"value":{}}}`,
+		},
+		{
+			name:    "envelope",
+			data:    `{"version":2,"sessions":{"nginx":{}}}`,
+			version: 2,
+			want:    `{"nginx":{}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, err := Unwrap([]byte(tt.data))
+			assert.NilError(t, err)
+			assert.Equal(t, e.Version, tt.version)
+			assert.Equal(t, string(e.Sessions), tt.want)
+		})
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	registry := map[int]Migration{
+		2: func(sessions json.RawMessage) (json.RawMessage, error) {
+			return json.RawMessage(`{"v2":` + string(sessions) + `}`), nil
+		},
+		3: func(sessions json.RawMessage) (json.RawMessage, error) {
+			return json.RawMessage(`{"v3":` + string(sessions) + `}`), nil
+		},
+	}
+
+	e, err := migrate(Envelope{Version: 1, Sessions: json.RawMessage(`{}`)}, registry, 3)
+	assert.NilError(t, err)
+	assert.Equal(t, e.Version, 3)
+	assert.Equal(t, string(e.Sessions), `{"v3":{"v2":{}}}`)
+
+	e, err = migrate(Envelope{Version: 2, Sessions: json.RawMessage(`{}`)}, registry, 3)
+	assert.NilError(t, err)
+	assert.Equal(t, string(e.Sessions), `{"v3":{}}`)
+
+	_, err = migrate(Envelope{Version: 4, Sessions: json.RawMessage(`{}`)}, registry, 3)
+	assert.ErrorContains(t, err, "written by a newer Sablier")
+
+	_, err = migrate(Envelope{Version: 1, Sessions: json.RawMessage(`{}`)}, map[int]Migration{}, 3)
+	assert.ErrorContains(t, err, "no migration of the sessions from version 1 to 2")
+
+	registry[3] = func(json.RawMessage) (json.RawMessage, error) { return nil, errors.New("boom") }
+	_, err = migrate(Envelope{Version: 1, Sessions: json.RawMessage(`{}`)}, registry, 3)
+	assert.ErrorContains(t, err, "from version 2 to 3: boom")
+}
+
+func TestMigrations(t *testing.T) {
+	// Every version can be migrated to the current one
+	for version := 2; version <= SchemaVersion; version++ {
+		_, ok := migrations[version]
+		assert.Assert(t, ok, "no migration to version %d", version)
+	}
+
+	sessions, err := Load([]byte(`{"nginx":{"value":{"name":"nginx"},"expiresAt":"2024-01-01T00:00:00Z"}}`))
+	assert.NilError(t, err)
+	assert.Equal(t, string(sessions), `{"nginx":{"value":{"name":"nginx"},"expiresAt":"2024-01-01T00:00:00Z"}}`)
+}
+
+func TestLoadRecords(t *testing.T) {
+	legacy := []byte(`{"value":{"name":"nginx"},"expiresAt":"2024-01-01T00:00:00Z"}`)
+	current, err := WrapRecord([]byte(`{"value":{"name":"apache"},"expiresAt":"2024-01-01T00:00:00Z"}`))
+	assert.NilError(t, err)
+
+	sessions, err := LoadRecords(map[string][]byte{
+		"nginx":   legacy,
+		"apache":  current,
+		"invalid": []byte("{"),
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(sessions), 2)
+	assert.Equal(t, string(sessions["nginx"]), string(legacy))
+	assert.Equal(t, string(sessions["apache"]), `{"value":{"name":"apache"},"expiresAt":"2024-01-01T00:00:00Z"}`)
+
+	_, err = LoadRecords(map[string][]byte{"nginx": []byte(`{"version":99,"session":{}}`)})
+	assert.ErrorContains(t, err, "written by a newer Sablier")
+}
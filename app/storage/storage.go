@@ -0,0 +1,36 @@
+package storage
+
+import (
+	"fmt"
+
+	"github.com/acouvreur/sablier/config"
+)
+
+// KVStorage is implemented by the backends able to persist each session as it changes,
+// instead of all of them at once on shutdown.
+type KVStorage interface {
+	Storage
+
+	Put(key string, value []byte) error
+	Delete(key string) error
+	// Write puts and deletes several entries in a single transaction
+	Write(puts map[string][]byte, deletes []string) error
+	// Entries returns all the persisted entries
+	Entries() (map[string][]byte, error)
+	Close() error
+}
+
+var backends = []string{"file", "bbolt", "redis"}
+
+// New returns the storage backend selected by the configuration
+func New(conf config.Storage) (Storage, error) {
+	switch conf.Backend {
+	case "", "file":
+		return NewFileStorage(conf)
+	case "bbolt":
+		return NewBoltStorage(conf)
+	case "redis":
+		return NewRedisStorage(conf)
+	}
+	return nil, fmt.Errorf("unknown storage backend %s, must be one of %v", conf.Backend, backends)
+}
@@ -3,6 +3,7 @@ package theme
 import (
 	"fmt"
 	"io"
+	"time"
 
 	"github.com/acouvreur/sablier/pkg/durations"
 	"github.com/acouvreur/sablier/version"
@@ -17,11 +18,27 @@ func (t *Themes) Render(name string, opts Options, writer io.Writer) error {
 		instances = []Instance{}
 	}
 
+	var hardStop string
+	if !opts.HardStopAt.IsZero() {
+		hardStop = durations.Humanize(max(time.Until(opts.HardStopAt), time.Second))
+	}
+
+	var expectedReadyIn string
+	var progress int
+	if !opts.ExpectedReadyAt.IsZero() {
+		expectedReadyIn = durations.Humanize(max(time.Until(opts.ExpectedReadyAt).Round(time.Second), time.Second))
+		progress = startProgress(opts.StartedAt, opts.ExpectedReadyAt)
+	}
+
 	options := templateOptions{
 		DisplayName:      opts.DisplayName,
 		InstanceStates:   instances,
 		SessionDuration:  durations.Humanize(opts.SessionDuration),
 		RefreshFrequency: fmt.Sprintf("%d", int64(opts.RefreshFrequency.Seconds())),
+		HardStop:         hardStop,
+		ProviderDegraded: opts.ProviderDegraded,
+		ExpectedReadyIn:  expectedReadyIn,
+		Progress:         progress,
 		Version:          version.Version,
 	}
 
@@ -32,3 +49,12 @@ func (t *Themes) Render(name string, opts Options, writer io.Writer) error {
 
 	return tpl.Execute(writer, options)
 }
+
+// startProgress returns the percentage of the usual start duration elapsed, 99 at most until the instances are ready
+func startProgress(startedAt time.Time, expectedReadyAt time.Time) int {
+	total := expectedReadyAt.Sub(startedAt)
+	if startedAt.IsZero() || total <= 0 {
+		return 0
+	}
+	return min(max(int(100*time.Since(startedAt)/total), 0), 99)
+}
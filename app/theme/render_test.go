@@ -227,3 +227,46 @@ func ExampleThemes_Render() {
 	//	</body>
 	//</html>
 }
+
+func TestThemes_RenderExpectedReady(t *testing.T) {
+	themes, err := theme.NewWithCustomThemes(fstest.MapFS{
+		"progress.html": &fstest.MapFile{Data: []byte(`{{ if .ExpectedReadyIn }}ready in ~{{ .ExpectedReadyIn }} ({{ .Progress }}%){{ end }}`)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	now := time.Now()
+	tests := []struct {
+		name string
+		opts theme.Options
+		want string
+	}{
+		{
+			name: "unknown start latency",
+			opts: theme.Options{},
+			want: "",
+		},
+		{
+			name: "starting",
+			opts: theme.Options{StartedAt: now.Add(-10 * time.Second), ExpectedReadyAt: now.Add(30*time.Second + 400*time.Millisecond)},
+			want: "ready in ~30 seconds (24%)",
+		},
+		{
+			name: "late",
+			opts: theme.Options{StartedAt: now.Add(-time.Minute), ExpectedReadyAt: now.Add(-30 * time.Second)},
+			want: "ready in ~1 second (99%)",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			writer := &bytes.Buffer{}
+			if err := themes.Render("progress", tt.opts, writer); err != nil {
+				t.Fatal(err)
+			}
+			if writer.String() != tt.want {
+				t.Errorf("Themes.Render() = %q, want %q", writer.String(), tt.want)
+			}
+		})
+	}
+}
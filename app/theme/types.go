@@ -24,6 +24,14 @@ type Options struct {
 	InstanceStates   []Instance
 	SessionDuration  time.Duration
 	RefreshFrequency time.Duration
+	// HardStopAt is when the instances will be stopped regardless of their activity, zero if never
+	HardStopAt time.Time
+	// ProviderDegraded is true when the provider is failing and the instances might take longer to start
+	ProviderDegraded bool
+	// StartedAt is when the instances started, ExpectedReadyAt when they usually are ready.
+	// Both are zero when the start latency is unknown.
+	StartedAt       time.Time
+	ExpectedReadyAt time.Time
 }
 
 // templateOptions holds the internal options used to template
@@ -32,5 +40,9 @@ type templateOptions struct {
 	InstanceStates   []Instance
 	SessionDuration  string
 	RefreshFrequency string
+	HardStop         string
+	ProviderDegraded bool
+	ExpectedReadyIn  string
+	Progress         int
 	Version          string
 }
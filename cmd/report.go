@@ -0,0 +1,48 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/spf13/cobra"
+)
+
+var newReportCommand = func() *cobra.Command {
+	return &cobra.Command{
+		Use:   "report",
+		Short: "Prints the savings report of a Sablier instance",
+		Long: `Prints the uptime avoided per instance over a window, and the savings computed
+from the sablier.cost label of the instances.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return report(cmd.OutOrStdout(), cmd.Flag("url").Value.String(), cmd.Flag("window").Value.String(), cmd.Flag("format").Value.String())
+		},
+	}
+}
+
+// report fetches the report from the API and writes it unchanged
+func report(out io.Writer, endpoint string, window string, format string) error {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return err
+	}
+	query := u.Query()
+	query.Set("window", window)
+	query.Set("format", format)
+	u.RawQuery = query.Encode()
+
+	resp, err := http.Get(u.String())
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, body)
+	}
+
+	_, err = io.Copy(out, resp.Body)
+	return err
+}
@@ -0,0 +1,40 @@
+package cmd
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"gotest.tools/v3/assert"
+)
+
+func TestReportCommand(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, r.URL.Path, "/api/report")
+		assert.Equal(t, r.URL.Query().Get("window"), "24h0m0s")
+		assert.Equal(t, r.URL.Query().Get("format"), "csv")
+		w.Write([]byte("instance\nnginx\n"))
+	}))
+	defer server.Close()
+
+	cmd := NewRootCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"report", "--url", server.URL + "/api/report", "--window", "24h", "--format", "csv"})
+
+	assert.NilError(t, cmd.Execute())
+	assert.Equal(t, out.String(), "instance\nnginx\n")
+}
+
+func TestReportCommandError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(`{"error":"unknown format"}`))
+	}))
+	defer server.Close()
+
+	err := report(&bytes.Buffer{}, server.URL, "24h", "xml")
+
+	assert.ErrorContains(t, err, "400 Bad Request")
+}
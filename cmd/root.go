@@ -54,19 +54,87 @@ It provides an integrations with multiple reverse proxies and different loading
 	viper.BindPFlag("provider.kubernetes.burst", startCmd.Flags().Lookup("provider.kubernetes.burst"))
 	startCmd.Flags().StringVar(&conf.Provider.Kubernetes.Delimiter, "provider.kubernetes.delimiter", "_", "Delimiter used for namespace/resource type/name resolution. Defaults to \"_\" for backward compatibility. But you should use \"/\" or \".\"")
 	viper.BindPFlag("provider.kubernetes.delimiter", startCmd.Flags().Lookup("provider.kubernetes.delimiter"))
+	startCmd.Flags().IntVar(&conf.Provider.Retry.MaxRetries, "provider.retry.max-retries", 3, "The number of retries of a provider call failing with a transient error")
+	viper.BindPFlag("provider.retry.max-retries", startCmd.Flags().Lookup("provider.retry.max-retries"))
+	startCmd.Flags().DurationVar(&conf.Provider.Retry.Backoff, "provider.retry.backoff", 200*time.Millisecond, "The delay before the first retry of a provider call, doubled after each retry")
+	viper.BindPFlag("provider.retry.backoff", startCmd.Flags().Lookup("provider.retry.backoff"))
+	startCmd.Flags().DurationVar(&conf.Provider.Retry.MaxBackoff, "provider.retry.max-backoff", 2*time.Second, "The maximum delay between two retries of a provider call")
+	viper.BindPFlag("provider.retry.max-backoff", startCmd.Flags().Lookup("provider.retry.max-backoff"))
+	startCmd.Flags().IntVar(&conf.Provider.CircuitBreaker.Threshold, "provider.circuit-breaker.threshold", 5, "The number of consecutive transient provider errors marking the provider as degraded. Zero disables it.")
+	viper.BindPFlag("provider.circuit-breaker.threshold", startCmd.Flags().Lookup("provider.circuit-breaker.threshold"))
+	startCmd.Flags().DurationVar(&conf.Provider.CircuitBreaker.OpenDuration, "provider.circuit-breaker.open-duration", 30*time.Second, "The time the provider calls fail fast once the provider is degraded, before a call is tried again")
+	viper.BindPFlag("provider.circuit-breaker.open-duration", startCmd.Flags().Lookup("provider.circuit-breaker.open-duration"))
+	startCmd.Flags().DurationVar(&conf.Provider.Orphans.Interval, "provider.orphans.interval", 0, "The interval between two checks of the enabled instances running without a session. Zero only checks them on startup, when provider.auto-stop-on-startup is set.")
+	viper.BindPFlag("provider.orphans.interval", startCmd.Flags().Lookup("provider.orphans.interval"))
+	startCmd.Flags().DurationVar(&conf.Provider.Orphans.GracePeriod, "provider.orphans.grace-period", 5*time.Minute, "The time an instance must run without a session before it is handled as an orphan by the periodic checks")
+	viper.BindPFlag("provider.orphans.grace-period", startCmd.Flags().Lookup("provider.orphans.grace-period"))
+	startCmd.Flags().StringVar(&conf.Provider.Orphans.Policy, "provider.orphans.policy", "stop", "The action taken on the orphans [stop adopt]")
+	viper.BindPFlag("provider.orphans.policy", startCmd.Flags().Lookup("provider.orphans.policy"))
+	startCmd.Flags().BoolVar(&conf.Provider.Orphans.DryRun, "provider.orphans.dry-run", false, "Only log and emit the actions taken on the orphans")
+	viper.BindPFlag("provider.orphans.dry-run", startCmd.Flags().Lookup("provider.orphans.dry-run"))
 	// Server flags
 	startCmd.Flags().IntVar(&conf.Server.Port, "server.port", 10000, "The server port to use")
 	viper.BindPFlag("server.port", startCmd.Flags().Lookup("server.port"))
 	startCmd.Flags().StringVar(&conf.Server.BasePath, "server.base-path", "/", "The base path for the API")
 	viper.BindPFlag("server.base-path", startCmd.Flags().Lookup("server.base-path"))
+	startCmd.Flags().StringVar(&conf.Server.SessionsControlToken, "server.sessions-control-token", "", "The bearer token required by the endpoints extending, stopping and pinning the sessions, these endpoints are disabled without it")
+	viper.BindPFlag("server.sessions-control-token", startCmd.Flags().Lookup("server.sessions-control-token"))
 	// Storage flags
+	startCmd.Flags().StringVar(&conf.Storage.Backend, "storage.backend", "file", "The storage backend [file bbolt redis]")
+	viper.BindPFlag("storage.backend", startCmd.Flags().Lookup("storage.backend"))
 	startCmd.Flags().StringVar(&conf.Storage.File, "storage.file", "", "File path to save the state")
 	viper.BindPFlag("storage.file", startCmd.Flags().Lookup("storage.file"))
+	startCmd.Flags().DurationVar(&conf.Storage.SnapshotInterval, "storage.snapshot-interval", time.Minute, "The interval between two saves of the state, zero only saves it on exit")
+	viper.BindPFlag("storage.snapshot-interval", startCmd.Flags().Lookup("storage.snapshot-interval"))
+	startCmd.Flags().IntVar(&conf.Storage.Snapshots, "storage.snapshots", 3, "The number of previous versions of the file storage kept, the newest valid one is loaded if the file is corrupt")
+	viper.BindPFlag("storage.snapshots", startCmd.Flags().Lookup("storage.snapshots"))
+	startCmd.Flags().DurationVar(&conf.Storage.FlushInterval, "storage.flush-interval", time.Second, "The interval between two writes of the changed sessions with the bbolt storage backend, zero writes each change as it happens")
+	viper.BindPFlag("storage.flush-interval", startCmd.Flags().Lookup("storage.flush-interval"))
+	startCmd.Flags().StringVar(&conf.Storage.Redis.Address, "storage.redis.address", "localhost:6379", "The address of the Redis server used by the redis storage backend")
+	viper.BindPFlag("storage.redis.address", startCmd.Flags().Lookup("storage.redis.address"))
+	startCmd.Flags().StringVar(&conf.Storage.Redis.Password, "storage.redis.password", "", "The password of the Redis server")
+	viper.BindPFlag("storage.redis.password", startCmd.Flags().Lookup("storage.redis.password"))
+	startCmd.Flags().IntVar(&conf.Storage.Redis.DB, "storage.redis.db", 0, "The Redis database number")
+	viper.BindPFlag("storage.redis.db", startCmd.Flags().Lookup("storage.redis.db"))
+	startCmd.Flags().StringVar(&conf.Storage.Redis.Prefix, "storage.redis.prefix", "sablier:", "The prefix of the Redis keys, replicas sharing the sessions must use the same prefix")
+	viper.BindPFlag("storage.redis.prefix", startCmd.Flags().Lookup("storage.redis.prefix"))
 	// Sessions flags
 	startCmd.Flags().DurationVar(&conf.Sessions.DefaultDuration, "sessions.default-duration", time.Duration(5)*time.Minute, "The default session duration")
 	viper.BindPFlag("sessions.default-duration", startCmd.Flags().Lookup("sessions.default-duration"))
-	startCmd.Flags().DurationVar(&conf.Sessions.ExpirationInterval, "sessions.expiration-interval", time.Duration(20)*time.Second, "The expiration checking interval. Higher duration gives less stress on CPU. If you only use sessions of 1h, setting this to 5m is a good trade-off.")
+	startCmd.Flags().DurationVar(&conf.Sessions.ExpirationInterval, "sessions.expiration-interval", time.Duration(20)*time.Second, "The interval between two checks of the expired sessions with the redis storage backend. The other backends stop the sessions within 100ms of their expiration.")
 	viper.BindPFlag("sessions.expiration-interval", startCmd.Flags().Lookup("sessions.expiration-interval"))
+	startCmd.Flags().DurationVar(&conf.Sessions.ReadinessPollInterval, "sessions.readiness-poll-interval", 500*time.Millisecond, "The first interval between two readiness checks of the blocking strategy. It doubles after each check. Providers notifying readiness events skip the wait.")
+	viper.BindPFlag("sessions.readiness-poll-interval", startCmd.Flags().Lookup("sessions.readiness-poll-interval"))
+	startCmd.Flags().DurationVar(&conf.Sessions.ReadinessMaxPollInterval, "sessions.readiness-max-poll-interval", 5*time.Second, "The maximum interval between two readiness checks of the blocking strategy")
+	viper.BindPFlag("sessions.readiness-max-poll-interval", startCmd.Flags().Lookup("sessions.readiness-max-poll-interval"))
+	startCmd.Flags().DurationVar(&conf.Sessions.GroupsResyncInterval, "sessions.groups-resync-interval", time.Minute, "The interval between two full listings of the groups. Groups are updated from the provider events in between.")
+	viper.BindPFlag("sessions.groups-resync-interval", startCmd.Flags().Lookup("sessions.groups-resync-interval"))
+	startCmd.Flags().DurationVar(&conf.Sessions.MaxLifetime, "sessions.max-lifetime", 0, "The maximum lifetime of a session since the instance started, regardless of its activity. Zero disables it.")
+	viper.BindPFlag("sessions.max-lifetime", startCmd.Flags().Lookup("sessions.max-lifetime"))
+	startCmd.Flags().DurationVar(&conf.Sessions.CoolDown, "sessions.cool-down", 0, "The period after an instance stopped during which it cannot be started again. Zero disables it.")
+	viper.BindPFlag("sessions.cool-down", startCmd.Flags().Lookup("sessions.cool-down"))
+	startCmd.Flags().DurationVar(&conf.Sessions.DrainPeriod, "sessions.drain-period", 0, "The period between the expiration of a session and the stop of the instance, after its pre-stop hook")
+	viper.BindPFlag("sessions.drain-period", startCmd.Flags().Lookup("sessions.drain-period"))
+	startCmd.Flags().DurationVar(&conf.Sessions.PreStopTimeout, "sessions.pre-stop-timeout", 30*time.Second, "The maximum duration of a pre-stop hook")
+	viper.BindPFlag("sessions.pre-stop-timeout", startCmd.Flags().Lookup("sessions.pre-stop-timeout"))
+	startCmd.Flags().DurationVar(&conf.Sessions.StartTimeout, "sessions.start-timeout", 0, "The time an instance has to become ready once started. Zero disables it.")
+	viper.BindPFlag("sessions.start-timeout", startCmd.Flags().Lookup("sessions.start-timeout"))
+	startCmd.Flags().BoolVar(&conf.Sessions.StopOnStartTimeout, "sessions.stop-on-start-timeout", true, "Stop the instances which did not become ready before the start timeout")
+	viper.BindPFlag("sessions.stop-on-start-timeout", startCmd.Flags().Lookup("sessions.stop-on-start-timeout"))
+	startCmd.Flags().DurationVar(&conf.Sessions.StartBackoff, "sessions.start-backoff", 10*time.Second, "The delay before an instance can be started again after a start timeout, doubled after each consecutive timeout")
+	viper.BindPFlag("sessions.start-backoff", startCmd.Flags().Lookup("sessions.start-backoff"))
+	startCmd.Flags().DurationVar(&conf.Sessions.StartMaxBackoff, "sessions.start-max-backoff", 5*time.Minute, "The maximum delay before an instance can be started again after a start timeout")
+	viper.BindPFlag("sessions.start-max-backoff", startCmd.Flags().Lookup("sessions.start-max-backoff"))
+	startCmd.Flags().DurationVar(&conf.Sessions.ReconcileInterval, "sessions.reconcile-interval", 5*time.Minute, "The interval between two reconciliations of the sessions with the provider. Zero only reconciles them on startup.")
+	viper.BindPFlag("sessions.reconcile-interval", startCmd.Flags().Lookup("sessions.reconcile-interval"))
+	startCmd.Flags().DurationVar(&conf.Sessions.StopTimeout, "sessions.stop-timeout", 30*time.Second, "The maximum duration of an attempt to stop the instance of an expired session")
+	viper.BindPFlag("sessions.stop-timeout", startCmd.Flags().Lookup("sessions.stop-timeout"))
+	startCmd.Flags().IntVar(&conf.Sessions.StopMaxRetries, "sessions.stop-max-retries", 5, "The number of retries of a failed stop, the instance is then marked as stop-failed")
+	viper.BindPFlag("sessions.stop-max-retries", startCmd.Flags().Lookup("sessions.stop-max-retries"))
+	startCmd.Flags().DurationVar(&conf.Sessions.StopBackoff, "sessions.stop-backoff", 5*time.Second, "The delay before retrying a failed stop, doubled after each retry")
+	viper.BindPFlag("sessions.stop-backoff", startCmd.Flags().Lookup("sessions.stop-backoff"))
+	startCmd.Flags().DurationVar(&conf.Sessions.StopMaxBackoff, "sessions.stop-max-backoff", 1*time.Minute, "The maximum delay before retrying a failed stop")
+	viper.BindPFlag("sessions.stop-max-backoff", startCmd.Flags().Lookup("sessions.stop-max-backoff"))
 
 	// logging level
 	rootCmd.PersistentFlags().StringVar(&conf.Logging.Level, "logging.level", log.InfoLevel.String(), "The logging level. Can be one of [panic, fatal, error, warn, info, debug, trace]")
@@ -84,6 +152,54 @@ It provides an integrations with multiple reverse proxies and different loading
 	startCmd.Flags().DurationVar(&conf.Strategy.Blocking.DefaultTimeout, "strategy.blocking.default-timeout", 1*time.Minute, "Default timeout used for blocking strategy")
 	viper.BindPFlag("strategy.blocking.default-timeout", startCmd.Flags().Lookup("strategy.blocking.default-timeout"))
 
+	// events
+	startCmd.Flags().StringVar(&conf.Events.Webhook.URL, "events.webhook.url", "", "The URL receiving the lifecycle events, disabled when empty")
+	viper.BindPFlag("events.webhook.url", startCmd.Flags().Lookup("events.webhook.url"))
+	startCmd.Flags().StringVar(&conf.Events.Webhook.Secret, "events.webhook.secret", "", "The secret used to sign the webhook payloads")
+	viper.BindPFlag("events.webhook.secret", startCmd.Flags().Lookup("events.webhook.secret"))
+	startCmd.Flags().StringSliceVar(&conf.Events.Webhook.Events, "events.webhook.events", nil, "The event types delivered to the webhook, all of them when empty")
+	viper.BindPFlag("events.webhook.events", startCmd.Flags().Lookup("events.webhook.events"))
+	startCmd.Flags().IntVar(&conf.Events.Webhook.BatchSize, "events.webhook.batch-size", 10, "The maximum number of events sent in a single webhook call")
+	viper.BindPFlag("events.webhook.batch-size", startCmd.Flags().Lookup("events.webhook.batch-size"))
+	startCmd.Flags().DurationVar(&conf.Events.Webhook.FlushInterval, "events.webhook.flush-interval", 1*time.Second, "The maximum time an event waits before being sent to the webhook")
+	viper.BindPFlag("events.webhook.flush-interval", startCmd.Flags().Lookup("events.webhook.flush-interval"))
+	startCmd.Flags().IntVar(&conf.Events.Webhook.MaxRetries, "events.webhook.max-retries", 3, "The number of retries of a failed webhook call")
+	viper.BindPFlag("events.webhook.max-retries", startCmd.Flags().Lookup("events.webhook.max-retries"))
+	startCmd.Flags().DurationVar(&conf.Events.Webhook.RetryInterval, "events.webhook.retry-interval", 1*time.Second, "The interval before the first retry of a failed webhook call, doubled after each retry")
+	viper.BindPFlag("events.webhook.retry-interval", startCmd.Flags().Lookup("events.webhook.retry-interval"))
+	startCmd.Flags().DurationVar(&conf.Events.Webhook.Timeout, "events.webhook.timeout", 10*time.Second, "The timeout of a webhook call")
+	viper.BindPFlag("events.webhook.timeout", startCmd.Flags().Lookup("events.webhook.timeout"))
+
+	// prewarm
+	startCmd.Flags().BoolVar(&conf.Prewarm.Enabled, "prewarm.enabled", false, "Record the requests and start the instances before their predicted first request of the day")
+	viper.BindPFlag("prewarm.enabled", startCmd.Flags().Lookup("prewarm.enabled"))
+	startCmd.Flags().DurationVar(&conf.Prewarm.Lookahead, "prewarm.lookahead", 2*time.Minute, "How long before the predicted first request the instances are started")
+	viper.BindPFlag("prewarm.lookahead", startCmd.Flags().Lookup("prewarm.lookahead"))
+	startCmd.Flags().Float64Var(&conf.Prewarm.ConfidenceThreshold, "prewarm.confidence-threshold", 0.75, "The minimum ratio of the past same weekdays with a request for an instance to be pre-warmed")
+	viper.BindPFlag("prewarm.confidence-threshold", startCmd.Flags().Lookup("prewarm.confidence-threshold"))
+	startCmd.Flags().DurationVar(&conf.Prewarm.Interval, "prewarm.interval", time.Minute, "The interval between two evaluations of the predictions")
+	viper.BindPFlag("prewarm.interval", startCmd.Flags().Lookup("prewarm.interval"))
+	startCmd.Flags().DurationVar(&conf.Prewarm.Retention, "prewarm.retention", 28*24*time.Hour, "How long the requests history is kept")
+	viper.BindPFlag("prewarm.retention", startCmd.Flags().Lookup("prewarm.retention"))
+
+	// leader election
+	startCmd.Flags().BoolVar(&conf.LeaderElection.Enabled, "leader-election.enabled", false, "Elect a leader among the replicas sharing the sessions, only the leader stops the instances")
+	viper.BindPFlag("leader-election.enabled", startCmd.Flags().Lookup("leader-election.enabled"))
+	startCmd.Flags().StringVar(&conf.LeaderElection.Lock, "leader-election.lock", "file", "The lock held by the leader [file kubernetes redis]")
+	viper.BindPFlag("leader-election.lock", startCmd.Flags().Lookup("leader-election.lock"))
+	startCmd.Flags().StringVar(&conf.LeaderElection.File, "leader-election.file", "", "The file locked by the leader with the file lock")
+	viper.BindPFlag("leader-election.file", startCmd.Flags().Lookup("leader-election.file"))
+	startCmd.Flags().StringVar(&conf.LeaderElection.LeaseName, "leader-election.lease-name", "sablier", "The name of the Lease held by the leader with the kubernetes lock")
+	viper.BindPFlag("leader-election.lease-name", startCmd.Flags().Lookup("leader-election.lease-name"))
+	startCmd.Flags().StringVar(&conf.LeaderElection.LeaseNamespace, "leader-election.lease-namespace", "", "The namespace of the Lease held by the leader with the kubernetes lock, defaults to the namespace of the pod")
+	viper.BindPFlag("leader-election.lease-namespace", startCmd.Flags().Lookup("leader-election.lease-namespace"))
+	startCmd.Flags().StringVar(&conf.LeaderElection.Identity, "leader-election.identity", "", "The identity of the replica, defaults to the hostname")
+	viper.BindPFlag("leader-election.identity", startCmd.Flags().Lookup("leader-election.identity"))
+	startCmd.Flags().DurationVar(&conf.LeaderElection.LeaseDuration, "leader-election.lease-duration", 15*time.Second, "How long the lock is held without being renewed before another replica can acquire it")
+	viper.BindPFlag("leader-election.lease-duration", startCmd.Flags().Lookup("leader-election.lease-duration"))
+	startCmd.Flags().DurationVar(&conf.LeaderElection.RenewInterval, "leader-election.renew-interval", 5*time.Second, "The interval between two attempts to acquire or renew the lock")
+	viper.BindPFlag("leader-election.renew-interval", startCmd.Flags().Lookup("leader-election.renew-interval"))
+
 	rootCmd.AddCommand(startCmd)
 	rootCmd.AddCommand(newVersionCommand())
 
@@ -91,6 +207,14 @@ It provides an integrations with multiple reverse proxies and different loading
 	healthCmd.Flags().String("url", "http://localhost:10000/health", "Sablier health endpoint")
 	rootCmd.AddCommand(healthCmd)
 
+	reportCmd := newReportCommand()
+	reportCmd.Flags().String("url", "http://localhost:10000/api/report", "Sablier report endpoint")
+	reportCmd.Flags().Duration("window", 7*24*time.Hour, "The window of the report, up to 90 days")
+	reportCmd.Flags().String("format", "json", "The format of the report [json csv]")
+	rootCmd.AddCommand(reportCmd)
+
+	rootCmd.AddCommand(newStorageCommand())
+
 	return rootCmd
 }
 
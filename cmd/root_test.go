@@ -108,11 +108,45 @@ func TestPrecedence(t *testing.T) {
 			"--provider.kubernetes.qps", "256",
 			"--provider.kubernetes.burst", "512",
 			"--provider.kubernetes.delimiter", "_",
+			"--provider.retry.max-retries", "3",
+			"--provider.retry.backoff", "3h",
+			"--provider.retry.max-backoff", "3h",
+			"--provider.circuit-breaker.threshold", "3",
+			"--provider.circuit-breaker.open-duration", "3h",
+			"--provider.orphans.interval", "3h",
+			"--provider.orphans.grace-period", "3h",
+			"--provider.orphans.policy", "adopt",
+			"--provider.orphans.dry-run=true",
 			"--server.port", "3333",
 			"--server.base-path", "/cli/",
+			"--server.sessions-control-token", "cli-token",
+			"--storage.backend", "cli",
 			"--storage.file", "/tmp/cli.json",
+			"--storage.snapshot-interval", "3h",
+			"--storage.snapshots", "3",
+			"--storage.flush-interval", "3s",
+			"--storage.redis.address", "cli:6379",
+			"--storage.redis.password", "cli",
+			"--storage.redis.db", "3",
+			"--storage.redis.prefix", "cli:",
 			"--sessions.default-duration", "3h",
 			"--sessions.expiration-interval", "3h",
+			"--sessions.readiness-poll-interval", "3h",
+			"--sessions.readiness-max-poll-interval", "3h",
+			"--sessions.groups-resync-interval", "3h",
+			"--sessions.max-lifetime", "3h",
+			"--sessions.cool-down", "3h",
+			"--sessions.drain-period", "3h",
+			"--sessions.pre-stop-timeout", "3h",
+			"--sessions.start-timeout", "3h",
+			"--sessions.stop-on-start-timeout=false",
+			"--sessions.start-backoff", "3h",
+			"--sessions.start-max-backoff", "3h",
+			"--sessions.reconcile-interval", "3h",
+			"--sessions.stop-timeout", "3h",
+			"--sessions.stop-max-retries", "3",
+			"--sessions.stop-backoff", "3h",
+			"--sessions.stop-max-backoff", "3h",
 			"--logging.level", "info",
 			"--strategy.dynamic.custom-themes-path", "/tmp/cli/themes",
 			// Must use `=` see https://github.com/spf13/cobra/issues/613
@@ -120,6 +154,27 @@ func TestPrecedence(t *testing.T) {
 			"--strategy.dynamic.default-theme", "cli",
 			"--strategy.dynamic.default-refresh-frequency", "3h",
 			"--strategy.blocking.default-timeout", "3h",
+			"--events.webhook.url", "http://cli/hook",
+			"--events.webhook.secret", "cli",
+			"--events.webhook.events", "session.requested,instance.starting",
+			"--events.webhook.batch-size", "3",
+			"--events.webhook.flush-interval", "3h",
+			"--events.webhook.max-retries", "3",
+			"--events.webhook.retry-interval", "3h",
+			"--events.webhook.timeout", "3h",
+			"--prewarm.enabled=true",
+			"--prewarm.lookahead", "3h",
+			"--prewarm.confidence-threshold", "0.3",
+			"--prewarm.interval", "3h",
+			"--prewarm.retention", "3h",
+			"--leader-election.enabled=true",
+			"--leader-election.lock", "cli",
+			"--leader-election.file", "/tmp/cli.lock",
+			"--leader-election.lease-name", "cli",
+			"--leader-election.lease-namespace", "cli",
+			"--leader-election.identity", "cli",
+			"--leader-election.lease-duration", "3h",
+			"--leader-election.renew-interval", "3h",
 		})
 		cmd.Execute()
 
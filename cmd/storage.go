@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/acouvreur/sablier/app/instance"
+	"github.com/acouvreur/sablier/app/storage"
+	"github.com/acouvreur/sablier/config"
+	"github.com/spf13/cobra"
+)
+
+var newStorageCommand = func() *cobra.Command {
+	storageCmd := &cobra.Command{
+		Use:   "storage",
+		Short: "Inspects and migrates the sessions saved by the file storage",
+	}
+
+	inspectCmd := &cobra.Command{
+		Use:   "inspect <file>",
+		Short: "Prints the sessions of a state file",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return inspect(cmd.OutOrStdout(), args[0], cmd.Flag("format").Value.String())
+		},
+	}
+	inspectCmd.Flags().String("format", "text", "The output format, json prints the sessions migrated to the current version [text json]")
+	storageCmd.AddCommand(inspectCmd)
+
+	migrateCmd := &cobra.Command{
+		Use:   "migrate <file>",
+		Short: "Migrates a state file to the current version",
+		Long: `Migrates the sessions of a state file written by any version of Sablier to the current version.
+The file is replaced unless an output file is set, the previous file is then kept as <file>.v<version>.bak.
+An existing output file or backup is never overwritten.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			output := cmd.Flag("output").Value.String()
+			if output == "" {
+				output = args[0]
+			}
+			return migrate(cmd.OutOrStdout(), args[0], output)
+		},
+	}
+	migrateCmd.Flags().String("output", "", "The file written, defaults to the migrated file")
+	storageCmd.AddCommand(migrateCmd)
+
+	return storageCmd
+}
+
+// readStateFile returns the envelope of the file as saved and migrated to the current version
+func readStateFile(file string) (saved storage.Envelope, migrated storage.Envelope, err error) {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return saved, migrated, err
+	}
+	saved, err = storage.Unwrap(b)
+	if err != nil {
+		return saved, migrated, fmt.Errorf("%s is not a valid state file: %w", file, err)
+	}
+	migrated, err = storage.Migrate(saved)
+	return saved, migrated, err
+}
+
+func inspect(out io.Writer, file string, format string) error {
+	saved, migrated, err := readStateFile(file)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(out)
+		encoder.SetEscapeHTML(false)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(migrated)
+	case "text":
+	default:
+		return fmt.Errorf("unknown format %s, must be one of [text json]", format)
+	}
+
+	var sessions map[string]struct {
+		Value     instance.State `json:"value"`
+		ExpiresAt time.Time      `json:"expiresAt"`
+	}
+	if err := json.Unmarshal(migrated.Sessions, &sessions); err != nil {
+		return err
+	}
+	names := make([]string, 0, len(sessions))
+	for name := range sessions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(out, "Version:  %d (current %d)\n", saved.Version, storage.SchemaVersion)
+	fmt.Fprintf(out, "Sessions: %d\n\n", len(sessions))
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tSTATUS\tEXPIRES AT\t")
+	now := time.Now()
+	for _, name := range names {
+		session := sessions[name]
+		expiresAt := session.ExpiresAt.Format(time.RFC3339)
+		if session.ExpiresAt.Before(now) {
+			expiresAt += " (expired)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t\n", name, session.Value.Status, expiresAt)
+	}
+	return w.Flush()
+}
+
+func migrate(out io.Writer, file string, output string) error {
+	saved, migrated, err := readStateFile(file)
+	if err != nil {
+		return err
+	}
+
+	if output == file {
+		if err := backup(file, fmt.Sprintf("%s.v%d.bak", file, saved.Version)); err != nil {
+			return err
+		}
+	} else if _, err := os.Stat(output); err == nil {
+		return fmt.Errorf("%s already exists", output)
+	}
+
+	s, err := storage.NewFileStorage(config.Storage{File: output})
+	if err != nil {
+		return err
+	}
+	writer, err := s.Writer()
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := storage.Close(writer, encoder.Encode(migrated)); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(out, "migrated %s from version %d to %d\n", output, saved.Version, migrated.Version)
+	return nil
+}
+
+// backup copies the file to the backup file, which must not exist
+func backup(file string, backup string) error {
+	b, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	f, err := os.OpenFile(backup, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if errors.Is(err, os.ErrExist) {
+		return fmt.Errorf("the backup %s already exists, move it before migrating %s", backup, file)
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(b); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
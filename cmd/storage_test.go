@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+)
+
+func writeLegacyStateFile(t *testing.T) string {
+	file := filepath.Join(t.TempDir(), "sessions.json")
+	expiresAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	legacy := `{
+  "nginx": {"value": {"name": "nginx", "status": "ready"}, "expiresAt": "` + expiresAt + `"},
+  "apache": {"value": {"name": "apache", "status": "not-ready"}, "expiresAt": "2024-01-01T00:00:00Z"}
+}`
+	assert.NilError(t, os.WriteFile(file, []byte(legacy), 0600))
+	return file
+}
+
+func TestStorageInspectCommand(t *testing.T) {
+	file := writeLegacyStateFile(t)
+
+	cmd := NewRootCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"storage", "inspect", file})
+	assert.NilError(t, cmd.Execute())
+
+	lines := strings.Split(out.String(), "\n")
+	assert.Equal(t, lines[0], "Version:  1 (current 2)")
+	assert.Equal(t, lines[1], "Sessions: 2")
+	assert.Assert(t, strings.HasPrefix(lines[3], "NAME"))
+	assert.Assert(t, strings.HasPrefix(lines[4], "apache  not-ready  2024-01-01T00:00:00Z (expired)"))
+	assert.Assert(t, strings.HasPrefix(lines[5], "nginx   ready"))
+}
+
+func TestStorageMigrateCommand(t *testing.T) {
+	file := writeLegacyStateFile(t)
+	legacy, _ := os.ReadFile(file)
+
+	cmd := NewRootCommand()
+	out := &bytes.Buffer{}
+	cmd.SetOut(out)
+	cmd.SetArgs([]string{"storage", "migrate", file})
+	assert.NilError(t, cmd.Execute())
+	assert.Equal(t, out.String(), "migrated "+file+" from version 1 to 2\n")
+
+	migrated, _ := os.ReadFile(file)
+	assert.Assert(t, strings.HasPrefix(string(migrated), "{\n  \"version\": 2,\n  \"sessions\": {"))
+	previous, _ := os.ReadFile(file + ".v1.bak")
+	assert.Equal(t, string(previous), string(legacy))
+
+	// The backup is never overwritten
+	assert.NilError(t, os.WriteFile(file, legacy, 0600))
+	err := migrate(&bytes.Buffer{}, file, file)
+	assert.ErrorContains(t, err, "already exists")
+	current, _ := os.ReadFile(file)
+	assert.Equal(t, string(current), string(legacy))
+	assert.NilError(t, os.WriteFile(file, migrated, 0600))
+
+	out.Reset()
+	assert.NilError(t, inspect(out, file, "text"))
+	assert.Assert(t, strings.HasPrefix(out.String(), "Version:  2 (current 2)\nSessions: 2\n"))
+}
+
+func TestStorageInspectCommandInvalidFile(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "sessions.json")
+	assert.NilError(t, os.WriteFile(file, []byte(`{"nginx":`), 0600))
+
+	err := inspect(&bytes.Buffer{}, file, "text")
+	assert.ErrorContains(t, err, "is not a valid state file")
+
+	assert.NilError(t, os.WriteFile(file, []byte(`{"version":99,"sessions":{}}`), 0600))
+	err = inspect(&bytes.Buffer{}, file, "json")
+	assert.ErrorContains(t, err, "written by a newer Sablier")
+}
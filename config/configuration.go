@@ -1,21 +1,27 @@
 package config
 
 type Config struct {
-	Server   Server
-	Storage  Storage
-	Provider Provider
-	Sessions Sessions
-	Logging  Logging
-	Strategy Strategy
+	Server         Server
+	Storage        Storage
+	Provider       Provider
+	Sessions       Sessions
+	Logging        Logging
+	Strategy       Strategy
+	Events         Events
+	Prewarm        Prewarm
+	LeaderElection LeaderElection
 }
 
 func NewConfig() Config {
 	return Config{
-		Server:   NewServerConfig(),
-		Storage:  NewStorageConfig(),
-		Provider: NewProviderConfig(),
-		Sessions: NewSessionsConfig(),
-		Logging:  NewLoggingConfig(),
-		Strategy: NewStrategyConfig(),
+		Server:         NewServerConfig(),
+		Storage:        NewStorageConfig(),
+		Provider:       NewProviderConfig(),
+		Sessions:       NewSessionsConfig(),
+		Logging:        NewLoggingConfig(),
+		Strategy:       NewStrategyConfig(),
+		Events:         NewEventsConfig(),
+		Prewarm:        NewPrewarmConfig(),
+		LeaderElection: NewLeaderElectionConfig(),
 	}
 }
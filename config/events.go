@@ -0,0 +1,34 @@
+package config
+
+import "time"
+
+type Webhook struct {
+	// The URL receiving the events, the webhook is disabled when empty
+	URL string `mapstructure:"URL" yaml:"url"`
+	// The secret used to sign the payloads, unsigned when empty
+	Secret string `mapstructure:"SECRET" yaml:"secret"`
+	// The event types to deliver, all of them when empty
+	Events        []string      `mapstructure:"EVENTS" yaml:"events"`
+	BatchSize     int           `mapstructure:"BATCH_SIZE" yaml:"batchSize" default:"10"`
+	FlushInterval time.Duration `mapstructure:"FLUSH_INTERVAL" yaml:"flushInterval" default:"1s"`
+	MaxRetries    int           `mapstructure:"MAX_RETRIES" yaml:"maxRetries" default:"3"`
+	RetryInterval time.Duration `mapstructure:"RETRY_INTERVAL" yaml:"retryInterval" default:"1s"`
+	Timeout       time.Duration `mapstructure:"TIMEOUT" yaml:"timeout" default:"10s"`
+}
+
+type Events struct {
+	Webhook Webhook
+}
+
+func NewEventsConfig() Events {
+	return Events{
+		Webhook: Webhook{
+			Events:        []string{},
+			BatchSize:     10,
+			FlushInterval: 1 * time.Second,
+			MaxRetries:    3,
+			RetryInterval: 1 * time.Second,
+			Timeout:       10 * time.Second,
+		},
+	}
+}
@@ -0,0 +1,34 @@
+package config
+
+import "time"
+
+type LeaderElection struct {
+	// Elects a leader among the replicas, only the leader stops the instances
+	Enabled bool `mapstructure:"ENABLED" yaml:"enabled" default:"false"`
+	// The lock held by the leader, either file, kubernetes or redis. Defaults to "file"
+	Lock string `mapstructure:"LOCK" yaml:"lock" default:"file"`
+	// The file locked by the leader with the file lock
+	File string `mapstructure:"FILE" yaml:"file" default:""`
+	// The Lease held by the leader with the kubernetes lock, the namespace defaults to the namespace of the pod
+	LeaseName      string `mapstructure:"LEASE_NAME" yaml:"leaseName" default:"sablier"`
+	LeaseNamespace string `mapstructure:"LEASE_NAMESPACE" yaml:"leaseNamespace" default:""`
+	// The identity of the replica, defaults to the hostname
+	Identity string `mapstructure:"IDENTITY" yaml:"identity" default:""`
+	// How long the lock is held without being renewed before another replica can acquire it
+	LeaseDuration time.Duration `mapstructure:"LEASE_DURATION" yaml:"leaseDuration" default:"15s"`
+	// The interval between two attempts to acquire or renew the lock
+	RenewInterval time.Duration `mapstructure:"RENEW_INTERVAL" yaml:"renewInterval" default:"5s"`
+}
+
+func NewLeaderElectionConfig() LeaderElection {
+	return LeaderElection{
+		Enabled:        false,
+		Lock:           "file",
+		File:           "",
+		LeaseName:      "sablier",
+		LeaseNamespace: "",
+		Identity:       "",
+		LeaseDuration:  15 * time.Second,
+		RenewInterval:  5 * time.Second,
+	}
+}
@@ -0,0 +1,26 @@
+package config
+
+import "time"
+
+type Prewarm struct {
+	// Records the requests and starts the instances before their predicted first request of the day
+	Enabled bool `mapstructure:"ENABLED" yaml:"enabled" default:"false"`
+	// How long before the predicted first request the instances are started
+	Lookahead time.Duration `mapstructure:"LOOKAHEAD" yaml:"lookahead" default:"2m"`
+	// The minimum ratio of the past same weekdays with a request for an instance to be pre-warmed
+	ConfidenceThreshold float64 `mapstructure:"CONFIDENCE_THRESHOLD" yaml:"confidenceThreshold" default:"0.75"`
+	// The interval between two evaluations of the predictions
+	Interval time.Duration `mapstructure:"INTERVAL" yaml:"interval" default:"1m"`
+	// How long the requests history is kept
+	Retention time.Duration `mapstructure:"RETENTION" yaml:"retention" default:"672h"`
+}
+
+func NewPrewarmConfig() Prewarm {
+	return Prewarm{
+		Enabled:             false,
+		Lookahead:           2 * time.Minute,
+		ConfidenceThreshold: 0.75,
+		Interval:            1 * time.Minute,
+		Retention:           28 * 24 * time.Hour,
+	}
+}
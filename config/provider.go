@@ -2,6 +2,7 @@ package config
 
 import (
 	"fmt"
+	"time"
 )
 
 // Provider holds the provider configurations
@@ -11,6 +12,9 @@ type Provider struct {
 	Name              string `mapstructure:"NAME" yaml:"name,omitempty" default:"docker"`
 	AutoStopOnStartup bool   `yaml:"auto-stop-on-startup,omitempty" default:"true"`
 	Kubernetes        Kubernetes
+	Retry             Retry
+	CircuitBreaker    CircuitBreaker
+	Orphans           Orphans
 }
 
 type Kubernetes struct {
@@ -22,6 +26,35 @@ type Kubernetes struct {
 	Delimiter string `mapstructure:"DELIMITER" yaml:"Delimiter" default:"_"`
 }
 
+// Retry holds the retry policy of the provider calls failing with a transient error
+type Retry struct {
+	// The number of retries after the first attempt, zero disables the retries
+	MaxRetries int `mapstructure:"MAX_RETRIES" yaml:"maxRetries" default:"3"`
+	// The delay before the first retry, doubled after each retry up to MaxBackoff
+	Backoff    time.Duration `mapstructure:"BACKOFF" yaml:"backoff" default:"200ms"`
+	MaxBackoff time.Duration `mapstructure:"MAX_BACKOFF" yaml:"maxBackoff" default:"2s"`
+}
+
+// CircuitBreaker holds the policy marking the provider as degraded after consecutive transient errors
+type CircuitBreaker struct {
+	// The number of consecutive transient errors opening the circuit, zero disables the circuit breaker
+	Threshold int `mapstructure:"THRESHOLD" yaml:"threshold" default:"5"`
+	// The time the provider calls fail fast once the circuit is open, before a call is tried again
+	OpenDuration time.Duration `mapstructure:"OPEN_DURATION" yaml:"openDuration" default:"30s"`
+}
+
+// Orphans holds the policy of the enabled instances running without a session
+type Orphans struct {
+	// The interval between two checks of the orphans, zero only checks them on startup
+	Interval time.Duration `mapstructure:"INTERVAL" yaml:"interval" default:"0s"`
+	// The time an instance must run without a session before it is handled as an orphan
+	GracePeriod time.Duration `mapstructure:"GRACE_PERIOD" yaml:"gracePeriod" default:"5m"`
+	// The action taken on the orphans, either "stop" or "adopt" to create a session with the default duration
+	Policy string `mapstructure:"POLICY" yaml:"policy" default:"stop"`
+	// Only log and emit the actions, the orphans are neither stopped nor adopted
+	DryRun bool `mapstructure:"DRY_RUN" yaml:"dryRun" default:"false"`
+}
+
 var providers = []string{"docker", "docker_swarm", "swarm", "kubernetes"}
 
 func NewProviderConfig() Provider {
@@ -33,6 +66,21 @@ func NewProviderConfig() Provider {
 			Burst:     10,
 			Delimiter: "_", //Delimiter used for namespace/resource type/name resolution. Defaults to "_" for backward compatibility. But you should use "/" or ".".
 		},
+		Retry: Retry{
+			MaxRetries: 3,
+			Backoff:    200 * time.Millisecond,
+			MaxBackoff: 2 * time.Second,
+		},
+		CircuitBreaker: CircuitBreaker{
+			Threshold:    5,
+			OpenDuration: 30 * time.Second,
+		},
+		Orphans: Orphans{
+			Interval:    0,
+			GracePeriod: 5 * time.Minute,
+			Policy:      "stop",
+			DryRun:      false,
+		},
 	}
 }
 
@@ -3,6 +3,8 @@ package config
 type Server struct {
 	Port     int    `mapstructure:"PORT" yaml:"port" default:"10000"`
 	BasePath string `mapstructure:"BASE_PATH" yaml:"basePath" default:"/"`
+	// The bearer token required by the endpoints acting on the sessions. Empty disables these endpoints.
+	SessionsControlToken string `mapstructure:"SESSIONS_CONTROL_TOKEN" yaml:"sessionsControlToken" default:""`
 }
 
 func NewServerConfig() Server {
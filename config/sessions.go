@@ -5,11 +5,54 @@ import "time"
 type Sessions struct {
 	DefaultDuration    time.Duration `mapstructure:"DEFAULT_DURATION" yaml:"defaultDuration" default:"5m"`
 	ExpirationInterval time.Duration `mapstructure:"EXPIRATION_INTERVAL" yaml:"expirationInterval" default:"20s"`
+	// The first interval between two readiness checks when the provider does not notify readiness.
+	// The interval doubles after each check, up to ReadinessMaxPollInterval.
+	ReadinessPollInterval    time.Duration `mapstructure:"READINESS_POLL_INTERVAL" yaml:"readinessPollInterval" default:"500ms"`
+	ReadinessMaxPollInterval time.Duration `mapstructure:"READINESS_MAX_POLL_INTERVAL" yaml:"readinessMaxPollInterval" default:"5s"`
+	// The interval between two full listings of the groups.
+	// Groups are updated from the provider events in between.
+	GroupsResyncInterval time.Duration `mapstructure:"GROUPS_RESYNC_INTERVAL" yaml:"groupsResyncInterval" default:"1m"`
+	// The maximum lifetime of a session since the instance started, regardless of its activity. Zero disables it.
+	MaxLifetime time.Duration `mapstructure:"MAX_LIFETIME" yaml:"maxLifetime" default:"0s"`
+	// The period after an instance stopped during which it cannot be started again. Zero disables it.
+	CoolDown time.Duration `mapstructure:"COOL_DOWN" yaml:"coolDown" default:"0s"`
+	// The period between the expiration of a session and the stop of the instance, after its pre-stop hook.
+	DrainPeriod time.Duration `mapstructure:"DRAIN_PERIOD" yaml:"drainPeriod" default:"0s"`
+	// The maximum duration of a pre-stop hook.
+	PreStopTimeout time.Duration `mapstructure:"PRE_STOP_TIMEOUT" yaml:"preStopTimeout" default:"30s"`
+	// The time an instance has to become ready once started. Zero disables it.
+	StartTimeout time.Duration `mapstructure:"START_TIMEOUT" yaml:"startTimeout" default:"0s"`
+	// Stop the instances which did not become ready before the start timeout
+	StopOnStartTimeout bool `mapstructure:"STOP_ON_START_TIMEOUT" yaml:"stopOnStartTimeout" default:"true"`
+	// The delay before an instance can be started again after a start timeout, doubled after each consecutive timeout up to StartMaxBackoff
+	StartBackoff    time.Duration `mapstructure:"START_BACKOFF" yaml:"startBackoff" default:"10s"`
+	StartMaxBackoff time.Duration `mapstructure:"START_MAX_BACKOFF" yaml:"startMaxBackoff" default:"5m"`
+	// The interval between two reconciliations of the sessions with the provider. Zero only reconciles them on startup.
+	ReconcileInterval time.Duration `mapstructure:"RECONCILE_INTERVAL" yaml:"reconcileInterval" default:"5m"`
+	// The maximum duration of an attempt to stop the instance of an expired session
+	StopTimeout time.Duration `mapstructure:"STOP_TIMEOUT" yaml:"stopTimeout" default:"30s"`
+	// The number of retries of a failed stop, the instance is then marked as stop-failed
+	StopMaxRetries int `mapstructure:"STOP_MAX_RETRIES" yaml:"stopMaxRetries" default:"5"`
+	// The delay before retrying a failed stop, doubled after each retry up to StopMaxBackoff
+	StopBackoff    time.Duration `mapstructure:"STOP_BACKOFF" yaml:"stopBackoff" default:"5s"`
+	StopMaxBackoff time.Duration `mapstructure:"STOP_MAX_BACKOFF" yaml:"stopMaxBackoff" default:"1m"`
 }
 
 func NewSessionsConfig() Sessions {
 	return Sessions{
-		DefaultDuration:    5 * time.Minute,
-		ExpirationInterval: 20 * time.Second,
+		DefaultDuration:          5 * time.Minute,
+		ExpirationInterval:       20 * time.Second,
+		ReadinessPollInterval:    500 * time.Millisecond,
+		ReadinessMaxPollInterval: 5 * time.Second,
+		GroupsResyncInterval:     1 * time.Minute,
+		PreStopTimeout:           30 * time.Second,
+		StopOnStartTimeout:       true,
+		StartBackoff:             10 * time.Second,
+		StartMaxBackoff:          5 * time.Minute,
+		ReconcileInterval:        5 * time.Minute,
+		StopTimeout:              30 * time.Second,
+		StopMaxRetries:           5,
+		StopBackoff:              5 * time.Second,
+		StopMaxBackoff:           1 * time.Minute,
 	}
 }
@@ -1,11 +1,41 @@
 package config
 
+import "time"
+
 type Storage struct {
-	File string `mapstructure:"FILE" yaml:"file" default:""`
+	// The storage backend, either file, bbolt or redis. Defaults to "file"
+	Backend string `mapstructure:"BACKEND" yaml:"backend" default:"file"`
+	File    string `mapstructure:"FILE" yaml:"file" default:""`
+	// The interval between two saves of the file, zero only saves it on exit
+	SnapshotInterval time.Duration `mapstructure:"SNAPSHOT_INTERVAL" yaml:"snapshotInterval" default:"1m"`
+	// The number of previous versions of the file kept, the newest valid one is loaded if the file is corrupt
+	Snapshots int `mapstructure:"SNAPSHOTS" yaml:"snapshots" default:"3"`
+	// The interval between two writes of the changed sessions with the bbolt backend, zero writes each change as it happens
+	FlushInterval time.Duration `mapstructure:"FLUSH_INTERVAL" yaml:"flushInterval" default:"1s"`
+	Redis         Redis
+}
+
+// Redis holds the connection to the server used by the redis storage backend
+type Redis struct {
+	Address  string `mapstructure:"ADDRESS" yaml:"address" default:"localhost:6379"`
+	Password string `mapstructure:"PASSWORD" yaml:"password" default:""`
+	DB       int    `mapstructure:"DB" yaml:"db" default:"0"`
+	// The prefix of the keys, replicas sharing the sessions must use the same prefix
+	Prefix string `mapstructure:"PREFIX" yaml:"prefix" default:"sablier:"`
 }
 
 func NewStorageConfig() Storage {
 	return Storage{
-		File: "",
+		Backend:          "file",
+		File:             "",
+		SnapshotInterval: time.Minute,
+		Snapshots:        3,
+		FlushInterval:    time.Second,
+		Redis: Redis{
+			Address:  "localhost:6379",
+			Password: "",
+			DB:       0,
+			Prefix:   "sablier:",
+		},
 	}
 }
@@ -0,0 +1,372 @@
+// Package rediskv implements tinykv.KV on top of Redis so that several processes share the same entries.
+//
+// The entries are stored in a hash, and each of them has a marker key expiring with the entry.
+// When a marker expires, the first process claiming the entry removes it from the hash and
+// is the only one notified of the expiration.
+package rediskv
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/acouvreur/sablier/pkg/tinykv"
+	"github.com/redis/go-redis/v9"
+	log "github.com/sirupsen/logrus"
+)
+
+// Interface guard
+var _ tinykv.KV[any] = (*KV[any])(nil)
+
+// claimScript removes the entry if its marker expired and returns it, nil otherwise.
+// It runs atomically so that a single process claims an expired entry.
+var claimScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[2]) == 1 then
+	return false
+end
+local value = redis.call("HGET", KEYS[1], ARGV[1])
+if value then
+	redis.call("HDEL", KEYS[1], ARGV[1])
+end
+return value
+`)
+
+// storedEntry is the format of an entry, the same as the tinykv JSON format
+type storedEntry[T any] struct {
+	Value     T         `json:"value"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+type KV[T any] struct {
+	client   *redis.Client
+	prefix   string
+	onExpire func(k string, v T)
+	// claimIf tells whether this process claims the expired entries
+	claimIf atomic.Pointer[func() bool]
+	// watchers receive the changes made by this process and the expirations it claims
+	watchers tinykv.Watchers[T]
+
+	ctx      context.Context
+	cancel   context.CancelFunc
+	stopOnce sync.Once
+}
+
+// New creates a KV storing its entries under the prefix. The expirations are received
+// from the Redis keyspace notifications, and checked at each expiration interval in case
+// the notifications are disabled or missed.
+func New[T any](client *redis.Client, prefix string, expirationInterval time.Duration, onExpire func(k string, v T)) *KV[T] {
+	if expirationInterval <= 0 {
+		expirationInterval = time.Second * 20
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	kv := &KV[T]{
+		client:   client,
+		prefix:   prefix,
+		onExpire: onExpire,
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+
+	ready := make(chan struct{})
+	go kv.watchExpirations(ready)
+	<-ready
+	go kv.sweepLoop(expirationInterval)
+	return kv
+}
+
+func (kv *KV[T]) hashKey() string {
+	return kv.prefix + "entries"
+}
+
+func (kv *KV[T]) markerKey(k string) string {
+	return kv.prefix + "ttl:" + k
+}
+
+func (kv *KV[T]) Put(k string, v T, expiresAfter time.Duration) error {
+	e := storedEntry[T]{Value: v, ExpiresAt: time.Now().Add(expiresAfter)}
+	b, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	// The entry is refreshed if its marker did not expire, an expired entry not claimed yet is put again
+	var live *redis.IntCmd
+	_, err = kv.client.TxPipelined(kv.ctx, func(pipe redis.Pipeliner) error {
+		live = pipe.Exists(kv.ctx, kv.markerKey(k))
+		pipe.HSet(kv.ctx, kv.hashKey(), k, b)
+		if expiresAfter > 0 {
+			// Redis rejects a TTL of 0, which a duration under a millisecond rounds to
+			pipe.Set(kv.ctx, kv.markerKey(k), 1, max(expiresAfter, time.Millisecond))
+		} else {
+			// A marker without TTL would never expire, the entry is already expired
+			pipe.Del(kv.ctx, kv.markerKey(k))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	op := tinykv.OpPut
+	if live.Val() > 0 {
+		op = tinykv.OpRefresh
+	}
+	kv.watchers.Notify(tinykv.Event[T]{Op: op, Key: k, Value: e.Value, ExpiresAt: e.ExpiresAt})
+	if expiresAfter <= 0 {
+		go kv.claim(k)
+	}
+	return nil
+}
+
+func (kv *KV[T]) Delete(k string) {
+	var deleted *redis.StringCmd
+	_, err := kv.client.TxPipelined(kv.ctx, func(pipe redis.Pipeliner) error {
+		deleted = pipe.HGet(kv.ctx, kv.hashKey(), k)
+		pipe.HDel(kv.ctx, kv.hashKey(), k)
+		pipe.Del(kv.ctx, kv.markerKey(k))
+		return nil
+	})
+	if errors.Is(err, redis.Nil) {
+		return
+	}
+	if err != nil {
+		log.Errorf("could not delete %s: %v", k, err)
+		return
+	}
+
+	if e, ok := kv.decode(k, []byte(deleted.Val())); ok {
+		kv.watchers.Notify(tinykv.Event[T]{Op: tinykv.OpDelete, Key: k, Value: e.Value, ExpiresAt: e.ExpiresAt})
+	}
+}
+
+// Subscribe streams the changes made by this process and the expirations it claims,
+// the changes made by the other processes are not received
+func (kv *KV[T]) Subscribe(buffer int, policy tinykv.DropPolicy) *tinykv.Subscription[T] {
+	return kv.watchers.Subscribe(buffer, policy)
+}
+
+func (kv *KV[T]) Get(k string) (T, bool) {
+	e, ok := kv.GetEntry(k)
+	if !ok {
+		var zero T
+		return zero, false
+	}
+	return e.Value(), true
+}
+
+func (kv *KV[T]) GetEntry(k string) (e tinykv.Entry[T], ok bool) {
+	b, err := kv.client.HGet(kv.ctx, kv.hashKey(), k).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			log.Errorf("could not get %s: %v", k, err)
+		}
+		return e, false
+	}
+
+	entry, ok := kv.decode(k, b)
+	if !ok {
+		return e, false
+	}
+	if !entry.ExpiresAt.After(time.Now()) {
+		go kv.claim(k)
+		return e, false
+	}
+	return tinykv.NewEntry(entry.Value, entry.ExpiresAt), true
+}
+
+func (kv *KV[T]) Keys() (keys []string) {
+	for k := range kv.entries() {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+func (kv *KV[T]) Values() (values []T) {
+	for _, e := range kv.entries() {
+		values = append(values, e.Value)
+	}
+	return values
+}
+
+func (kv *KV[T]) Entries() map[string]tinykv.Entry[T] {
+	entries := make(map[string]tinykv.Entry[T])
+	for k, e := range kv.entries() {
+		entries[k] = tinykv.NewEntry(e.Value, e.ExpiresAt)
+	}
+	return entries
+}
+
+// entries returns the entries which did not expire yet
+func (kv *KV[T]) entries() map[string]storedEntry[T] {
+	raw, err := kv.client.HGetAll(kv.ctx, kv.hashKey()).Result()
+	if err != nil {
+		log.Errorf("could not list the entries: %v", err)
+		return nil
+	}
+
+	now := time.Now()
+	entries := make(map[string]storedEntry[T], len(raw))
+	for k, b := range raw {
+		if e, ok := kv.decode(k, []byte(b)); ok && e.ExpiresAt.After(now) {
+			entries[k] = e
+		}
+	}
+	return entries
+}
+
+func (kv *KV[T]) decode(k string, b []byte) (storedEntry[T], bool) {
+	var e storedEntry[T]
+	if err := json.Unmarshal(b, &e); err != nil {
+		log.Errorf("could not decode %s: %v", k, err)
+		return e, false
+	}
+	return e, true
+}
+
+// Stop stops watching the expirations, the client is not closed
+func (kv *KV[T]) Stop() {
+	kv.stopOnce.Do(kv.cancel)
+}
+
+func (kv *KV[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(kv.entries())
+}
+
+// UnmarshalJSON puts the entries which did not expire yet
+func (kv *KV[T]) UnmarshalJSON(b []byte) error {
+	var entries map[string]storedEntry[T]
+	if err := json.Unmarshal(b, &entries); err != nil {
+		return err
+	}
+
+	for k, e := range entries {
+		if expiresAfter := time.Until(e.ExpiresAt); expiresAfter > 0 {
+			if err := kv.Put(k, e.Value, expiresAfter); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ClaimIf only lets this process claim the expired entries while cond returns true,
+// the expired entries are left to the other processes otherwise.
+func (kv *KV[T]) ClaimIf(cond func() bool) {
+	kv.claimIf.Store(&cond)
+}
+
+// claim removes the entry if it expired and notifies the expiration, unless another process claimed it first
+func (kv *KV[T]) claim(k string) {
+	if cond := kv.claimIf.Load(); cond != nil && !(*cond)() {
+		return
+	}
+
+	b, err := claimScript.Run(kv.ctx, kv.client, []string{kv.hashKey(), kv.markerKey(k)}, k).Text()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) && !errors.Is(err, context.Canceled) {
+			log.Errorf("could not claim the expired entry %s: %v", k, err)
+		}
+		return
+	}
+
+	e, ok := kv.decode(k, []byte(b))
+	if !ok {
+		return
+	}
+	kv.watchers.Notify(tinykv.Event[T]{Op: tinykv.OpExpire, Key: k, Value: e.Value, ExpiresAt: e.ExpiresAt})
+	if kv.onExpire != nil {
+		kv.onExpire(k, e.Value)
+	}
+}
+
+// watchExpirations claims the entries whose marker expired, as notified by Redis
+func (kv *KV[T]) watchExpirations(ready chan<- struct{}) {
+	kv.enableNotifications()
+
+	channel := fmt.Sprintf("__keyevent@%d__:expired", kv.client.Options().DB)
+	pubsub := kv.client.Subscribe(kv.ctx, channel)
+	defer pubsub.Close()
+	// Wait for the subscription so that no expiration is missed after New returns
+	if _, err := pubsub.Receive(kv.ctx); err != nil {
+		log.Warnf("could not subscribe to the expirations, relying on the periodic checks: %v", err)
+	}
+	close(ready)
+
+	markerPrefix := kv.markerKey("")
+	for msg := range pubsub.Channel() {
+		if k, ok := strings.CutPrefix(msg.Payload, markerPrefix); ok {
+			kv.claim(k)
+		}
+	}
+}
+
+// enableNotifications enables the expired keyspace events, it can fail on managed Redis services
+// where they must be enabled in the service configuration
+func (kv *KV[T]) enableNotifications() {
+	current, err := kv.client.ConfigGet(kv.ctx, "notify-keyspace-events").Result()
+	if err != nil {
+		log.Warnf("could not read notify-keyspace-events, make sure it includes \"Ex\": %v", err)
+		return
+	}
+
+	flags := current["notify-keyspace-events"]
+	if strings.Contains(flags, "E") && (strings.Contains(flags, "x") || strings.Contains(flags, "A")) {
+		return
+	}
+	if !strings.Contains(flags, "E") {
+		flags += "E"
+	}
+	if !strings.Contains(flags, "x") && !strings.Contains(flags, "A") {
+		flags += "x"
+	}
+	if err := kv.client.ConfigSet(kv.ctx, "notify-keyspace-events", flags).Err(); err != nil {
+		log.Warnf("could not set notify-keyspace-events to %s, the expirations are only checked periodically: %v", flags, err)
+	}
+}
+
+// sweepLoop claims the entries whose marker expired at each interval
+func (kv *KV[T]) sweepLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-kv.ctx.Done():
+			return
+		case <-ticker.C:
+			kv.sweep()
+		}
+	}
+}
+
+func (kv *KV[T]) sweep() {
+	keys, err := kv.client.HKeys(kv.ctx, kv.hashKey()).Result()
+	if err != nil {
+		if !errors.Is(err, context.Canceled) {
+			log.Errorf("could not list the entries: %v", err)
+		}
+		return
+	}
+
+	pipe := kv.client.Pipeline()
+	exists := make([]*redis.IntCmd, len(keys))
+	for i, k := range keys {
+		exists[i] = pipe.Exists(kv.ctx, kv.markerKey(k))
+	}
+	if _, err := pipe.Exec(kv.ctx); err != nil {
+		log.Errorf("could not check the expirations: %v", err)
+		return
+	}
+
+	for i, k := range keys {
+		if exists[i].Val() == 0 {
+			kv.claim(k)
+		}
+	}
+}
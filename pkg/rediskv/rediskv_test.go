@@ -0,0 +1,263 @@
+package rediskv
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/acouvreur/sablier/pkg/tinykv"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/poll"
+)
+
+type expirations struct {
+	mu   sync.Mutex
+	keys []string
+}
+
+func (e *expirations) onExpire(k string, _ string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.keys = append(e.keys, k)
+}
+
+func (e *expirations) get() []string {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return append([]string(nil), e.keys...)
+}
+
+func newKV(t *testing.T, server *miniredis.Miniredis, interval time.Duration, onExpire func(string, string)) *KV[string] {
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	kv := New[string](client, "sablier:", interval, onExpire)
+	t.Cleanup(func() {
+		kv.Stop()
+		client.Close()
+	})
+	return kv
+}
+
+func TestKV_PutGet(t *testing.T) {
+	server := miniredis.RunT(t)
+	kv := newKV(t, server, time.Minute, nil)
+
+	assert.NilError(t, kv.Put("nginx", "ready", time.Minute))
+
+	v, ok := kv.Get("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, v, "ready")
+
+	e, ok := kv.GetEntry("nginx")
+	assert.Assert(t, ok)
+	assert.Assert(t, e.ExpiresAt().After(time.Now().Add(59*time.Second)))
+
+	assert.DeepEqual(t, kv.Keys(), []string{"nginx"})
+	assert.DeepEqual(t, kv.Values(), []string{"ready"})
+	assert.Equal(t, len(kv.Entries()), 1)
+
+	kv.Delete("nginx")
+	_, ok = kv.Get("nginx")
+	assert.Assert(t, !ok)
+	assert.Assert(t, !server.Exists("sablier:ttl:nginx"))
+}
+
+func TestKV_SharedBetweenReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	a := newKV(t, server, time.Minute, nil)
+	b := newKV(t, server, time.Minute, nil)
+
+	assert.NilError(t, a.Put("nginx", "ready", time.Minute))
+
+	v, ok := b.Get("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, v, "ready")
+}
+
+func TestKV_ExpiresOnceAcrossReplicas(t *testing.T) {
+	server := miniredis.RunT(t)
+	expired := &expirations{}
+	a := newKV(t, server, 10*time.Millisecond, expired.onExpire)
+	newKV(t, server, 10*time.Millisecond, expired.onExpire)
+	newKV(t, server, 10*time.Millisecond, expired.onExpire)
+
+	assert.NilError(t, a.Put("nginx", "ready", 50*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	server.FastForward(time.Second)
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if len(expired.get()) == 0 {
+			return poll.Continue("nginx did not expire yet")
+		}
+		return poll.Success()
+	}, poll.WithTimeout(time.Second), poll.WithDelay(5*time.Millisecond))
+
+	// Give the other replicas a chance to claim it again
+	time.Sleep(50 * time.Millisecond)
+	assert.DeepEqual(t, expired.get(), []string{"nginx"})
+	_, ok := a.Get("nginx")
+	assert.Assert(t, !ok)
+}
+
+func TestKV_ExpiresOnNotification(t *testing.T) {
+	server := miniredis.RunT(t)
+	expired := &expirations{}
+	kv := newKV(t, server, time.Hour, expired.onExpire)
+
+	assert.NilError(t, kv.Put("nginx", "ready", 50*time.Millisecond))
+	assert.NilError(t, kv.Put("whoami", "ready", time.Hour))
+	server.FastForward(time.Second)
+	// miniredis does not send keyspace notifications
+	server.Publish("__keyevent@0__:expired", "sablier:ttl:nginx")
+
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if len(expired.get()) == 0 {
+			return poll.Continue("nginx did not expire yet")
+		}
+		return poll.Success()
+	}, poll.WithTimeout(time.Second), poll.WithDelay(5*time.Millisecond))
+	assert.DeepEqual(t, expired.get(), []string{"nginx"})
+}
+
+func TestKV_NotificationForUnexpiredEntry(t *testing.T) {
+	server := miniredis.RunT(t)
+	expired := &expirations{}
+	kv := newKV(t, server, time.Hour, expired.onExpire)
+
+	assert.NilError(t, kv.Put("nginx", "ready", time.Hour))
+	server.Publish("__keyevent@0__:expired", "sablier:ttl:nginx")
+
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(expired.get()), 0)
+	_, ok := kv.Get("nginx")
+	assert.Assert(t, ok)
+}
+
+func TestKV_ClaimIf(t *testing.T) {
+	server := miniredis.RunT(t)
+	followerExpired := &expirations{}
+	follower := newKV(t, server, 10*time.Millisecond, followerExpired.onExpire)
+	follower.ClaimIf(func() bool { return false })
+
+	assert.NilError(t, follower.Put("nginx", "ready", 50*time.Millisecond))
+	time.Sleep(50 * time.Millisecond)
+	server.FastForward(time.Second)
+
+	// The entry is left to another process
+	time.Sleep(50 * time.Millisecond)
+	assert.Equal(t, len(followerExpired.get()), 0)
+	assert.Assert(t, server.Exists("sablier:entries"))
+
+	leaderExpired := &expirations{}
+	newKV(t, server, 10*time.Millisecond, leaderExpired.onExpire)
+	poll.WaitOn(t, func(poll.LogT) poll.Result {
+		if len(leaderExpired.get()) == 0 {
+			return poll.Continue("nginx did not expire yet")
+		}
+		return poll.Success()
+	}, poll.WithTimeout(time.Second), poll.WithDelay(5*time.Millisecond))
+	assert.Equal(t, len(followerExpired.get()), 0)
+}
+
+func TestKV_MarshalJSON(t *testing.T) {
+	server := miniredis.RunT(t)
+	a := newKV(t, server, time.Minute, nil)
+	assert.NilError(t, a.Put("nginx", "ready", time.Minute))
+
+	b, err := a.MarshalJSON()
+	assert.NilError(t, err)
+
+	other := miniredis.RunT(t)
+	c := newKV(t, other, time.Minute, nil)
+	assert.NilError(t, c.UnmarshalJSON(b))
+
+	v, ok := c.Get("nginx")
+	assert.Assert(t, ok)
+	assert.Equal(t, v, "ready")
+}
+
+func TestKV_Subscribe(t *testing.T) {
+	server := miniredis.RunT(t)
+	kv := newKV(t, server, time.Hour, nil)
+	sub := kv.Subscribe(10, tinykv.DropNewest)
+	defer sub.Unsubscribe()
+
+	assert.NilError(t, kv.Put("nginx", "starting", time.Minute))
+	assert.NilError(t, kv.Put("nginx", "ready", 50*time.Millisecond))
+	kv.Delete("whoami")
+	assert.NilError(t, kv.Put("whoami", "ready", time.Minute))
+	kv.Delete("whoami")
+	server.FastForward(time.Second)
+	server.Publish("__keyevent@0__:expired", "sablier:ttl:nginx")
+
+	for _, want := range []struct {
+		op    tinykv.Op
+		key   string
+		value string
+	}{
+		{tinykv.OpPut, "nginx", "starting"},
+		{tinykv.OpRefresh, "nginx", "ready"},
+		{tinykv.OpPut, "whoami", "ready"},
+		{tinykv.OpDelete, "whoami", "ready"},
+		{tinykv.OpExpire, "nginx", "ready"},
+	} {
+		select {
+		case event := <-sub.Events:
+			assert.Equal(t, event.Op, want.op)
+			assert.Equal(t, event.Key, want.key)
+			assert.Equal(t, event.Value, want.value)
+		case <-time.After(time.Second):
+			t.Fatalf("did not receive %s of %s", want.op, want.key)
+		}
+	}
+}
+
+func TestKV_PutExpiredNotClaimed(t *testing.T) {
+	server := miniredis.RunT(t)
+	kv := newKV(t, server, time.Hour, nil)
+	// The expired entries are left to another process
+	kv.ClaimIf(func() bool { return false })
+	sub := kv.Subscribe(10, tinykv.DropNewest)
+	defer sub.Unsubscribe()
+
+	assert.NilError(t, kv.Put("nginx", "starting", 50*time.Millisecond))
+	server.FastForward(time.Second)
+	assert.NilError(t, kv.Put("nginx", "ready", time.Minute))
+
+	for _, want := range []tinykv.Op{tinykv.OpPut, tinykv.OpPut} {
+		select {
+		case event := <-sub.Events:
+			assert.Equal(t, event.Op, want)
+		case <-time.After(time.Second):
+			t.Fatalf("did not receive %s", want)
+		}
+	}
+}
+
+func TestKV_PutNonPositiveDuration(t *testing.T) {
+	for _, expiresAfter := range []time.Duration{0, -time.Second, time.Microsecond} {
+		t.Run(expiresAfter.String(), func(t *testing.T) {
+			server := miniredis.RunT(t)
+			expired := &expirations{}
+			kv := newKV(t, server, time.Hour, expired.onExpire)
+
+			assert.NilError(t, kv.Put("nginx", "ready", expiresAfter))
+			if expiresAfter > 0 {
+				server.FastForward(time.Second)
+				server.Publish("__keyevent@0__:expired", "sablier:ttl:nginx")
+			}
+
+			// The entry expires instead of being kept forever
+			poll.WaitOn(t, func(poll.LogT) poll.Result {
+				if len(expired.get()) == 1 {
+					return poll.Success()
+				}
+				return poll.Continue("nginx did not expire")
+			}, poll.WithTimeout(time.Second))
+			assert.Assert(t, !server.Exists("sablier:ttl:nginx"))
+			_, ok := kv.Get("nginx")
+			assert.Assert(t, !ok)
+		})
+	}
+}
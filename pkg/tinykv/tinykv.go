@@ -6,10 +6,17 @@ import (
 	"time"
 )
 
+// Resolution is the default delay between the expiration of an entry and its removal
+const Resolution = 100 * time.Millisecond
+
 type timeout struct {
 	expiresAt    time.Time
 	expiresAfter time.Duration
 	key          string
+
+	// list links the timeout in its slot of the timing wheel
+	list       *timeoutList
+	prev, next *timeout
 }
 
 func newTimeout(
@@ -31,26 +38,34 @@ func (to *timeout) expired() bool {
 
 //-----------------------------------------------------------------------------
 
-// timeout heap
-type th []*timeout
-
-func (h th) Len() int           { return len(h) }
-func (h th) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
-func (h th) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
-func (h *th) Push(x tohVal)     { *h = append(*h, x) }
-func (h *th) Pop() tohVal {
-	old := *h
-	n := len(old)
-	x := old[n-1]
-	*h = old[0 : n-1]
-	return x
+// Entry is a value with its expiration
+type Entry[T any] struct {
+	*timeout
+	value T
 }
 
-//-----------------------------------------------------------------------------
+// NewEntry returns an entry expiring at the given time, for the KV implementations outside of this package
+func NewEntry[T any](value T, expiresAt time.Time) Entry[T] {
+	return Entry[T]{
+		value: value,
+		timeout: &timeout{
+			expiresAt:    expiresAt,
+			expiresAfter: time.Until(expiresAt),
+		},
+	}
+}
 
-type entry[T any] struct {
-	*timeout
-	value T
+// Value returns the value of the entry
+func (e Entry[T]) Value() T {
+	return e.value
+}
+
+// ExpiresAt returns the time at which the entry expires, or the zero time if it never expires
+func (e Entry[T]) ExpiresAt() time.Time {
+	if e.timeout == nil {
+		return time.Time{}
+	}
+	return e.expiresAt
 }
 
 //-----------------------------------------------------------------------------
@@ -59,10 +74,15 @@ type entry[T any] struct {
 type KV[T any] interface {
 	Delete(k string)
 	Get(k string) (v T, ok bool)
+	GetEntry(k string) (e Entry[T], ok bool)
 	Keys() (keys []string)
 	Values() (values []T)
-	Entries() (entries map[string]entry[T])
+	Entries() (entries map[string]Entry[T])
 	Put(k string, v T, expiresAfter time.Duration) error
+	// Subscribe streams the changes of the entries, see Watchers. Only the changes made through
+	// this KV are streamed: with a KV shared by several processes, such as rediskv, the changes
+	// made by the other processes and the expirations they claim are not received.
+	Subscribe(buffer int, policy DropPolicy) *Subscription[T]
 	Stop()
 	MarshalJSON() ([]byte, error)
 	UnmarshalJSON(b []byte) error
@@ -74,24 +94,31 @@ type KV[T any] interface {
 type store[T any] struct {
 	onExpire func(k string, v T)
 
-	stop               chan struct{}
-	stopOnce           sync.Once
-	expirationInterval time.Duration
-	mx                 sync.Mutex
-	kv                 map[string]*entry[T]
-	heap               th
+	stop       chan struct{}
+	stopOnce   sync.Once
+	resolution time.Duration
+	mx         sync.Mutex
+	kv         map[string]*Entry[T]
+	timers     scheduler
+	watchers   Watchers[T]
 }
 
 // New creates a new *store, onExpire is for notification (must be fast).
-func New[T any](expirationInterval time.Duration, onExpire ...func(k string, v T)) KV[T] {
-	if expirationInterval <= 0 {
-		expirationInterval = time.Second * 20
+// The entries are removed at most resolution after their expiration, Resolution if it is not
+// positive. Checking the expirations does not depend on the number of entries.
+func New[T any](resolution time.Duration, onExpire ...func(k string, v T)) KV[T] {
+	if resolution <= 0 {
+		resolution = Resolution
 	}
+	return newStore(newTimingWheel(resolution, time.Now()), resolution, onExpire...)
+}
+
+func newStore[T any](timers scheduler, resolution time.Duration, onExpire ...func(k string, v T)) *store[T] {
 	res := &store[T]{
-		stop:               make(chan struct{}),
-		kv:                 make(map[string]*entry[T]),
-		expirationInterval: expirationInterval,
-		heap:               th{},
+		stop:       make(chan struct{}),
+		kv:         make(map[string]*Entry[T]),
+		resolution: resolution,
+		timers:     timers,
 	}
 	if len(onExpire) > 0 && onExpire[0] != nil {
 		res.onExpire = onExpire[0]
@@ -109,7 +136,11 @@ func (kv *store[T]) Stop() {
 func (kv *store[T]) Delete(k string) {
 	kv.mx.Lock()
 	defer kv.mx.Unlock()
-	delete(kv.kv, k)
+	if e, ok := kv.kv[k]; ok {
+		kv.timers.cancel(e.timeout)
+		delete(kv.kv, k)
+		kv.notify(OpDelete, k, e)
+	}
 }
 
 func (kv *store[T]) Get(k string) (T, bool) {
@@ -123,12 +154,37 @@ func (kv *store[T]) Get(k string) (T, bool) {
 	}
 	if e.expired() {
 		go notifyExpirations(map[string]T{k: e.value}, kv.onExpire)
+		kv.timers.cancel(e.timeout)
 		delete(kv.kv, k)
+		kv.notify(OpExpire, k, e)
 		return zero, false
 	}
 	return e.value, ok
 }
 
+// GetEntry returns a copy of the entry with its expiration
+func (kv *store[T]) GetEntry(k string) (Entry[T], bool) {
+	kv.mx.Lock()
+	defer kv.mx.Unlock()
+
+	e, ok := kv.kv[k]
+	if !ok || e.expired() {
+		return Entry[T]{}, false
+	}
+
+	copied := Entry[T]{
+		value: e.value,
+	}
+	if e.timeout != nil {
+		copied.timeout = &timeout{
+			expiresAt:    e.expiresAt,
+			expiresAfter: e.expiresAfter,
+			key:          k,
+		}
+	}
+	return copied, true
+}
+
 func (kv *store[T]) Keys() (keys []string) {
 	kv.mx.Lock()
 	defer kv.mx.Unlock()
@@ -149,14 +205,14 @@ func (kv *store[T]) Values() (values []T) {
 	return values
 }
 
-func (kv *store[T]) Entries() (entries map[string]entry[T]) {
+func (kv *store[T]) Entries() (entries map[string]Entry[T]) {
 	kv.mx.Lock()
 	defer kv.mx.Unlock()
 
-	entries = make(map[string]entry[T])
+	entries = make(map[string]Entry[T])
 	for k, v := range kv.kv {
 
-		e := entry[T]{
+		e := Entry[T]{
 			value: v.value,
 		}
 		if v.timeout != nil {
@@ -175,26 +231,44 @@ func (kv *store[T]) Entries() (entries map[string]entry[T]) {
 
 // Put puts an entry inside kv store with provided options
 func (kv *store[T]) Put(k string, v T, expiresAfter time.Duration) error {
-	e := &entry[T]{
+	e := &Entry[T]{
 		value: v,
 	}
 	kv.mx.Lock()
 	defer kv.mx.Unlock()
 
+	op := OpPut
+	if previous, ok := kv.kv[k]; ok {
+		kv.timers.cancel(previous.timeout)
+		if !previous.expired() {
+			op = OpRefresh
+		}
+	}
 	e.timeout = newTimeout(k, expiresAfter)
-	timeheapPush(&kv.heap, e.timeout)
+	kv.timers.schedule(e.timeout)
 
 	kv.kv[k] = e
+	kv.notify(op, k, e)
 	return nil
 }
 
+// Subscribe streams the puts, refreshes, deletions and expirations of the entries
+func (kv *store[T]) Subscribe(buffer int, policy DropPolicy) *Subscription[T] {
+	return kv.watchers.Subscribe(buffer, policy)
+}
+
+// notify is called with the lock held, so that the subscribers receive the changes in order
+func (kv *store[T]) notify(op Op, k string, e *Entry[T]) {
+	kv.watchers.Notify(Event[T]{Op: op, Key: k, Value: e.value, ExpiresAt: e.ExpiresAt()})
+}
+
 func (kv *store[T]) MarshalJSON() ([]byte, error) {
 	kv.mx.Lock()
 	defer kv.mx.Unlock()
 	return json.Marshal(kv.kv)
 }
 
-func (e *entry[T]) MarshalJSON() ([]byte, error) {
+func (e *Entry[T]) MarshalJSON() ([]byte, error) {
 	if e.timeout != nil {
 		return json.Marshal(&struct {
 			Value     T         `json:"value"`
@@ -254,84 +328,41 @@ func (e *minimalEntry[T]) UnmarshalJSON(b []byte) error {
 //-----------------------------------------------------------------------------
 
 func (kv *store[T]) expireLoop() {
-	interval := kv.expirationInterval
-	expireTime := time.NewTimer(interval)
+	ticker := time.NewTicker(kv.resolution)
+	defer ticker.Stop()
 	for {
 		select {
 		case <-kv.stop:
 			return
-		case <-expireTime.C:
-			v := kv.expireFunc()
-			if v < 0 {
-				v = -1 * v
-			}
-			if v > 0 && v <= kv.expirationInterval {
-				interval = (2*interval + v) / 3 // good enough history
-			}
-			if interval <= 0 {
-				interval = time.Millisecond
-			}
-			expireTime.Reset(interval)
+		case now := <-ticker.C:
+			kv.expire(now)
 		}
 	}
 }
 
-func (kv *store[T]) expireFunc() time.Duration {
+// expire removes the entries expired at now and notifies their expiration
+func (kv *store[T]) expire(now time.Time) {
 	kv.mx.Lock()
 	defer kv.mx.Unlock()
 
-	var interval time.Duration
-	if len(kv.heap) == 0 {
-		return interval
-	}
 	expired := make(map[string]T)
-	c := -1
-	for {
-		if len(kv.heap) == 0 {
-			break
-		}
-		c++
-		if c >= len(kv.heap) {
-			break
-		}
-		last := kv.heap[0]
-		entry, ok := kv.kv[last.key]
-		if !ok {
-			timeheapPop(&kv.heap)
+	for _, to := range kv.timers.due(now) {
+		entry, ok := kv.kv[to.key]
+		if !ok || entry.timeout != to {
+			// The entry was deleted or refreshed since
 			continue
 		}
-		if !last.expired() {
-			interval = time.Until(last.expiresAt)
-			if interval < 0 {
-				interval = last.expiresAfter
-			}
-			break
-		}
-		last = timeheapPop(&kv.heap)
-		if ok {
-			expired[last.key] = entry.value
-		}
-	}
-REVAL:
-	for k := range expired {
-		newVal, ok := kv.kv[k]
-		if !ok ||
-			newVal.timeout == nil ||
-			!newVal.expired() {
-			delete(expired, k)
-			goto REVAL
+		if !to.expired() {
+			kv.timers.schedule(to)
+			continue
 		}
-		delete(kv.kv, k)
+		expired[to.key] = entry.value
+		delete(kv.kv, to.key)
+		kv.notify(OpExpire, to.key, entry)
 	}
-	go notifyExpirations(expired, kv.onExpire)
-	if interval == 0 && len(kv.heap) > 0 {
-		last := kv.heap[0]
-		interval = time.Until(last.expiresAt)
-		if interval < 0 {
-			interval = last.expiresAfter
-		}
+	if len(expired) > 0 {
+		go notifyExpirations(expired, kv.onExpire)
 	}
-	return interval
 }
 
 func notifyExpirations[T any](
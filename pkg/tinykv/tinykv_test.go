@@ -60,6 +60,23 @@ func TestGetPut(t *testing.T) {
 	assert.NotEqual(2, v)
 }
 
+func TestGetEntry(t *testing.T) {
+	assert := assert.New(t)
+	rg := New[int](0)
+	defer rg.Stop()
+
+	before := time.Now()
+	rg.Put("1", 1, time.Minute*50)
+
+	e, ok := rg.GetEntry("1")
+	assert.True(ok)
+	assert.Equal(1, e.Value())
+	assert.WithinDuration(before.Add(time.Minute*50), e.ExpiresAt(), time.Second)
+
+	_, ok = rg.GetEntry("2")
+	assert.False(ok)
+}
+
 func TestKeys(t *testing.T) {
 	assert := assert.New(t)
 	rg := New[int](0)
@@ -0,0 +1,127 @@
+package tinykv
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Op is the change of an entry notified to the subscribers
+type Op string
+
+const (
+	// OpPut is a put of a key which was not in the store
+	OpPut Op = "put"
+	// OpRefresh is a put of a key which was already in the store
+	OpRefresh Op = "refresh"
+	// OpDelete is a deletion of an entry
+	OpDelete Op = "delete"
+	// OpExpire is an expiration of an entry
+	OpExpire Op = "expire"
+)
+
+// Event is a change of an entry
+type Event[T any] struct {
+	Op        Op
+	Key       string
+	Value     T
+	ExpiresAt time.Time
+}
+
+// DropPolicy tells which event is dropped when the buffer of a subscriber is full
+type DropPolicy int
+
+const (
+	// DropNewest drops the event being notified, the subscriber receives the oldest events
+	DropNewest DropPolicy = iota
+	// DropOldest drops the oldest buffered event, the subscriber receives the latest events
+	DropOldest
+)
+
+// Subscription is the channel receiving the events of a subscriber
+type Subscription[T any] struct {
+	// Events is closed when the subscriber unsubscribes
+	Events <-chan Event[T]
+
+	events      chan Event[T]
+	policy      DropPolicy
+	dropped     atomic.Uint64
+	unsubscribe func()
+}
+
+// Dropped returns the number of events dropped because the subscriber was too slow
+func (s *Subscription[T]) Dropped() uint64 {
+	return s.dropped.Load()
+}
+
+// Unsubscribe stops the notifications and closes Events, it can be called several times
+func (s *Subscription[T]) Unsubscribe() {
+	s.unsubscribe()
+}
+
+// Watchers dispatches the changes of the entries to the subscribers. The zero value is ready to use.
+//
+// Notifying never blocks: when the buffer of a subscriber is full, an event is dropped according to its policy.
+type Watchers[T any] struct {
+	mu          sync.Mutex
+	subscribers map[*Subscription[T]]struct{}
+}
+
+// Subscribe returns a subscription buffering up to buffer events
+func (w *Watchers[T]) Subscribe(buffer int, policy DropPolicy) *Subscription[T] {
+	events := make(chan Event[T], max(buffer, 1))
+	sub := &Subscription[T]{
+		Events: events,
+		events: events,
+		policy: policy,
+	}
+
+	var once sync.Once
+	sub.unsubscribe = func() {
+		once.Do(func() {
+			w.mu.Lock()
+			delete(w.subscribers, sub)
+			w.mu.Unlock()
+			close(sub.events)
+		})
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.subscribers == nil {
+		w.subscribers = make(map[*Subscription[T]]struct{})
+	}
+	w.subscribers[sub] = struct{}{}
+	return sub
+}
+
+// Notify sends the event to all the subscribers
+func (w *Watchers[T]) Notify(event Event[T]) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	for sub := range w.subscribers {
+		sub.send(event)
+	}
+}
+
+func (s *Subscription[T]) send(event Event[T]) {
+	for {
+		select {
+		case s.events <- event:
+			return
+		default:
+		}
+
+		if s.policy == DropNewest {
+			s.dropped.Add(1)
+			return
+		}
+		// Notify is the only sender, the event fits once the oldest one is dropped,
+		// unless the subscriber received it meanwhile
+		select {
+		case <-s.events:
+			s.dropped.Add(1)
+		default:
+		}
+	}
+}
@@ -0,0 +1,143 @@
+package tinykv
+
+import (
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func receive[T any](t *testing.T, events <-chan Event[T]) Event[T] {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event[T]{}
+	}
+}
+
+func TestSubscribe(t *testing.T) {
+	assert := assert.New(t)
+	kv := New[int](time.Millisecond * 10)
+	defer kv.Stop()
+	sub := kv.Subscribe(10, DropNewest)
+	defer sub.Unsubscribe()
+
+	kv.Put("1", 1, time.Minute)
+	kv.Put("1", 2, time.Minute)
+	kv.Delete("1")
+	kv.Delete("1")
+	kv.Put("2", 3, time.Millisecond*10)
+
+	for _, want := range []Event[int]{
+		{Op: OpPut, Key: "1", Value: 1},
+		{Op: OpRefresh, Key: "1", Value: 2},
+		{Op: OpDelete, Key: "1", Value: 2},
+		{Op: OpPut, Key: "2", Value: 3},
+		{Op: OpExpire, Key: "2", Value: 3},
+	} {
+		event := receive(t, sub.Events)
+		assert.Equal(want.Op, event.Op)
+		assert.Equal(want.Key, event.Key)
+		assert.Equal(want.Value, event.Value)
+		assert.False(event.ExpiresAt.IsZero())
+	}
+}
+
+func TestSubscribeSeveralSubscribers(t *testing.T) {
+	assert := assert.New(t)
+	kv := New[int](0)
+	defer kv.Stop()
+	first := kv.Subscribe(1, DropNewest)
+	defer first.Unsubscribe()
+	second := kv.Subscribe(1, DropNewest)
+	defer second.Unsubscribe()
+
+	kv.Put("1", 1, time.Minute)
+
+	assert.Equal("1", receive(t, first.Events).Key)
+	assert.Equal("1", receive(t, second.Events).Key)
+}
+
+func TestSubscribeSlowSubscriber(t *testing.T) {
+	assert := assert.New(t)
+	kv := New[int](0)
+	defer kv.Stop()
+	newest := kv.Subscribe(2, DropNewest)
+	defer newest.Unsubscribe()
+	oldest := kv.Subscribe(2, DropOldest)
+	defer oldest.Unsubscribe()
+
+	// The puts do not wait for the subscribers
+	for i := 1; i <= 5; i++ {
+		kv.Put(strconv.Itoa(i), i, time.Minute)
+	}
+
+	assert.Equal(1, receive(t, newest.Events).Value)
+	assert.Equal(2, receive(t, newest.Events).Value)
+	assert.Equal(uint64(3), newest.Dropped())
+
+	assert.Equal(4, receive(t, oldest.Events).Value)
+	assert.Equal(5, receive(t, oldest.Events).Value)
+	assert.Equal(uint64(3), oldest.Dropped())
+}
+
+func TestUnsubscribe(t *testing.T) {
+	assert := assert.New(t)
+	kv := New[int](0)
+	defer kv.Stop()
+	sub := kv.Subscribe(1, DropNewest)
+
+	sub.Unsubscribe()
+	sub.Unsubscribe()
+	kv.Put("1", 1, time.Minute)
+
+	_, ok := <-sub.Events
+	assert.False(ok)
+}
+
+func TestSubscribeConcurrentMutation(t *testing.T) {
+	assert := assert.New(t)
+	kv := New[int](time.Millisecond)
+	defer kv.Stop()
+
+	const writers, puts = 8, 500
+	fast := kv.Subscribe(writers*puts*2, DropNewest)
+	slow := kv.Subscribe(4, DropOldest)
+
+	var wg sync.WaitGroup
+	for w := 0; w < writers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < puts; i++ {
+				k := strconv.Itoa(w*puts + i)
+				kv.Put(k, i, time.Minute)
+				kv.Delete(k)
+			}
+		}()
+	}
+
+	// Unsubscribing while the entries are mutated
+	time.Sleep(time.Millisecond)
+	slow.Unsubscribe()
+	wg.Wait()
+	fast.Unsubscribe()
+
+	// Each key is put then deleted, in order
+	ops := make(map[string][]Op)
+	for event := range fast.Events {
+		ops[event.Key] = append(ops[event.Key], event.Op)
+	}
+	assert.Equal(uint64(0), fast.Dropped())
+	assert.Len(ops, writers*puts)
+	for k, got := range ops {
+		assert.Equal([]Op{OpPut, OpDelete}, got, k)
+	}
+	for range slow.Events {
+	}
+}
@@ -0,0 +1,169 @@
+package tinykv
+
+import "time"
+
+const (
+	wheelBits   = 6
+	wheelSlots  = 1 << wheelBits
+	wheelMask   = wheelSlots - 1
+	wheelLevels = 5
+)
+
+// scheduler tracks the timeouts of the entries and returns the ones which are due
+type scheduler interface {
+	schedule(to *timeout)
+	cancel(to *timeout)
+	// due removes and returns the timeouts due at now
+	due(now time.Time) []*timeout
+	len() int
+}
+
+//-----------------------------------------------------------------------------
+
+// timeoutList is an intrusive doubly linked list of timeouts
+type timeoutList struct {
+	head *timeout
+}
+
+func (l *timeoutList) push(to *timeout) {
+	to.list = l
+	to.prev = nil
+	to.next = l.head
+	if l.head != nil {
+		l.head.prev = to
+	}
+	l.head = to
+}
+
+func (l *timeoutList) remove(to *timeout) {
+	if to.prev != nil {
+		to.prev.next = to.next
+	} else {
+		l.head = to.next
+	}
+	if to.next != nil {
+		to.next.prev = to.prev
+	}
+	to.list, to.prev, to.next = nil, nil, nil
+}
+
+// take empties the list and returns its first timeout, the others are linked by next
+func (l *timeoutList) take() *timeout {
+	head := l.head
+	l.head = nil
+	return head
+}
+
+//-----------------------------------------------------------------------------
+
+// timingWheel is a hierarchical timing wheel: scheduling and cancelling a timeout are O(1).
+//
+// The level 0 has a slot for each of the next wheelSlots ticks, and each slot of the level n
+// spans wheelSlots times the duration of a slot of the level n-1. When the level 0 completes a
+// rotation, the next slot of the level 1 is cascaded down to the lower levels, and so on.
+// The timeouts beyond the last level are kept in its farthest slot, and are scheduled again
+// when it is cascaded.
+type timingWheel struct {
+	tick  time.Duration
+	start time.Time
+	// base is the next tick to process
+	base   int64
+	levels [wheelLevels][wheelSlots]timeoutList
+	count  int
+}
+
+func newTimingWheel(tick time.Duration, start time.Time) *timingWheel {
+	return &timingWheel{
+		tick:  tick,
+		start: start,
+	}
+}
+
+// ticks returns the first tick at which t has passed
+func (w *timingWheel) ticks(t time.Time) int64 {
+	elapsed := t.Sub(w.start)
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64((elapsed + w.tick - 1) / w.tick)
+}
+
+func (w *timingWheel) schedule(to *timeout) {
+	w.count++
+	w.add(to)
+}
+
+func (w *timingWheel) add(to *timeout) {
+	expires := w.ticks(to.expiresAt)
+	delta := expires - w.base
+	if delta < 0 {
+		expires, delta = w.base, 0
+	}
+
+	for level := 0; level < wheelLevels; level++ {
+		if delta < 1<<(wheelBits*(level+1)) {
+			w.levels[level][(expires>>(wheelBits*level))&wheelMask].push(to)
+			return
+		}
+	}
+
+	expires = w.base + 1<<(wheelBits*wheelLevels) - 1
+	w.levels[wheelLevels-1][(expires>>(wheelBits*(wheelLevels-1)))&wheelMask].push(to)
+}
+
+func (w *timingWheel) cancel(to *timeout) {
+	if to == nil || to.list == nil {
+		return
+	}
+	to.list.remove(to)
+	w.count--
+}
+
+func (w *timingWheel) due(now time.Time) []*timeout {
+	// target is the last tick which has passed
+	target := int64(-1)
+	if elapsed := now.Sub(w.start); elapsed >= 0 {
+		target = int64(elapsed / w.tick)
+	}
+	if w.count == 0 {
+		w.base = max(w.base, target+1)
+		return nil
+	}
+
+	var due []*timeout
+	for ; w.base <= target; w.base++ {
+		index := w.base & wheelMask
+		if index == 0 {
+			w.cascade(1)
+		}
+		for to := w.levels[0][index].take(); to != nil; {
+			next := to.next
+			to.list, to.prev, to.next = nil, nil, nil
+			due = append(due, to)
+			to = next
+		}
+	}
+	w.count -= len(due)
+	return due
+}
+
+// cascade schedules again the timeouts of the current slot of the level, they move to the lower levels
+func (w *timingWheel) cascade(level int) {
+	if level >= wheelLevels {
+		return
+	}
+	index := (w.base >> (wheelBits * level)) & wheelMask
+	if index == 0 {
+		w.cascade(level + 1)
+	}
+	for to := w.levels[level][index].take(); to != nil; {
+		next := to.next
+		to.list, to.prev, to.next = nil, nil, nil
+		w.add(to)
+		to = next
+	}
+}
+
+func (w *timingWheel) len() int {
+	return w.count
+}
@@ -0,0 +1,279 @@
+package tinykv
+
+import (
+	"fmt"
+	"math/rand"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimingWheel(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	tick := time.Millisecond
+	w := newTimingWheel(tick, start)
+	r := rand.New(rand.NewSource(start.Unix()))
+
+	// The timeouts span the first three levels, so that they are cascaded
+	n := 10000
+	timeouts := make(map[*timeout]struct{}, n)
+	for i := 0; i < n; i++ {
+		to := &timeout{key: strconv.Itoa(i), expiresAt: start.Add(time.Duration(r.Intn(300000000)) * time.Microsecond)}
+		w.schedule(to)
+		timeouts[to] = struct{}{}
+	}
+	assert.Equal(n, w.len())
+
+	for now := start; w.len() > 0; now = now.Add(tick) {
+		for _, to := range w.due(now) {
+			_, ok := timeouts[to]
+			assert.True(ok, "%s is due twice", to.key)
+			delete(timeouts, to)
+			assert.False(to.expiresAt.After(now), "%s is due before its expiration", to.key)
+			assert.True(now.Sub(to.expiresAt) < tick, "%s is due late", to.key)
+		}
+	}
+	assert.Empty(timeouts)
+}
+
+func TestTimingWheelCancel(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	w := newTimingWheel(time.Millisecond, start)
+	kept := &timeout{key: "kept", expiresAt: start.Add(time.Second)}
+	cancelled := &timeout{key: "cancelled", expiresAt: start.Add(time.Second)}
+	w.schedule(kept)
+	w.schedule(cancelled)
+
+	w.cancel(cancelled)
+	w.cancel(cancelled)
+
+	assert.Equal(1, w.len())
+	assert.Equal([]*timeout{kept}, w.due(start.Add(time.Second)))
+	assert.Equal(0, w.len())
+}
+
+func TestTimingWheelBeyondLastLevel(t *testing.T) {
+	assert := assert.New(t)
+
+	start := time.Now()
+	w := newTimingWheel(time.Hour, start)
+	// Beyond the range of the wheel, like the pinned sessions
+	to := &timeout{key: "pinned", expiresAt: start.Add(100 * 365 * 24 * time.Hour)}
+	w.schedule(to)
+
+	assert.Empty(w.due(start.Add(10 * 365 * 24 * time.Hour)))
+	assert.Equal(1, w.len())
+}
+
+func TestExpirationLatency(t *testing.T) {
+	assert := assert.New(t)
+	expired := make(chan time.Time, 1)
+	kv := New(time.Millisecond*200, func(k string, v int) {
+		expired <- time.Now()
+	})
+	defer kv.Stop()
+
+	putAt := time.Now()
+	kv.Put("1", 1, time.Millisecond*50)
+
+	select {
+	case at := <-expired:
+		// The entry is removed at most the expiration interval after its expiration
+		assert.WithinDuration(putAt.Add(time.Millisecond*50), at, time.Millisecond*200+time.Millisecond*50)
+	case <-time.After(time.Second):
+		assert.Fail("the entry did not expire within a second")
+	}
+}
+
+func TestRefreshDoesNotGrowTimers(t *testing.T) {
+	assert := assert.New(t)
+	kv := New[int](0).(*store[int])
+	defer kv.Stop()
+
+	for i := 0; i < 1000; i++ {
+		kv.Put("1", i, time.Minute)
+	}
+	kv.Delete("1")
+
+	assert.Equal(0, kv.timers.len())
+}
+
+//-----------------------------------------------------------------------------
+
+var schedulers = []struct {
+	name string
+	new  func() scheduler
+}{
+	{"heap", func() scheduler { return &timeoutHeap{} }},
+	{"wheel", func() scheduler { return newTimingWheel(Resolution, time.Now()) }},
+}
+
+// BenchmarkRefresh refreshes random sessions among a large number of them. With the heap, each
+// refresh pushes a new timeout, the timers metric is the number of timeouts held in the end.
+func BenchmarkRefresh(b *testing.B) {
+	for _, sessions := range []int{1000, 10000, 100000} {
+		for _, scheduler := range schedulers {
+			b.Run(fmt.Sprintf("%s/%d", scheduler.name, sessions), func(b *testing.B) {
+				kv := newStore[int](scheduler.new(), Resolution)
+				defer kv.Stop()
+				keys := make([]string, sessions)
+				for i := range keys {
+					keys[i] = strconv.Itoa(i)
+					kv.Put(keys[i], i, time.Hour)
+				}
+				r := rand.New(rand.NewSource(1))
+
+				b.ResetTimer()
+				for n := 0; n < b.N; n++ {
+					kv.Put(keys[r.Intn(sessions)], n, time.Hour)
+				}
+				b.StopTimer()
+				b.ReportMetric(float64(kv.timers.len()), "timers")
+			})
+		}
+	}
+}
+
+// BenchmarkExpire expires sessions spread over a minute, checked at each resolution tick
+func BenchmarkExpire(b *testing.B) {
+	for _, sessions := range []int{1000, 10000, 100000} {
+		for _, scheduler := range schedulers {
+			b.Run(fmt.Sprintf("%s/%d", scheduler.name, sessions), func(b *testing.B) {
+				for n := 0; n < b.N; n++ {
+					b.StopTimer()
+					timers := scheduler.new()
+					start := time.Now()
+					for i := 0; i < sessions; i++ {
+						timers.schedule(&timeout{expiresAt: start.Add(time.Minute * time.Duration(i) / time.Duration(sessions))})
+					}
+					b.StartTimer()
+
+					for now := start; timers.len() > 0; now = now.Add(Resolution) {
+						timers.due(now)
+					}
+				}
+			})
+		}
+	}
+}
+
+//-----------------------------------------------------------------------------
+
+// The timeout heap below is the former scheduler, kept to compare it with the timing wheel.
+// It is adapted from the container/heap package of the Go standard library.
+
+type tohVal = *timeout
+
+// Any type that implements heap.Interface may be used as a
+// min-heap with the following invariants (established after
+// Init has been called or if the data is empty or sorted):
+//
+//	!h.Less(j, i) for 0 <= i < h.Len() and 2*i+1 <= j <= 2*i+2 and j < h.Len()
+//
+// Note that Push and Pop in this interface are for package heap's
+// implementation to call. To add and remove things from the heap,
+// use heap.Push and heap.Pop.
+type timeheapInterface interface {
+	Len() int
+	Less(i, j int) bool
+	Swap(i, j int)
+	Push(x tohVal) // add x as element Len()
+	Pop() tohVal   // remove and return element Len() - 1.
+}
+
+// Push pushes the element x onto the heap. The complexity is
+// O(log(n)) where n = h.Len().
+func timeheapPush(h timeheapInterface, x tohVal) {
+	h.Push(x)
+	timeheapup(h, h.Len()-1)
+}
+
+// Pop removes the minimum element (according to Less) from the heap
+// and returns it. The complexity is O(log(n)) where n = h.Len().
+// It is equivalent to Remove(h, 0).
+func timeheapPop(h timeheapInterface) tohVal {
+	n := h.Len() - 1
+	h.Swap(0, n)
+	timeheapdown(h, 0, n)
+	return h.Pop()
+}
+
+func timeheapup(h timeheapInterface, j int) {
+	for {
+		i := (j - 1) / 2 // parent
+		if i == j || !h.Less(j, i) {
+			break
+		}
+		h.Swap(i, j)
+		j = i
+	}
+}
+
+func timeheapdown(h timeheapInterface, i0, n int) bool {
+	i := i0
+	for {
+		j1 := 2*i + 1
+		if j1 >= n || j1 < 0 { // j1 < 0 after int overflow
+			break
+		}
+		j := j1 // left child
+		if j2 := j1 + 1; j2 < n && h.Less(j2, j1) {
+			j = j2 // = 2*i + 2  // right child
+		}
+		if !h.Less(j, i) {
+			break
+		}
+		h.Swap(i, j)
+		i = j
+	}
+	return i > i0
+}
+
+//-----------------------------------------------------------------------------
+
+// timeout heap
+type th []*timeout
+
+func (h th) Len() int           { return len(h) }
+func (h th) Less(i, j int) bool { return h[i].expiresAt.Before(h[j].expiresAt) }
+func (h th) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *th) Push(x tohVal)     { *h = append(*h, x) }
+func (h *th) Pop() tohVal {
+	old := *h
+	n := len(old)
+	x := old[n-1]
+	*h = old[0 : n-1]
+	return x
+}
+
+//-----------------------------------------------------------------------------
+
+// timeoutHeap is the former scheduler, a refreshed entry pushes a new timeout and the
+// previous one stays in the heap until it is due
+type timeoutHeap struct {
+	heap th
+}
+
+func (h *timeoutHeap) schedule(to *timeout) {
+	timeheapPush(&h.heap, to)
+}
+
+func (h *timeoutHeap) cancel(*timeout) {}
+
+func (h *timeoutHeap) due(now time.Time) []*timeout {
+	var due []*timeout
+	for len(h.heap) > 0 && !h.heap[0].expiresAt.After(now) {
+		due = append(due, timeheapPop(&h.heap))
+	}
+	return due
+}
+
+func (h *timeoutHeap) len() int {
+	return len(h.heap)
+}